@@ -0,0 +1,134 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/engineapi"
+	"ScriptExecServer/pkg/formatter"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var changeKindTitles = map[string]string{
+	"scope":     "Scopes",
+	"function":  "Functions",
+	"callback":  "Callbacks",
+	"class":     "Classes",
+	"property":  "Properties",
+	"struct":    "Structs",
+	"field":     "Struct fields",
+	"enum":      "Enums",
+	"bitfield":  "Bitfields",
+	"enumvalue": "Enum values",
+}
+
+// RenderApiDiffMarkdown renders the diff as a changelog grouped by the kind of
+// the changed exports.
+func RenderApiDiffMarkdown(diff *engineapi.Diff, title string, headingLevel int) []byte {
+	buf := bytes.NewBufferString("")
+	h := strings.Repeat("#", headingLevel)
+
+	if title != "" {
+		_, _ = fmt.Fprintf(buf, "%s %s\n\n", h, title)
+		h += "#"
+	}
+	_, _ = fmt.Fprintf(buf, "%d added, %d removed, %d changed.\n",
+		diff.Count(engineapi.Added), diff.Count(engineapi.Removed), diff.Count(engineapi.Changed))
+
+	for _, kind := range diff.Kinds() {
+		_, _ = fmt.Fprintf(buf, "\n%s %s\n", h, changeKindTitles[kind])
+
+		for _, t := range []engineapi.ChangeType{engineapi.Added, engineapi.Removed, engineapi.Changed} {
+			header := false
+			for _, c := range diff.Changes {
+				if c.Kind != kind || c.Type != t {
+					continue
+				}
+				if !header {
+					_, _ = fmt.Fprintf(buf, "\n%s# %s\n\n", h, strings.Title(string(t)))
+					header = true
+				}
+
+				switch t {
+				case engineapi.Added:
+					_, _ = fmt.Fprintf(buf, "- `%s`: `%s`\n", c.Name, c.New)
+				case engineapi.Removed:
+					_, _ = fmt.Fprintf(buf, "- `%s`: `%s`\n", c.Name, c.Old)
+				default:
+					_, _ = fmt.Fprintf(buf, "- `%s`: %s\n", c.Name, strings.Join(c.Details, ", "))
+					if c.Old != "" {
+						_, _ = fmt.Fprintf(buf, "  - before: `%s`\n  - after: `%s`\n", c.Old, c.New)
+					}
+				}
+			}
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// RenderApiDiffHugo renders the diff as a content page of the given section.
+func RenderApiDiffHugo(diff *engineapi.Diff, title string, section string) []byte {
+	buf := bytes.NewBufferString("")
+	_, _ = fmt.Fprintf(buf, "---\ntitle: %q\nurl: \"/%s/changelog\"\n---\n\n", title, section)
+	_, _ = buf.Write(RenderApiDiffMarkdown(diff, "", 2))
+	return buf.Bytes()
+}
+
+func RunApiDiff(args []string) error {
+	fs := flag.NewFlagSet("apidiff", flag.ContinueOnError)
+	oldPath := fs.String("old", "", "engine API export of the previous version")
+	newPath := fs.String("new", "", "engine API export of the current version")
+	format := fs.String("format", "markdown", "output format: markdown, json or hugo")
+	output := fs.String("o", "", "file the diff is written to, stdout when empty, hugo defaults to <content>/<section>/changelog.md")
+	title := fs.String("title", "Scripting API changes", "title of the changelog")
+	contentDir := fs.String("content", DefaultConfig().ContentDir, "root folder of the generated pages, used by the hugo format")
+	section := fs.String("section", "scripting", "section the hugo page is written to")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *oldPath == "" || *newPath == "" {
+		return errors.New("both -old and -new are required")
+	}
+
+	oldScope, err := engineapi.ReadEngineApiExportXml(*oldPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read engine API export %s", *oldPath)
+	}
+	newScope, err := engineapi.ReadEngineApiExportXml(*newPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read engine API export %s", *newPath)
+	}
+	diff := engineapi.DiffExports(oldScope, newScope)
+
+	var data []byte
+	switch *format {
+	case "markdown", "md":
+		data = RenderApiDiffMarkdown(diff, *title, 1)
+	case "json":
+		data, err = json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	case "hugo":
+		data = RenderApiDiffHugo(diff, *title, *section)
+		if *output == "" {
+			*output = filepath.Join(*contentDir, *section, "changelog.md")
+		}
+	default:
+		return errors.Errorf("unknown diff format %s", *format)
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return errors.WithStack(err)
+	}
+	_, err = formatter.WriteFileIfChanged(*output, data)
+	return err
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/bindings"
+	"ScriptExecServer/pkg/engineapi"
+	"ScriptExecServer/pkg/formatter"
+	"flag"
+	"github.com/pkg/errors"
+	"os"
+)
+
+func RunBindings(args []string) error {
+	fs := flag.NewFlagSet("bindings", flag.ContinueOnError)
+	opts := bindings.DefaultOptions()
+	input := fs.String("engineapi", "engineApi.xml", "engine API export to generate the bindings from")
+	lang := fs.String("lang", "csharp", "language of the bindings: csharp or c")
+	output := fs.String("o", "", "file the bindings are written to, stdout when empty")
+	fs.StringVar(&opts.Namespace, "namespace", opts.Namespace, "C# namespace, or the include guard prefix of the C header")
+	fs.StringVar(&opts.Library, "library", opts.Library, "native library the C# bindings import from")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	scope, err := engineapi.ReadEngineApiExportXml(*input)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read engine API export %s", *input)
+	}
+
+	var code []byte
+	switch *lang {
+	case "csharp", "cs":
+		code = bindings.GenerateCSharp(scope, opts)
+	case "c":
+		code = bindings.GenerateCHeader(scope, opts)
+	default:
+		return errors.Errorf("unknown binding language %s", *lang)
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(code)
+		return errors.WithStack(err)
+	}
+	_, err = formatter.WriteFileIfChanged(*output, code)
+	return err
+}
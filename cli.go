@@ -0,0 +1,299 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"ScriptExecServer/pkg/formatter"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+type Command struct {
+	Name        string
+	Description string
+	Run         func(args []string) error
+}
+
+type docSetFlags []DocSetConfig
+
+func (d *docSetFlags) String() string {
+	names := make([]string, len(*d))
+	for i, set := range *d {
+		names[i] = set.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func (d *docSetFlags) Set(value string) error {
+	set, err := ParseDocSetFlag(value)
+	if err != nil {
+		return err
+	}
+	*d = append(*d, set)
+	return nil
+}
+
+var commands []Command
+
+func init() {
+	commands = []Command{
+		{
+			Name:        "all",
+			Description: "Convert all doc sets and write the data and menu files (default)",
+			Run:         DocSetCommand("all", RunAll),
+		},
+		{
+			Name:        "convert",
+			Description: "Write the content pages of each doc set in the configured format",
+			Run:         DocSetCommand("convert", RunConvert),
+		},
+		{
+			Name:        "data",
+			Description: "Write the combined doc set data file",
+			Run:         DocSetCommand("data", RunData),
+		},
+		{
+			Name:        "menu",
+			Description: "Write the main menu file",
+			Run:         DocSetCommand("menu", RunMenu),
+		},
+		{
+			Name:        "site",
+			Description: "Write the doc sets as a standalone HTML site that doesn't need Hugo",
+			Run:         DocSetCommand("site", RunSite),
+		},
+		{
+			Name:        "docset",
+			Description: "Write the doc sets as a Dash docset for Dash and Zeal",
+			Run:         DocSetCommand("docset", RunDocset),
+		},
+		{
+			Name:        "lookup",
+			Description: "Print the documentation of a class, function or other name in the terminal",
+			Run:         RunLookupCommand,
+		},
+		{
+			Name:        "links",
+			Description: "Report the external links that aren't in the allowlist or are dead",
+			Run:         RunLinksCommand,
+		},
+		{
+			Name:        "tooling",
+			Description: "Write the TorqueScript completion database and VS Code snippets",
+			Run:         DocSetCommand("tooling", RunTooling),
+		},
+		{
+			Name:        "bindings",
+			Description: "Generate C# or C bindings from the engine API export",
+			Run:         RunBindings,
+		},
+		{
+			Name:        "apidiff",
+			Description: "Write a changelog of the differences between two engine API exports",
+			Run:         RunApiDiff,
+		},
+		{
+			Name:        "serve",
+			Description: "Serve the TorqueScript evaluation API over HTTP",
+			Run:         RunServe,
+		},
+	}
+}
+
+func FindCommand(name string) (*Command, bool) {
+	for i := range commands {
+		if commands[i].Name == name {
+			return &commands[i], true
+		}
+	}
+	return nil, false
+}
+
+func PrintUsage() {
+	_, _ = fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+
+	sorted := make([]Command, len(commands))
+	copy(sorted, commands)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	for _, c := range sorted {
+		_, _ = fmt.Fprintf(os.Stderr, "  %-12s %s\n", c.Name, c.Description)
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
+}
+
+func RunCommand(args []string) error {
+	name := "all"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name = args[0]
+		args = args[1:]
+	}
+
+	if name == "help" {
+		PrintUsage()
+		return nil
+	}
+
+	c, ok := FindCommand(name)
+	if !ok {
+		PrintUsage()
+		return errors.New(fmt.Sprintf("unknown command: %s", name))
+	}
+
+	err := c.Run(args)
+	if err == flag.ErrHelp {
+		return nil
+	}
+	return err
+}
+
+// DocSetCommand wraps a command that operates on the configured doc sets,
+// registering the shared flags and loading every doc set before run is called.
+func DocSetCommand(name string, run func(cfg *Config, sets []*DocSet) error) func(args []string) error {
+	return DocSetArgsCommand(name, nil, func(cfg *Config, sets []*DocSet, args []string) error {
+		return run(cfg, sets)
+	})
+}
+
+// DocSetArgsCommand is DocSetCommand for commands with flags of their own, which
+// flags registers, and arguments, which are passed on to run.
+func DocSetArgsCommand(name string, flags func(fs *flag.FlagSet), run func(cfg *Config, sets []*DocSet, args []string) error) func(args []string) error {
+	return func(args []string) error {
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+
+		var sets docSetFlags
+		configPath := fs.String("config", "", "path to a YAML config file describing the doc sets")
+		contentDir := fs.String("content", "", "root folder for generated pages (default \"hugo/content\")")
+		dataFile := fs.String("data", "", "path of the generated data file (default \"hugo/data/goxygen.json\")")
+		menuFile := fs.String("menu", "", "path of the generated menu file (default \"hugo/data/menu/main.yml\")")
+		fs.Var(&sets, "docset", "doc set as name=input[,output[,section]], may be repeated")
+		workers := fs.Int("workers", runtime.NumCPU(), "number of XML files parsed concurrently")
+		quiet := fs.Bool("quiet", false, "disable progress reporting")
+		lenient := fs.Bool("lenient", false, "skip unknown doxygen elements and attributes with a warning instead of failing")
+		reportPath := fs.String("report", "", "write the parse diagnostics as JSON to this path")
+		incremental := fs.Bool("incremental", false, "only render pages whose input changed since the last run")
+		manifestFile := fs.String("manifest", "", "path of the incremental build manifest (default \"hugo/.goxygen-manifest.json\")")
+		siteDir := fs.String("site", "", "folder the standalone HTML site is written to (default \"site\")")
+		bundle := fs.String("bundle", "", "folder the Dash docset is written to (default \"Torque3D.docset\")")
+		format := fs.String("format", "", "format of the generated pages, one of "+strings.Join(formatter.Formats, ", ")+" (default \"hugo\")")
+		if flags != nil {
+			flags(fs)
+		}
+
+		err := fs.Parse(args)
+		if err != nil {
+			return err
+		}
+
+		cfg := DefaultConfig()
+		if *configPath != "" {
+			cfg, err = ReadConfig(*configPath)
+			if err != nil {
+				return err
+			}
+		}
+		if len(sets) > 0 {
+			cfg.DocSets = sets
+		}
+		if *contentDir != "" {
+			cfg.ContentDir = *contentDir
+		}
+		if *dataFile != "" {
+			cfg.DataFile = *dataFile
+		}
+		if *menuFile != "" {
+			cfg.MenuFile = *menuFile
+		}
+		if *incremental {
+			cfg.Incremental = true
+		}
+		if *manifestFile != "" {
+			cfg.ManifestFile = *manifestFile
+		}
+		if *format != "" {
+			cfg.Format = *format
+		}
+		if *siteDir != "" {
+			cfg.SiteDir = *siteDir
+		}
+		if *bundle != "" {
+			cfg.DocsetBundle = *bundle
+		}
+
+		err = cfg.Validate()
+		if err != nil {
+			return err
+		}
+
+		collector := diagnostics.NewCollector()
+		docSets := make([]*DocSet, len(cfg.DocSets))
+		for i, set := range cfg.DocSets {
+			docSets[i], err = LoadDocSet(set, LoadOptions{
+				Workers:     *workers,
+				Quiet:       *quiet,
+				Lenient:     *lenient,
+				Diagnostics: collector,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !*lenient && collector.Count(diagnostics.Error) > 0 {
+			err = WriteDiagnostics(collector, *reportPath)
+			if err != nil {
+				return err
+			}
+			return errors.New(fmt.Sprintf("%d errors while parsing the doc sets, rerun with -lenient to skip them", collector.Count(diagnostics.Error)))
+		}
+
+		// The problems found while rendering are reported along with the
+		// ones of the parsing.
+		cfg.Diagnostics = collector
+		runErr := run(cfg, docSets, fs.Args())
+		err = WriteDiagnostics(collector, *reportPath)
+		if runErr != nil {
+			return runErr
+		}
+		return err
+	}
+}
+
+// WriteDiagnostics prints a summary of the collected diagnostics and writes the
+// full JSON report to reportPath, if given.
+func WriteDiagnostics(collector *diagnostics.Collector, reportPath string) error {
+	if collector.Len() > 0 {
+		_ = collector.WriteSummary(os.Stderr)
+	}
+
+	if reportPath == "" {
+		return nil
+	}
+
+	f, err := os.Create(reportPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	return errors.WithStack(collector.WriteJSON(f))
+}
+
+func RunAll(cfg *Config, sets []*DocSet) error {
+	err := RunConvert(cfg, sets)
+	if err != nil {
+		return err
+	}
+
+	err = RunData(cfg, sets)
+	if err != nil {
+		return err
+	}
+
+	return RunMenu(cfg, sets)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"ScriptExecServer/pkg/doxygen"
+	"ScriptExecServer/pkg/formatter"
+	"fmt"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type DocSetConfig struct {
+	// Name is the key the doc set is stored under in the data file.
+	Name string `yaml:"name"`
+	// Title is the name of the doc set in the main menu.
+	Title string `yaml:"title,omitempty"`
+	// Input is the folder containing the doxygen XML output.
+	Input string `yaml:"input"`
+	// Output is the folder the generated pages are written to.
+	Output string `yaml:"output,omitempty"`
+	// Section is the URL section the pages are served under.
+	Section string `yaml:"section,omitempty"`
+	// RootDir is the title of the dir compound linked as "Files" in the menu.
+	RootDir string `yaml:"rootdir,omitempty"`
+	// Kinds limits loading to the compounds of these doxygen kinds.
+	Kinds []string `yaml:"kinds,omitempty"`
+	// Names limits loading to the compounds with names matching these patterns.
+	Names []string `yaml:"names,omitempty"`
+	// EngineApi is the engine API export XML merged into the doc set, if any.
+	EngineApi string `yaml:"engineapi,omitempty"`
+}
+
+// ToolingConfig configures the editor tooling written by the tooling command.
+type ToolingConfig struct {
+	// DocSet is the name of the doc set the tooling data is built from.
+	DocSet string `yaml:"docset,omitempty"`
+	// Completion is the path of the completion and signature database, the
+	// JSON schema is written next to it.
+	Completion string `yaml:"completion,omitempty"`
+	// Snippets is the path of the VS Code snippets file.
+	Snippets string `yaml:"snippets,omitempty"`
+}
+
+type Config struct {
+	ContentDir string         `yaml:"content,omitempty"`
+	DataFile   string         `yaml:"data,omitempty"`
+	MenuFile   string         `yaml:"menu,omitempty"`
+	DocSets    []DocSetConfig `yaml:"docsets"`
+
+	// Format is the formatter the pages are rendered with, hugo, markdown, html,
+	// man, text or docset.
+	Format string `yaml:"format,omitempty"`
+
+	// SiteDir is the folder the standalone HTML site is written to.
+	SiteDir   string `yaml:"site,omitempty"`
+	SiteTitle string `yaml:"sitetitle,omitempty"`
+	// DocsetBundle is the .docset folder the Dash docset is written to.
+	DocsetBundle string `yaml:"docsetbundle,omitempty"`
+
+	// Incremental only renders the pages whose input changed since the run
+	// recorded in the manifest file.
+	Incremental  bool   `yaml:"incremental,omitempty"`
+	ManifestFile string `yaml:"manifest,omitempty"`
+
+	Tooling ToolingConfig `yaml:"tooling,omitempty"`
+
+	// Diagnostics receives the problems found while rendering the pages, it
+	// isn't read from the config file.
+	Diagnostics *diagnostics.Collector `yaml:"-"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		ContentDir:   "hugo/content",
+		DataFile:     "hugo/data/goxygen.json",
+		MenuFile:     "hugo/data/menu/main.yml",
+		ManifestFile: "hugo/.goxygen-manifest.json",
+		Format:       "hugo",
+		SiteDir:      "site",
+		SiteTitle:    "Torque3D Documentation",
+		DocsetBundle: "Torque3D.docset",
+		Tooling: ToolingConfig{
+			DocSet:     "scripting",
+			Completion: "tooling/torquescript.json",
+			Snippets:   "tooling/torquescript.code-snippets",
+		},
+		DocSets: []DocSetConfig{
+			{
+				Name:    "coding",
+				Title:   "Coding Reference",
+				Input:   "doxygen/xml",
+				RootDir: "Engine",
+			},
+			{
+				Name:      "scripting",
+				Title:     "Scripting Reference",
+				Input:     "script-doxygen/xml",
+				EngineApi: "engineApi.xml",
+			},
+		},
+	}
+}
+
+func ReadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.DocSets = nil
+	err = yaml.UnmarshalStrict(data, cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse config file %s", path)
+	}
+
+	return cfg, nil
+}
+
+// ParseDocSetFlag parses a doc set given on the command line in the form
+// name=input[,output[,section]].
+func ParseDocSetFlag(value string) (DocSetConfig, error) {
+	eqIdx := strings.Index(value, "=")
+	if eqIdx <= 0 {
+		return DocSetConfig{}, errors.New(fmt.Sprintf("invalid doc set %q, expected name=input[,output[,section]]", value))
+	}
+
+	d := DocSetConfig{
+		Name: value[:eqIdx],
+	}
+	parts := strings.Split(value[eqIdx+1:], ",")
+	d.Input = parts[0]
+	if len(parts) > 1 {
+		d.Output = parts[1]
+	}
+	if len(parts) > 2 {
+		d.Section = parts[2]
+	}
+	if len(parts) > 3 || d.Input == "" {
+		return DocSetConfig{}, errors.New(fmt.Sprintf("invalid doc set %q, expected name=input[,output[,section]]", value))
+	}
+
+	return d, nil
+}
+
+// IncludesCompound reports whether the doc set selects a compound from the index.
+func (d DocSetConfig) IncludesCompound(c doxygen.IndexCompound) bool {
+	if len(d.Kinds) > 0 {
+		found := false
+		for _, kind := range d.Kinds {
+			if kind == c.Kind {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(d.Names) == 0 {
+		return true
+	}
+	for _, pattern := range d.Names {
+		if ok, _ := path.Match(pattern, c.Name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) Validate() error {
+	if len(c.DocSets) == 0 {
+		return errors.New("no doc sets configured")
+	}
+	if c.Format == "" {
+		c.Format = "hugo"
+	}
+	found := false
+	for _, format := range formatter.Formats {
+		if format == c.Format {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.New(fmt.Sprintf("unknown format %s, expected one of %s", c.Format, strings.Join(formatter.Formats, ", ")))
+	}
+
+	names := make(map[string]bool)
+	for i := range c.DocSets {
+		d := &c.DocSets[i]
+		if d.Name == "" {
+			return errors.New(fmt.Sprintf("doc set %d is missing a name", i))
+		}
+		if names[d.Name] {
+			return errors.New(fmt.Sprintf("duplicate doc set name: %s", d.Name))
+		}
+		names[d.Name] = true
+
+		if d.Input == "" {
+			return errors.New(fmt.Sprintf("doc set %s is missing an input folder", d.Name))
+		}
+		if d.Section == "" {
+			d.Section = d.Name
+		}
+		if d.Output == "" {
+			d.Output = filepath.Join(c.ContentDir, d.Section)
+		}
+		for _, pattern := range d.Names {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return errors.Wrapf(err, "invalid name pattern %q in doc set %s", pattern, d.Name)
+			}
+		}
+		if d.Title == "" {
+			d.Title = fmt.Sprintf("%s Reference", strings.Title(d.Name))
+		}
+	}
+
+	return nil
+}
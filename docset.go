@@ -0,0 +1,309 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"ScriptExecServer/pkg/doxygen"
+	"ScriptExecServer/pkg/engineapi"
+	"ScriptExecServer/pkg/formatter"
+	"ScriptExecServer/pkg/goxy"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+type DocSet struct {
+	DocSetConfig
+
+	Index     *doxygen.DoxygenIndex
+	Compounds []*goxy.CompoundDoc
+	Data      GoxygenData
+	// Hashes maps compound ids to the hash of the XML file they were parsed from.
+	Hashes map[string]string
+}
+
+type LoadOptions struct {
+	// Workers is the number of XML files parsed concurrently, defaults to the number of CPUs.
+	Workers int
+	// Quiet disables progress reporting.
+	Quiet bool
+	// Lenient skips unknown doxygen constructs instead of failing the file.
+	Lenient bool
+	// Diagnostics receives the problems found while loading the doc set.
+	Diagnostics *diagnostics.Collector
+}
+
+func LoadDocSet(cfg DocSetConfig, opts LoadOptions) (*DocSet, error) {
+	parseOpts := doxygen.ParseOptions{
+		Workers:     opts.Workers,
+		Lenient:     opts.Lenient,
+		Diagnostics: opts.Diagnostics,
+	}
+	if !opts.Quiet {
+		parseOpts.Progress = ProgressPrinter(cfg.Name)
+	}
+
+	index, err := LoadIndex(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var files <-chan doxygen.ParsedFile
+	if index != nil {
+		files, err = doxygen.StreamDoxygenCompounds(cfg.Input, index.CompoundIds(cfg.IncludesCompound), parseOpts)
+	} else {
+		files, err = doxygen.StreamDoxygenFolder(cfg.Input, parseOpts)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read doc set %s", cfg.Name)
+	}
+
+	compounds := make([]*goxy.CompoundDoc, 0)
+	hashes := make(map[string]string)
+	for f := range files {
+		if f.Err != nil && opts.Diagnostics == nil {
+			log.Printf("Error reading file (%s): %v\n", f.Path, f.Err)
+		}
+		if f.Doc == nil {
+			continue
+		}
+
+		compound, err := goxy.CompoundFromDoxygen(f.Doc)
+		if err != nil {
+			if opts.Diagnostics != nil {
+				opts.Diagnostics.Reportf(diagnostics.Error, f.Path, "unable to convert compound %s: %v", f.Doc.CompoundDef.CompoundName, err)
+			} else {
+				fmt.Println(fmt.Sprintf("unable to parse doxygen compound doc: %v, due to: %v", f.Doc.CompoundDef.CompoundName, err))
+			}
+			continue
+		}
+		compounds = append(compounds, compound)
+		hashes[compound.Id] = f.Hash
+	}
+
+	if cfg.EngineApi != "" {
+		engineCompounds, hash, err := LoadEngineApi(cfg, opts)
+		if err != nil {
+			return nil, err
+		}
+		loaded := len(compounds)
+		var merged []*goxy.CompoundDoc
+		compounds, merged = AddEngineCompounds(compounds, engineCompounds)
+		for _, compound := range compounds[loaded:] {
+			hashes[compound.Id] = hash
+		}
+		// The inner compounds of these come from the export too.
+		for _, compound := range merged {
+			hashes[compound.Id] = hashStrings(hashes[compound.Id], hash)
+		}
+	}
+
+	return &DocSet{
+		DocSetConfig: cfg,
+		Index:        index,
+		Compounds:    compounds,
+		Data:         ExtractCompoundMetadata(compounds, index),
+		Hashes:       hashes,
+	}, nil
+}
+
+// LoadIndex reads the index.xml of a doc set. Doc sets without an index are
+// loaded by listing their input folder, which can't be combined with selecting
+// compounds by kind or name.
+func LoadIndex(cfg DocSetConfig, opts LoadOptions) (*doxygen.DoxygenIndex, error) {
+	indexPath := filepath.Join(cfg.Input, "index.xml")
+	if _, err := os.Stat(indexPath); os.IsNotExist(err) {
+		if len(cfg.Kinds) > 0 || len(cfg.Names) > 0 {
+			return nil, errors.New(fmt.Sprintf("doc set %s selects compounds but has no index.xml", cfg.Name))
+		}
+		if opts.Diagnostics != nil {
+			opts.Diagnostics.Reportf(diagnostics.Info, indexPath, "no index found, parsing every compound file")
+		}
+		return nil, nil
+	}
+
+	index, err := doxygen.ReadIndex(indexPath)
+	if err != nil {
+		if opts.Diagnostics == nil || len(cfg.Kinds) > 0 || len(cfg.Names) > 0 {
+			return nil, errors.Wrapf(err, "unable to read index of doc set %s", cfg.Name)
+		}
+		opts.Diagnostics.Reportf(diagnostics.Error, indexPath, "unable to read index, parsing every compound file: %v", err)
+		return nil, nil
+	}
+	return index, nil
+}
+
+// LoadEngineApi converts the engine API export of a doc set into compounds and
+// returns them with the hash of the export. A missing export is reported, as
+// older doxygen outputs don't include one.
+func LoadEngineApi(cfg DocSetConfig, opts LoadOptions) ([]*goxy.CompoundDoc, string, error) {
+	data, err := ioutil.ReadFile(cfg.EngineApi)
+	if os.IsNotExist(err) {
+		if opts.Diagnostics != nil {
+			opts.Diagnostics.Reportf(diagnostics.Warning, cfg.EngineApi, "engine API export not found, skipping it")
+		} else {
+			log.Printf("Engine API export (%s) not found, skipping it", cfg.EngineApi)
+		}
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+
+	scope, err := engineapi.ReadEngineApiExportXml(cfg.EngineApi)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "unable to read engine API export of doc set %s", cfg.Name)
+	}
+	compounds, err := goxy.CompoundsFromEngineApi(scope)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "unable to convert engine API export of doc set %s", cfg.Name)
+	}
+	return compounds, hashBytes(data), nil
+}
+
+// AddEngineCompounds adds the compounds of the engine API export to the ones
+// loaded from doxygen. Classes, structs and scopes that doxygen already has a
+// compound of the same name for keep that compound, the engine API one is left
+// out and the refs to it point to the doxygen one, which gets its inner
+// compounds. The doxygen compounds that got merged into are returned too.
+func AddEngineCompounds(compounds []*goxy.CompoundDoc, engine []*goxy.CompoundDoc) ([]*goxy.CompoundDoc, []*goxy.CompoundDoc) {
+	byName := make(map[string]*goxy.CompoundDoc)
+	for _, compound := range compounds {
+		switch compound.Kind {
+		case goxy.Class, goxy.Struct, goxy.Namespace:
+			byName[compound.Name] = compound
+		}
+	}
+
+	replaced := make(map[string]*goxy.CompoundDoc)
+	merged := make([]*goxy.CompoundDoc, 0)
+	for _, compound := range engine {
+		if existing, ok := byName[compound.Name]; ok && compound.Id != goxy.EngineApiId {
+			replaced[compound.Id] = existing
+			merged = append(merged, existing)
+		}
+	}
+	remap := func(refs []goxy.InnerCompoundRef) {
+		for i := range refs {
+			if existing, ok := replaced[refs[i].RefId]; ok {
+				refs[i].RefId = existing.Id
+			}
+		}
+	}
+	merge := func(refs []goxy.InnerCompoundRef, inner []goxy.InnerCompoundRef) []goxy.InnerCompoundRef {
+		remap(inner)
+		for _, ref := range inner {
+			known := false
+			for _, r := range refs {
+				known = known || r.RefId == ref.RefId
+			}
+			if !known {
+				refs = append(refs, ref)
+			}
+		}
+		return refs
+	}
+
+	for _, compound := range engine {
+		if existing, ok := replaced[compound.Id]; ok {
+			existing.InnerClasses = merge(existing.InnerClasses, compound.InnerClasses)
+			existing.InnerNamespaces = merge(existing.InnerNamespaces, compound.InnerNamespaces)
+			continue
+		}
+
+		remap(compound.InnerClasses)
+		remap(compound.InnerNamespaces)
+		for i, node := range compound.InheritanceGraph.Nodes {
+			if existing, ok := replaced[node.RefId]; ok {
+				compound.InheritanceGraph.Nodes[i].RefId = existing.Id
+			}
+		}
+		compounds = append(compounds, compound)
+	}
+	return compounds, merged
+}
+
+// ProgressPrinter returns a progress callback that logs roughly every tenth of
+// the files parsed for the named doc set.
+func ProgressPrinter(name string) func(done, total int) {
+	lastPercent := -10
+	return func(done, total int) {
+		percent := done * 100 / total
+		if percent/10 == lastPercent/10 && done != total {
+			return
+		}
+		lastPercent = percent
+		log.Printf("%s: parsed %d/%d files (%d%%)", name, done, total, percent)
+	}
+}
+
+// FormatterDocSets returns the doc sets as the formatters see them.
+func FormatterDocSets(sets []*DocSet) []*formatter.DocSet {
+	fsets := make([]*formatter.DocSet, len(sets))
+	for i, set := range sets {
+		fsets[i] = &formatter.DocSet{
+			Name:      set.Name,
+			Title:     set.Title,
+			Section:   set.Section,
+			Output:    set.Output,
+			RootDir:   set.RootDir,
+			Compounds: set.Compounds,
+			Entities:  set.Data.Entities,
+			Refs:      set.Data.Refs,
+		}
+	}
+	return fsets
+}
+
+// NewFormatter creates the formatter of the configured format for the doc sets,
+// the returned doc sets line up with sets.
+func NewFormatter(cfg *Config, sets []*DocSet) (formatter.Formatter, []*formatter.DocSet, error) {
+	fsets := FormatterDocSets(sets)
+	f, err := formatter.New(cfg.Format, formatter.Options{
+		Dir:         cfg.ContentDir,
+		Title:       cfg.SiteTitle,
+		DataFile:    cfg.DataFile,
+		MenuFile:    cfg.MenuFile,
+		Diagnostics: cfg.Diagnostics,
+	}, fsets)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, fsets, nil
+}
+
+func RunConvert(cfg *Config, sets []*DocSet) error {
+	if cfg.Incremental {
+		return RunIncrementalConvert(cfg, sets)
+	}
+
+	f, fsets, err := NewFormatter(cfg, sets)
+	if err != nil {
+		return err
+	}
+
+	for i, set := range sets {
+		for _, compound := range set.Compounds {
+			err := f.WriteCompound(fsets[i], compound, CompoundOutputPath(set, f, compound))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return f.WriteIndex()
+}
+
+func CompoundOutputPath(set *DocSet, f formatter.Formatter, compound *goxy.CompoundDoc) string {
+	return filepath.Join(set.Output, string(compound.Kind), compound.Id+f.Extension())
+}
+
+func RunData(cfg *Config, sets []*DocSet) error {
+	f, _, err := NewFormatter(cfg, sets)
+	if err != nil {
+		return err
+	}
+	return f.WriteData()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/goxy"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEngineApiPages(t *testing.T) {
+	set, err := LoadDocSet(DocSetConfig{
+		Name:      "scripting",
+		Input:     t.TempDir(),
+		Output:    "scripting",
+		Section:   "scripting",
+		EngineApi: "pkg/engineapi/testdata/export_new.xml",
+	}, LoadOptions{Quiet: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compounds := make(map[string]*goxy.CompoundDoc)
+	for _, c := range set.Compounds {
+		compounds[c.Id] = c
+	}
+
+	tests := []struct {
+		format   string
+		compound string
+		want     []string
+	}{
+		{
+			format:   "markdown",
+			compound: goxy.EngineApiId,
+			want: []string{
+				"# Engine API",
+				"int getRealTime(int scale = 1, int offset)",
+				"void echo(string text, ...)",
+				"## Callbacks",
+				"GFXFormatR8G8B8",
+				"TypeMasks",
+				"[SimObject](",
+				"[Math](",
+			},
+		},
+		{
+			format:   "markdown",
+			compound: "engineapi_class_simobject",
+			want: []string{
+				"# SimObject",
+				"## Properties",
+				"const string",
+				"## Methods",
+				"getId",
+			},
+		},
+		{
+			format:   "hugo",
+			compound: "engineapi_scope_math_scope_vector",
+			want: []string{
+				"title: \"Math::Vector\"",
+				"mDot",
+				"Dot product.",
+				`<span class="n">Point3F</span>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.compound, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Format = tt.format
+			cfg.ContentDir = t.TempDir()
+			f, fsets, err := NewFormatter(cfg, []*DocSet{set})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			compound, ok := compounds[tt.compound]
+			if !ok {
+				t.Fatalf("no compound %s", tt.compound)
+			}
+			content, err := f.Renderer(fsets[0]).RenderCompound(compound)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(content), want) {
+					t.Errorf("page doesn't contain %q:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestAddEngineCompounds(t *testing.T) {
+	doxygen := []*goxy.CompoundDoc{
+		{Id: "class_sim_object", Kind: goxy.Class, Name: "SimObject"},
+		{Id: "consoledoc_8h", Kind: goxy.File, Name: "SceneObject"},
+	}
+	ref := func(id string) goxy.InnerCompoundRef {
+		return goxy.InnerCompoundRef{RefId: id, Protection: goxy.Public}
+	}
+	engine := []*goxy.CompoundDoc{
+		{
+			Id:           goxy.EngineApiId,
+			Kind:         goxy.Namespace,
+			Name:         "Engine API",
+			InnerClasses: []goxy.InnerCompoundRef{ref("engineapi_class_simobject"), ref("engineapi_class_sceneobject")},
+		},
+		{
+			Id:           "engineapi_class_simobject",
+			Kind:         goxy.Class,
+			Name:         "SimObject",
+			InnerClasses: []goxy.InnerCompoundRef{ref("engineapi_class_simobject_struct_state")},
+		},
+		{Id: "engineapi_class_simobject_struct_state", Kind: goxy.Struct, Name: "State"},
+		{
+			Id:   "engineapi_class_sceneobject",
+			Kind: goxy.Class,
+			Name: "SceneObject",
+			InheritanceGraph: goxy.Graph{Nodes: []goxy.GraphNode{
+				{Id: 1, Label: "SceneObject", RefId: "engineapi_class_sceneobject"},
+				{Id: 2, Label: "SimObject", RefId: "engineapi_class_simobject"},
+			}},
+		},
+	}
+
+	compounds, merged := AddEngineCompounds(doxygen, engine)
+
+	ids := make([]string, 0, len(compounds))
+	for _, c := range compounds {
+		ids = append(ids, c.Id)
+	}
+	wantIds := []string{"class_sim_object", "consoledoc_8h", goxy.EngineApiId, "engineapi_class_simobject_struct_state", "engineapi_class_sceneobject"}
+	if !reflect.DeepEqual(ids, wantIds) {
+		t.Errorf("compounds = %v, want %v", ids, wantIds)
+	}
+	if len(merged) != 1 || merged[0].Id != "class_sim_object" {
+		t.Errorf("merged = %v, want the doxygen SimObject", merged)
+	}
+
+	simObject := compounds[0]
+	if want := []goxy.InnerCompoundRef{ref("engineapi_class_simobject_struct_state")}; !reflect.DeepEqual(simObject.InnerClasses, want) {
+		t.Errorf("SimObject inner classes = %v, want %v", simObject.InnerClasses, want)
+	}
+	root := compounds[2]
+	if want := []goxy.InnerCompoundRef{ref("class_sim_object"), ref("engineapi_class_sceneobject")}; !reflect.DeepEqual(root.InnerClasses, want) {
+		t.Errorf("engine API inner classes = %v, want %v", root.InnerClasses, want)
+	}
+	if got := compounds[4].InheritanceGraph.Nodes[1].RefId; got != "class_sim_object" {
+		t.Errorf("SceneObject superclass ref = %s, want class_sim_object", got)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/formatter"
+	"log"
+	"path/filepath"
+)
+
+// RunDocset writes the doc sets as a Dash docset to the bundle folder, with
+// the pages laid out like the standalone HTML site.
+func RunDocset(cfg *Config, sets []*DocSet) error {
+	docsetCfg := *cfg
+	docsetCfg.Format = "docset"
+	docsetCfg.ContentDir = cfg.DocsetBundle
+	docsetCfg.Incremental = false
+
+	documents := formatter.DocsetDocuments(cfg.DocsetBundle)
+	docsetSets := make([]*DocSet, len(sets))
+	for i, set := range sets {
+		docsetSet := *set
+		docsetSet.Output = filepath.Join(documents, set.Section)
+		docsetSets[i] = &docsetSet
+	}
+
+	err := RunAll(&docsetCfg, docsetSets)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Wrote the docset to %s", cfg.DocsetBundle)
+	return nil
+}
@@ -17,6 +17,8 @@ doxygen
 cd /Torque3D/My\ Projects/Stock/game || exit
 cat > ./main.cs <<EOF
 dumpEngineDocs("consoledoc.h");
+\$engineApi = exportEngineAPIToXML();
+\$engineApi.saveFile("engineApi.xml");
 quit();
 EOF
 ./Stock
@@ -28,9 +30,11 @@ mkdir /DoxygenOutput
 ls -la /Torque3D/My\ Projects/Stock/game
 cp -r /Torque3D/My\ Projects/Stock/game/script-doxygen /DoxygenOutput/
 cp -r /Torque3D/doxygen /DoxygenOutput/
+cp /Torque3D/My\ Projects/Stock/game/engineApi.xml /DoxygenOutput/
 
 cd /DoxygenOutput || exit
-/Goxygen/DoxygenConverter
+/Goxygen/DoxygenConverter all -lenient -report /DoxygenOutput/diagnostics.json
+/Goxygen/DoxygenConverter docset -lenient -bundle /DoxygenOutput/Torque3D.docset
 
 mkdir /Hugo
 git clone https://github.com/lukaspj/T3DDocs.git /Hugo/t3ddocs
@@ -45,6 +49,7 @@ export HUGO_ENV='production'
 hugo -v --minify --enableGitInfo
 mkdir static
 zip -9 -r static/offline.zip public/
+tar -czf static/Torque3D.docset.tgz -C /DoxygenOutput Torque3D.docset
 rm config.toml
 mv config.toml.bck config.toml
 printf "\nt3dversion = \"%s\"\n" "${T3D_VERSION}" >> config.toml
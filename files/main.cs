@@ -1,8 +1,25 @@
-if ($Game::argc != 2) {
-    error("Should have exactly one argument, received " @ $Game::argc);
+// Evaluates the script given as the only argument, or with "-file <path>" the
+// contents of that file, which is how the pre-warmed containers receive theirs.
+if ($Game::argc == 3 && $Game::argv[1] $= "-file") {
+    $Eval::file = new FileObject();
+    if (!$Eval::file.openForRead($Game::argv[2])) {
+        error("Unable to read script " @ $Game::argv[2]);
+        $Eval::file.delete();
+        quit();
+    }
+    $Eval::script = "";
+    while (!$Eval::file.isEOF()) {
+        $Eval::script = $Eval::script @ $Eval::file.readLine() @ "\n";
+    }
+    $Eval::file.close();
+    $Eval::file.delete();
+} else if ($Game::argc == 2) {
+    $Eval::script = $Game::argv[1];
+} else {
+    error("Should have exactly one argument or -file <path>, received " @ $Game::argc);
     quit();
 }
 
-echo(eval($Game::argv[1]));
+echo(eval($Eval::script));
 
 quit();
\ No newline at end of file
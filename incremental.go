@@ -0,0 +1,259 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/formatter"
+	"ScriptExecServer/pkg/goxy"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"sync"
+)
+
+type PageManifest struct {
+	// Input is the hash of the compound XML and the metadata resolved for it.
+	Input string `json:"input"`
+	// Dependencies are the ref ids resolved while rendering the page.
+	Dependencies []string `json:"dependencies"`
+	// DependencyHash is the hash of the compounds behind the dependencies.
+	DependencyHash string `json:"dependencyHash"`
+	Output         string `json:"output"`
+	OutputHash     string `json:"outputHash"`
+}
+
+type Manifest struct {
+	Generator string `json:"generator"`
+	// Pages maps doc set names to the pages of their compounds, by compound id.
+	Pages map[string]map[string]PageManifest `json:"pages"`
+}
+
+func NewManifest() *Manifest {
+	return &Manifest{
+		Generator: GeneratorHash(),
+		Pages:     make(map[string]map[string]PageManifest),
+	}
+}
+
+var (
+	generatorOnce sync.Once
+	generatorHash string
+)
+
+// GeneratorHash identifies the build of the generator the pages are rendered
+// with, a manifest written by a different build can't be reused. Everything
+// that goes into a page is compiled into the executable, so the executable is
+// hashed as a whole. It is empty if the executable can't be read, in which
+// case no manifest is reused.
+func GeneratorHash() string {
+	generatorOnce.Do(func() {
+		path, err := os.Executable()
+		if err == nil {
+			generatorHash, err = hashFile(path)
+		}
+		if err != nil {
+			log.Printf("Unable to hash the generator, every page will be rebuilt: %v", err)
+		}
+	})
+	return generatorHash
+}
+
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewManifest(), nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	m := &Manifest{}
+	err = json.Unmarshal(data, m)
+	if err != nil {
+		log.Printf("Ignoring unreadable manifest (%s): %v", path, err)
+		return NewManifest(), nil
+	}
+	if m.Pages == nil {
+		m.Pages = make(map[string]map[string]PageManifest)
+	}
+	if m.Generator == "" || m.Generator != GeneratorHash() {
+		// The outputs are kept so the pages that are gone are still cleaned
+		// up, without hashes none of them is reused.
+		log.Printf("Manifest (%s) was written by a different generator, rebuilding every page", path)
+		m.Generator = GeneratorHash()
+		for _, pages := range m.Pages {
+			for id, page := range pages {
+				pages[id] = PageManifest{Output: page.Output}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *Manifest) Write(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = formatter.WriteFileIfChanged(path, data)
+	return err
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashStrings(values ...string) string {
+	h := sha256.New()
+	for _, v := range values {
+		_, _ = fmt.Fprintf(h, "%d:%s;", len(v), v)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CompoundInputHash combines the hash of the XML a compound was parsed from with
+// the metadata ExtractCompoundMetadata resolved from other compounds.
+func CompoundInputHash(set *DocSet, compound *goxy.CompoundDoc) string {
+	return hashStrings(
+		set.Section,
+		set.Hashes[compound.Id],
+		compound.Parent,
+		compound.Location.FileRefId,
+		compound.Location.BodyFileRefId,
+	)
+}
+
+// DependencyHash hashes the current state of everything a page resolved through
+// the ref table: the ref entries and the XML of the compounds that own them.
+func DependencyHash(set *DocSet, dependencies []string) string {
+	values := make([]string, 0, len(dependencies)*5)
+	for _, id := range dependencies {
+		ref, ok := set.Data.Refs[id]
+		if !ok {
+			values = append(values, id, "")
+			continue
+		}
+
+		owner := id
+		if _, ok := set.Data.Entities[ref.ParentRef]; ok {
+			owner = ref.ParentRef
+		}
+		values = append(values, id, ref.Kind, ref.Name, ref.ParentRef, set.Hashes[owner])
+	}
+	return hashStrings(values...)
+}
+
+func RunIncrementalConvert(cfg *Config, sets []*DocSet) error {
+	previous, err := ReadManifest(cfg.ManifestFile)
+	if err != nil {
+		return err
+	}
+	next := NewManifest()
+
+	f, fsets, err := NewFormatter(cfg, sets)
+	if err != nil {
+		return err
+	}
+
+	rendered, written, removed := 0, 0, 0
+	for i, set := range sets {
+		r := f.Renderer(fsets[i])
+
+		oldPages := previous.Pages[set.Name]
+		pages := make(map[string]PageManifest, len(set.Compounds))
+		outputs := make(map[string]bool, len(set.Compounds))
+		for _, compound := range set.Compounds {
+			output := CompoundOutputPath(set, f, compound)
+			input := CompoundInputHash(set, compound)
+			outputs[output] = true
+
+			if old, ok := oldPages[compound.Id]; ok &&
+				old.Output == output &&
+				old.Input == input &&
+				old.DependencyHash == DependencyHash(set, old.Dependencies) {
+				// Outputs that were deleted or edited by hand are rendered again.
+				if hash, err := hashFile(output); err == nil && hash == old.OutputHash {
+					pages[compound.Id] = old
+					continue
+				}
+			}
+
+			r.StartTracking()
+			content, err := r.RenderCompound(compound)
+			dependencies := r.StopTracking()
+			if err != nil {
+				return err
+			}
+			rendered++
+
+			changed, err := formatter.WriteFileIfChanged(output, content)
+			if err != nil {
+				return err
+			}
+			if changed {
+				written++
+			}
+
+			pages[compound.Id] = PageManifest{
+				Input:          input,
+				Dependencies:   dependencies,
+				DependencyHash: DependencyHash(set, dependencies),
+				Output:         output,
+				OutputHash:     hashBytes(content),
+			}
+		}
+
+		stale := make([]string, 0)
+		for _, old := range oldPages {
+			if !outputs[old.Output] {
+				stale = append(stale, old.Output)
+			}
+		}
+		sort.Strings(stale)
+		for _, path := range stale {
+			err := os.Remove(path)
+			if err != nil && !os.IsNotExist(err) {
+				return errors.WithStack(err)
+			}
+			removed++
+		}
+
+		next.Pages[set.Name] = pages
+	}
+
+	// Doc sets that aren't part of this run keep their pages.
+	for name, pages := range previous.Pages {
+		if _, ok := next.Pages[name]; !ok {
+			next.Pages[name] = pages
+		}
+	}
+
+	log.Printf("Incremental build: rendered %d pages, wrote %d, removed %d stale pages", rendered, written, removed)
+
+	err = f.WriteIndex()
+	if err != nil {
+		return err
+	}
+	return next.Write(cfg.ManifestFile)
+}
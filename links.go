@@ -0,0 +1,246 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"ScriptExecServer/pkg/formatter"
+	"ScriptExecServer/pkg/goxy"
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LinkOptions configures the external link checker.
+type LinkOptions struct {
+	// Allowlist is a file of URL prefixes, one per line, the links may point
+	// to. Without it every link is allowed.
+	Allowlist string
+	// Check requests every allowed link and reports the ones that fail.
+	Check bool
+	// Timeout is the time a request may take before its link counts as dead.
+	Timeout time.Duration
+}
+
+// ExternalLink is a ulink in the documentation of a compound or one of its
+// members.
+type ExternalLink struct {
+	Url      string
+	Set      *DocSet
+	Compound *goxy.CompoundDoc
+	// Member is the name of the member documented with the link, if any.
+	Member string
+}
+
+// linkCheckers is the number of links requested at once.
+const linkCheckers = 8
+
+func RunLinksCommand(args []string) error {
+	opts := LinkOptions{}
+	return DocSetArgsCommand("links", func(fs *flag.FlagSet) {
+		fs.StringVar(&opts.Allowlist, "allowlist", "", "file of URL prefixes, one per line, the links may point to")
+		fs.BoolVar(&opts.Check, "check", false, "request every allowed link and report the dead ones")
+		fs.DurationVar(&opts.Timeout, "timeout", 10*time.Second, "time a request may take before its link counts as dead")
+	}, func(cfg *Config, sets []*DocSet, args []string) error {
+		return RunLinks(sets, opts, os.Stdout)
+	})(args)
+}
+
+// CollectLinks lists the ulinks of every compound of the doc sets, sorted by
+// URL.
+func CollectLinks(sets []*DocSet) []ExternalLink {
+	links := make([]ExternalLink, 0)
+	for _, set := range sets {
+		for _, compound := range set.Compounds {
+			add := func(member string, d goxy.Descriptions) {
+				for _, doc := range []goxy.DocString{d.BriefDescription, d.DetailedDescription, d.InBodyDescription} {
+					goxy.Walk(doc, func(element goxy.DocStringElement) {
+						if u, ok := element.Value.(goxy.DocStringUlink); ok {
+							links = append(links, ExternalLink{
+								Url:      u.Url,
+								Set:      set,
+								Compound: compound,
+								Member:   member,
+							})
+						}
+					})
+				}
+			}
+
+			add("", compound.Descriptions)
+			for _, section := range compound.Sections {
+				add("", goxy.Descriptions{DetailedDescription: section.Description})
+				for _, member := range formatter.Members(section) {
+					add(member.Name, member.Descriptions)
+					for _, value := range member.Values {
+						add(value.Name, value.Descriptions)
+					}
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(links, func(i, j int) bool {
+		return links[i].Url < links[j].Url
+	})
+	return links
+}
+
+// ReadAllowlist reads the URL prefixes of an allowlist, skipping blank lines
+// and comments starting with #.
+func ReadAllowlist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	prefixes := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefixes = append(prefixes, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "unable to read allowlist %s", path)
+	}
+	return prefixes, nil
+}
+
+func allowed(url string, prefixes []string) bool {
+	if prefixes == nil {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(url, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLink requests url and returns why it is dead, or "" if it is alive.
+// Servers that don't answer HEAD requests are asked again with GET.
+func checkLink(client *http.Client, url string) string {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return ""
+	}
+
+	var status int
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			return err.Error()
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err.Error()
+		}
+		_ = resp.Body.Close()
+
+		status = resp.StatusCode
+		if status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+			break
+		}
+	}
+
+	if status >= 400 {
+		return fmt.Sprintf("%d %s", status, http.StatusText(status))
+	}
+	return ""
+}
+
+// linkDiagnostic reports a problem with a link where it is documented.
+func linkDiagnostic(link ExternalLink, severity diagnostics.Severity, message string) diagnostics.Diagnostic {
+	file := link.Compound.Location.File
+	if file == "" {
+		file = link.Set.Name + ":" + link.Compound.Id
+	}
+	element := link.Compound.Name
+	if link.Member != "" {
+		element += "::" + link.Member
+	}
+	return diagnostics.Diagnostic{
+		File:     file,
+		Line:     link.Compound.Location.Line,
+		Element:  element,
+		Severity: severity,
+		Message:  message,
+	}
+}
+
+// RunLinks reports the external links of the doc sets that aren't in the
+// allowlist and, if asked to check them, the dead ones to w.
+func RunLinks(sets []*DocSet, opts LinkOptions, w io.Writer) error {
+	var prefixes []string
+	if opts.Allowlist != "" {
+		var err error
+		prefixes, err = ReadAllowlist(opts.Allowlist)
+		if err != nil {
+			return err
+		}
+	}
+
+	links := CollectLinks(sets)
+	byUrl := make(map[string][]ExternalLink)
+	urls := make([]string, 0)
+	for _, link := range links {
+		if _, ok := byUrl[link.Url]; !ok {
+			urls = append(urls, link.Url)
+		}
+		byUrl[link.Url] = append(byUrl[link.Url], link)
+	}
+
+	collector := diagnostics.NewCollector()
+	checked := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if !allowed(url, prefixes) {
+			for _, link := range byUrl[url] {
+				collector.Add(linkDiagnostic(link, diagnostics.Warning, fmt.Sprintf("link to %s is not in the allowlist", url)))
+			}
+			continue
+		}
+		checked = append(checked, url)
+	}
+
+	if opts.Check {
+		client := &http.Client{Timeout: opts.Timeout}
+		slots := make(chan struct{}, linkCheckers)
+		wg := sync.WaitGroup{}
+		for _, url := range checked {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(url string) {
+				defer wg.Done()
+				defer func() { <-slots }()
+
+				if reason := checkLink(client, url); reason != "" {
+					for _, link := range byUrl[url] {
+						collector.Add(linkDiagnostic(link, diagnostics.Error, fmt.Sprintf("dead link to %s: %s", url, reason)))
+					}
+				}
+			}(url)
+		}
+		wg.Wait()
+	}
+
+	for _, d := range collector.Diagnostics() {
+		_, _ = fmt.Fprintln(w, d.String())
+	}
+	log.Printf("Found %d links to %d URLs", len(links), len(urls))
+
+	if dead := collector.Count(diagnostics.Error); dead > 0 {
+		return errors.New(fmt.Sprintf("%d dead links", dead))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadLinkSet(t *testing.T) *DocSet {
+	t.Helper()
+	set, err := LoadDocSet(DocSetConfig{
+		Name:    "scripting",
+		Input:   "pkg/doxygen/testdata",
+		Output:  "scripting",
+		Section: "scripting",
+	}, LoadOptions{Quiet: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+func TestCollectLinks(t *testing.T) {
+	set := loadLinkSet(t)
+
+	type link struct {
+		Url      string
+		Compound string
+		Member   string
+	}
+	got := make([]link, 0)
+	for _, l := range CollectLinks([]*DocSet{set}) {
+		if l.Set != set {
+			t.Errorf("link to %s has set %v, want %v", l.Url, l.Set, set)
+		}
+		got = append(got, link{l.Url, l.Compound.Id, l.Member})
+	}
+
+	want := []link{
+		{"http://internal.example.com/spec", "classlinker", "link"},
+		{"https://docs.example.org/linker", "classlinker", ""},
+		{"https://mirror.example.org/", "classlinker", "Primary"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CollectLinks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist.txt")
+	err := ioutil.WriteFile(path, []byte("# Documentation hosts\nhttps://docs.example.org/\n\n  https://mirror.example.org/  \n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefixes, err := ReadAllowlist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://docs.example.org/", "https://mirror.example.org/"}; !reflect.DeepEqual(prefixes, want) {
+		t.Fatalf("ReadAllowlist() = %v, want %v", prefixes, want)
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		prefixes []string
+		want     bool
+	}{
+		{name: "no allowlist", url: "http://internal.example.com/spec", want: true},
+		{name: "empty allowlist", url: "https://docs.example.org/linker", prefixes: []string{}, want: false},
+		{name: "prefix", url: "https://docs.example.org/linker", prefixes: prefixes, want: true},
+		{name: "exact", url: "https://mirror.example.org/", prefixes: prefixes, want: true},
+		{name: "other host", url: "https://docs.example.org.evil/", prefixes: []string{"https://docs.example.org/"}, want: false},
+		{name: "other scheme", url: "http://docs.example.org/linker", prefixes: prefixes, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allowed(tt.url, tt.prefixes); got != tt.want {
+				t.Errorf("allowed(%s) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunLinks(t *testing.T) {
+	set := loadLinkSet(t)
+	allowlist := filepath.Join(t.TempDir(), "allowlist.txt")
+	err := ioutil.WriteFile(allowlist, []byte("https://\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts LinkOptions
+		want string
+	}{
+		{name: "no allowlist", opts: LinkOptions{}, want: ""},
+		{
+			name: "allowlist",
+			opts: LinkOptions{Allowlist: allowlist},
+			want: "linker.h:5 (Linker::link): warning: link to http://internal.example.com/spec is not in the allowlist\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			// Without Check no link is requested, so none can be dead.
+			if err := RunLinks([]*DocSet{set}, tt.opts, out); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("RunLinks() wrote %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/formatter"
+	"ScriptExecServer/pkg/goxy"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LookupOptions configures how lookup prints the documentation.
+type LookupOptions struct {
+	// Man prints roff man pages instead of text.
+	Man bool
+	// Color is auto, always or never.
+	Color string
+	// Width is the column the text is wrapped at, 0 disables wrapping.
+	Width int
+}
+
+// LookupMatch is a ref of a doc set matching a looked up name.
+type LookupMatch struct {
+	Set       *DocSet
+	Ref       goxy.CompoundRef
+	Qualified string
+}
+
+// lookupRenderer renders the pages of compounds and members in the terminal.
+type lookupRenderer interface {
+	formatter.Renderer
+	RenderMember(compound *goxy.CompoundDoc, member formatter.MemberDoc) []byte
+}
+
+func RunLookupCommand(args []string) error {
+	opts := LookupOptions{}
+	return DocSetArgsCommand("lookup", func(fs *flag.FlagSet) {
+		fs.BoolVar(&opts.Man, "man", false, "print roff man pages, e.g. for man -l -")
+		fs.StringVar(&opts.Color, "color", "auto", "style the text with ANSI escape codes: auto, always or never")
+		fs.IntVar(&opts.Width, "width", 80, "column the text is wrapped at, 0 disables wrapping")
+		// The progress reports would drown the documentation.
+		_ = fs.Set("quiet", "true")
+	}, func(cfg *Config, sets []*DocSet, names []string) error {
+		return RunLookup(sets, names, opts, os.Stdout)
+	})(args)
+}
+
+// qualifiedName returns the name of a ref prefixed with the name of its
+// compound, if it is a member.
+func qualifiedName(refs map[string]goxy.CompoundRef, ref goxy.CompoundRef) string {
+	if parent, ok := refs[ref.ParentRef]; ok {
+		return parent.Name + "::" + ref.Name
+	}
+	return ref.Name
+}
+
+// normalizeLookupName lower cases a name, TorqueScript names are case
+// insensitive, and accepts both Class.member and Class::member.
+func normalizeLookupName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), ".", "::"))
+}
+
+// FindRefs resolves a name through the refs of the doc sets. Members match by
+// their own name and by their name qualified with their compound's. Compounds
+// are listed before members.
+func FindRefs(sets []*DocSet, name string) []LookupMatch {
+	query := normalizeLookupName(name)
+	matches := make([]LookupMatch, 0)
+	for _, set := range sets {
+		for _, ref := range set.Data.Refs {
+			if ref.Name == "N/A" {
+				continue
+			}
+			qualified := qualifiedName(set.Data.Refs, ref)
+			if strings.ToLower(ref.Name) == query || strings.ToLower(qualified) == query {
+				matches = append(matches, LookupMatch{
+					Set:       set,
+					Ref:       ref,
+					Qualified: qualified,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		iMember, jMember := matches[i].Ref.ParentRef != goxy.NoParentRef, matches[j].Ref.ParentRef != goxy.NoParentRef
+		if iMember != jMember {
+			return !iMember
+		}
+		if matches[i].Qualified != matches[j].Qualified {
+			return matches[i].Qualified < matches[j].Qualified
+		}
+		return matches[i].Ref.RefId < matches[j].Ref.RefId
+	})
+	return matches
+}
+
+// suggestLookupNames returns up to ten qualified names containing name, for
+// when nothing matches it exactly.
+func suggestLookupNames(sets []*DocSet, name string) []string {
+	query := normalizeLookupName(name)
+	found := make(map[string]bool)
+	for _, set := range sets {
+		for _, ref := range set.Data.Refs {
+			if ref.Name == "N/A" {
+				continue
+			}
+			qualified := qualifiedName(set.Data.Refs, ref)
+			if strings.Contains(strings.ToLower(qualified), query) {
+				found[qualified] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for qualified := range found {
+		names = append(names, qualified)
+	}
+	sort.Strings(names)
+	if len(names) > 10 {
+		names = names[:10]
+	}
+	return names
+}
+
+// useColor decides whether the text printed to f is styled.
+func useColor(option string, f *os.File) (bool, error) {
+	switch option {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			return false, nil
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return false, nil
+		}
+		return info.Mode()&os.ModeCharDevice != 0, nil
+	default:
+		return false, errors.New(fmt.Sprintf("unknown color option %s, expected auto, always or never", option))
+	}
+}
+
+// RunLookup prints the documentation of every compound and member matching the
+// names to w.
+func RunLookup(sets []*DocSet, names []string, opts LookupOptions, w *os.File) error {
+	if len(names) == 0 {
+		return errors.New("lookup needs a name, e.g. lookup SimObject::getId")
+	}
+
+	color, err := useColor(opts.Color, w)
+	if err != nil {
+		return err
+	}
+
+	renderers := make(map[*DocSet]lookupRenderer, len(sets))
+	renderer := func(set *DocSet) lookupRenderer {
+		if r, ok := renderers[set]; ok {
+			return r
+		}
+		var r lookupRenderer
+		if opts.Man {
+			r = formatter.NewManFormatter(set.Section, set.Title, set.Data.Entities, set.Data.Refs)
+		} else {
+			r = formatter.NewTerminalFormatter(set.Section, set.Data.Entities, set.Data.Refs, opts.Width, color)
+		}
+		renderers[set] = r
+		return r
+	}
+
+	printed := 0
+	for _, name := range names {
+		matches := FindRefs(sets, name)
+		if len(matches) == 0 {
+			message := fmt.Sprintf("no documentation found for %s", name)
+			if suggestions := suggestLookupNames(sets, name); len(suggestions) > 0 {
+				message += ", did you mean " + strings.Join(suggestions, ", ")
+			}
+			return errors.New(message)
+		}
+
+		for _, match := range matches {
+			if printed > 0 && !opts.Man {
+				_, _ = fmt.Fprint(w, "\n")
+			}
+			err := printLookupMatch(w, renderer(match.Set), match)
+			if err != nil {
+				return err
+			}
+			printed++
+		}
+	}
+	return nil
+}
+
+func printLookupMatch(w io.Writer, r lookupRenderer, match LookupMatch) error {
+	entities := match.Set.Data.Entities
+	if compound, ok := entities[match.Ref.RefId]; ok {
+		content, err := r.RenderCompound(compound)
+		if err != nil {
+			return err
+		}
+		_, _ = w.Write(content)
+		return nil
+	}
+
+	if compound, ok := entities[match.Ref.ParentRef]; ok {
+		if member, ok := formatter.FindMember(compound, match.Ref.RefId); ok {
+			_, _ = w.Write(r.RenderMember(compound, member))
+			return nil
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "%s (%s) is listed in %s, but its documentation wasn't loaded\n", match.Qualified, match.Ref.Kind, match.Set.Title)
+	return nil
+}
@@ -2,13 +2,8 @@ package main
 
 import (
 	"ScriptExecServer/pkg/doxygen"
-	"ScriptExecServer/pkg/formatter"
 	"ScriptExecServer/pkg/goxy"
-	"encoding/json"
 	"fmt"
-	"github.com/pkg/errors"
-	"gopkg.in/yaml.v2"
-	"io/ioutil"
 	"log"
 	"os"
 	"strings"
@@ -19,227 +14,32 @@ type GoxygenData struct {
 	Refs     map[string]goxy.CompoundRef
 }
 
-type GeekdocBundleMenuItem struct {
-	Name string                  `yaml:"name,omitempty"`
-	Ref  string                  `yaml:"ref,omitempty"`
-	Icon string                  `yaml:"icon,omitempty"`
-	Sub  []GeekdocBundleMenuItem `yaml:"sub,omitempty"`
-}
-
-type GeekdocBundleMenu struct {
-	Menus map[string][]GeekdocBundleMenuItem
-}
-
 func main() {
-	/*r := chi.NewRouter()
-
-	if err := http.ListenAndServe(":3000", r); err != nil {
-		log.Fatalf("server crashed with error: %v", err)
-	}
-	 */
-
-	scriptDocs := doxygen.ParseDoxygenFolder("script-doxygen/xml")
-
-	scriptCompounds, scriptData := ExtractDoxygenMetadata(scriptDocs)
-
-	docs := doxygen.ParseDoxygenFolder("doxygen/xml")
-
-	compounds, data := ExtractDoxygenMetadata(docs)
-
-	scriptingFormatter := formatter.NewHugoFormatter("scripting", scriptData.Entities, scriptData.Refs)
-	codingFormatter := formatter.NewHugoFormatter("coding", data.Entities, data.Refs)
-
-	for _, compound := range compounds {
-		err := codingFormatter.WriteCompound(compound, fmt.Sprintf("hugo/content/coding/%s/%s.html", compound.Kind, compound.Id))
-
-		if err != nil {
-			log.Fatalf("Error: %+v", err)
-		}
-	}
-
-	for _, compound := range scriptCompounds {
-		err := scriptingFormatter.WriteCompound(compound, fmt.Sprintf("hugo/content/scripting/%s/%s.html", compound.Kind, compound.Id))
-
-		if err != nil {
-			log.Fatalf("Error: %+v", err)
-		}
-	}
-
-	os.MkdirAll("hugo/data", 0644)
-	bytes, err := json.Marshal(map[string]GoxygenData {
-		"coding": data,
-		"scripting": scriptData,
-	})
-	if err != nil {
-		log.Fatalf("Error: %v", errors.WithStack(err))
-	}
-	err = ioutil.WriteFile("hugo/data/goxygen.json", bytes, 0644)
+	err := RunCommand(os.Args[1:])
 	if err != nil {
-		log.Fatalf("Error: %v", errors.WithStack(err))
-	}
-
-	codingPages := make([]GeekdocBundleMenuItem, 0)
-	for _, compound := range compounds {
-		if compound.Kind == goxy.Page {
-			codingPages = append(codingPages, GeekdocBundleMenuItem{
-				Name: compound.Title,
-				Ref:  fmt.Sprintf("coding/page/%s", compound.Id),
-			})
-		}
-	}
-
-	scriptingPages := make([]GeekdocBundleMenuItem, 0)
-	for _, compound := range scriptCompounds {
-		if compound.Kind == goxy.Page {
-			scriptingPages = append(scriptingPages, GeekdocBundleMenuItem{
-				Name: compound.Title,
-				Ref:  fmt.Sprintf("scripting/page/%s", compound.Id),
-			})
-		}
-	}
-
-	engineGroups := make([]GeekdocBundleMenuItem, 0)
-	var rootDirRefId string
-	for _, compound := range compounds {
-		if compound.Kind == goxy.Group && compound.Parent == "" {
-			group := GeekdocBundleMenuItem{
-				Name: compound.Title,
-				Ref:  fmt.Sprintf("coding/group/%s", compound.Id),
-				Sub:  []GeekdocBundleMenuItem{},
-			}
-
-			for _, inner := range compounds {
-				if inner.Kind == goxy.Group && inner.Parent == compound.Id {
-					group.Sub = append(group.Sub, GeekdocBundleMenuItem{
-						Name: inner.Title,
-						Ref:  fmt.Sprintf("coding/group/%s", inner.Id),
-					},
-					)
-				}
-			}
-
-			engineGroups = append(engineGroups, group)
-		}
-
-		if compound.Kind == goxy.Dir && compound.Title == "Engine" {
-			rootDirRefId = compound.Id
-		}
-	}
-
-	scriptGroups := make([]GeekdocBundleMenuItem, 0)
-	for _, compound := range scriptCompounds {
-		if compound.Kind == goxy.Group && compound.Parent == "" {
-			group := GeekdocBundleMenuItem{
-				Name: compound.Title,
-				Ref:  fmt.Sprintf("scripting/group/%s", compound.Id),
-				Sub:  []GeekdocBundleMenuItem{},
-			}
-
-			for _, inner := range compounds {
-				if inner.Kind == goxy.Group && inner.Parent == compound.Id {
-					group.Sub = append(group.Sub, GeekdocBundleMenuItem{
-						Name: inner.Title,
-						Ref:  fmt.Sprintf("scripting/group/%s", inner.Id),
-					},
-					)
-				}
-			}
-
-			scriptGroups = append(scriptGroups, group)
-		}
+		log.Fatalf("Error: %+v", err)
 	}
-
-	menu := map[string][]GeekdocBundleMenuItem{
-		"main": {
-			{
-				Name: "Coding Reference",
-				Sub: []GeekdocBundleMenuItem{
-					{
-						Name: "Classes",
-						Ref:  "coding/class",
-					},
-					{
-						Name: "Files",
-						Ref:  fmt.Sprintf("coding/dir/%s", rootDirRefId),
-					},
-					{
-						Name: "Groups",
-						Ref:  "coding/group",
-					},
-					{
-						Name: "Namespaces",
-						Ref:  "coding/namespace",
-					},
-					{
-						Name: "Pages",
-						Ref:  "coding/page",
-						Sub:  codingPages,
-					},
-					{
-						Name: "Unions",
-						Ref:  "coding/union",
-					},
-				},
-			},
-			{
-				Name: "Scripting Reference",
-				Sub: []GeekdocBundleMenuItem{
-					{
-						Name: "Classes",
-						Ref:  "scripting/class",
-					},
-					{
-						Name: "Groups",
-						Ref:  "scripting/group",
-					},
-					{
-						Name: "Namespaces",
-						Ref:  "scripting/namespace",
-					},
-					{
-						Name: "Pages",
-						Ref:  "scripting/page",
-						Sub:  codingPages,
-					},
-				},
-			},
-		},
-	}
-
-	os.MkdirAll("hugo/data/menu", 0644)
-	bytes, err = yaml.Marshal(menu)
-	if err != nil {
-		log.Fatalf("Error: %v", errors.WithStack(err))
-	}
-	err = ioutil.WriteFile("hugo/data/menu/main.yml", bytes, 0644)
-	if err != nil {
-		log.Fatalf("Error: %v", errors.WithStack(err))
-	}
-
-	return
 }
 
-func ExtractDoxygenMetadata(docs []*doxygen.Doxygen) ([]*goxy.CompoundDoc, GoxygenData) {
-	compounds := make([]*goxy.CompoundDoc, 0)
-
+func ExtractCompoundMetadata(compounds []*goxy.CompoundDoc, index *doxygen.DoxygenIndex) GoxygenData {
 	data := GoxygenData{
 		Entities: make(map[string]*goxy.CompoundDoc),
 		Refs:     make(map[string]goxy.CompoundRef),
 	}
+	if index != nil {
+		AddRefsFromIndex(data.Refs, index)
+	}
 	files := make(map[string]*goxy.CompoundDoc)
-	for _, doc := range docs {
-		compound, err := goxy.CompoundFromDoxygen(doc)
-		if err != nil {
-			fmt.Println(fmt.Sprintf("unable to parse doxygen compound doc: %v, due to: %v", doc.CompoundDef.CompoundName, err))
-		} else {
-			compounds = append(compounds, compound)
-			if compound.Kind == goxy.File {
-				files[strings.ToLower(compound.Location.File)] = compound
-			}
+	for _, compound := range compounds {
+		if compound.Kind == goxy.File {
+			files[strings.ToLower(compound.Location.File)] = compound
 		}
 	}
+	resolveFile := FileResolver(files)
 
 	for _, compound := range compounds {
+		ResolveIncludes(compound, resolveFile)
+
 		if file, ok := files[strings.ToLower(compound.Location.File)]; ok {
 			compound.Location.FileRefId = file.Id
 		}
@@ -301,7 +101,7 @@ func ExtractDoxygenMetadata(docs []*doxygen.Doxygen) ([]*goxy.CompoundDoc, Goxyg
 		data.Refs[compound.Id] = goxy.CompoundRef{
 			Kind:      string(compound.Kind),
 			Name:      compound.Name,
-			ParentRef: "N/D",
+			ParentRef: goxy.NoParentRef,
 			RefId:     compound.Id,
 		}
 
@@ -382,7 +182,52 @@ func ExtractDoxygenMetadata(docs []*doxygen.Doxygen) ([]*goxy.CompoundDoc, Goxyg
 		}
 	}
 
-	return compounds, data
+	return data
+}
+
+// AddRefsFromIndex seeds the ref table with every compound and member in the
+// index, so refs into compounds that weren't loaded still resolve. Entries for
+// the loaded compounds are overwritten once they are walked.
+func AddRefsFromIndex(refs map[string]goxy.CompoundRef, index *doxygen.DoxygenIndex) {
+	for _, compound := range index.Compounds {
+		compoundId := strings.ToLower(compound.RefId)
+		kind, err := goxy.KindFromDoxygen(compound.Kind)
+		if err != nil {
+			kind = goxy.Kind(compound.Kind)
+		}
+
+		refs[compoundId] = goxy.CompoundRef{
+			Kind:      string(kind),
+			Name:      compound.Name,
+			ParentRef: goxy.NoParentRef,
+			RefId:     compoundId,
+		}
+	}
+
+	for _, compound := range index.Compounds {
+		compoundId := strings.ToLower(compound.RefId)
+		for _, member := range compound.Members {
+			memberId := strings.ToLower(member.RefId)
+
+			// Members are listed under every compound they appear in, prefer the
+			// compound that defines them, whose id prefixes the member id.
+			owner := strings.HasPrefix(memberId, compoundId+"_1")
+			if _, ok := refs[memberId]; ok && !owner {
+				continue
+			}
+
+			kind := member.Kind
+			if kind == "variable" {
+				kind = "attribute"
+			}
+			refs[memberId] = goxy.CompoundRef{
+				Kind:      kind,
+				Name:      member.Name,
+				ParentRef: compoundId,
+				RefId:     memberId,
+			}
+		}
+	}
 }
 
 func AddRefsFromDescriptions(refs map[string]goxy.CompoundRef, id string, d goxy.Descriptions) {
@@ -391,6 +236,57 @@ func AddRefsFromDescriptions(refs map[string]goxy.CompoundRef, id string, d goxy
 	AddRefsFromDocstring(refs, id, d.InBodyDescription)
 }
 
+// FileResolver returns a function finding the file compound an include names,
+// by its path or, for includes relative to an include dir, the end of its path.
+// Names matching more than one file aren't resolved.
+func FileResolver(files map[string]*goxy.CompoundDoc) func(name string) string {
+	byBase := make(map[string][]string)
+	for path := range files {
+		base := path[strings.LastIndex(path, "/")+1:]
+		byBase[base] = append(byBase[base], path)
+	}
+
+	return func(name string) string {
+		name = strings.TrimPrefix(strings.ToLower(strings.ReplaceAll(name, "\\", "/")), "./")
+		if name == "" {
+			return ""
+		}
+		if file, ok := files[name]; ok {
+			return file.Id
+		}
+
+		found := ""
+		for _, path := range byBase[name[strings.LastIndex(name, "/")+1:]] {
+			if strings.HasSuffix(path, "/"+name) {
+				if found != "" {
+					return ""
+				}
+				found = files[path].Id
+			}
+		}
+		return found
+	}
+}
+
+// ResolveIncludes links the includes of a file, and the nodes of its include
+// graphs, that doxygen left without a ref to the file compounds they name.
+func ResolveIncludes(compound *goxy.CompoundDoc, resolveFile func(name string) string) {
+	for _, includes := range [][]goxy.IncludeRef{compound.Includes, compound.IncludedBy} {
+		for i := range includes {
+			if includes[i].RefId == "" {
+				includes[i].RefId = resolveFile(includes[i].Name)
+			}
+		}
+	}
+	for _, graph := range []goxy.Graph{compound.IncludeGraph, compound.IncludedByGraph} {
+		for i := range graph.Nodes {
+			if graph.Nodes[i].RefId == "" {
+				graph.Nodes[i].RefId = resolveFile(graph.Nodes[i].Label)
+			}
+		}
+	}
+}
+
 func AddRefsFromDocstring(refs map[string]goxy.CompoundRef, id string, doc goxy.DocString) {
 	for _, element := range doc.Content {
 		switch element.Type {
@@ -473,12 +369,43 @@ func AddRefsFromDocstring(refs map[string]goxy.CompoundRef, id string, doc goxy.
 		case goxy.Highlight:
 			v := element.Value.(goxy.DocStringHighlight)
 			AddRefsFromDocstring(refs, id, v.Content)
+		case goxy.CodeLine:
+			v := element.Value.(goxy.DocStringCodeLine)
+			AddRefsFromDocstring(refs, id, v.Content)
+		case goxy.CodeHighlight:
+			v := element.Value.(goxy.DocStringCodeHighlight)
+			AddRefsFromDocstring(refs, id, v.Content)
 		case goxy.Ref:
 			v := element.Value.(goxy.DocStringRef)
 			AddRefsFromDocstring(refs, id, v.Content)
+		case goxy.Ulink:
+			v := element.Value.(goxy.DocStringUlink)
+			AddRefsFromDocstring(refs, id, v.Content)
+		case goxy.BlockQuote:
+			v := element.Value.(goxy.DocStringBlockQuote)
+			AddRefsFromDocstring(refs, id, v.Content)
+		case goxy.Details:
+			v := element.Value.(goxy.DocStringDetails)
+			AddRefsFromDocstring(refs, id, v.Summary)
+			AddRefsFromDocstring(refs, id, v.Content)
+		case goxy.Strike:
+			v := element.Value.(goxy.DocStringStrike)
+			AddRefsFromDocstring(refs, id, v.Content)
+		case goxy.Underline:
+			v := element.Value.(goxy.DocStringUnderline)
+			AddRefsFromDocstring(refs, id, v.Content)
+		case goxy.Subscript:
+			v := element.Value.(goxy.DocStringSubscript)
+			AddRefsFromDocstring(refs, id, v.Content)
+		case goxy.Superscript:
+			v := element.Value.(goxy.DocStringSuperscript)
+			AddRefsFromDocstring(refs, id, v.Content)
 		case goxy.Image:
 		case goxy.Text:
 		case goxy.LineBreak:
+		case goxy.HorizontalRule:
+		case goxy.Formula:
+		case goxy.Diagram:
 		default:
 			fmt.Printf("unhandled docstring ref: %s\n", element.Type)
 		}
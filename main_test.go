@@ -0,0 +1,83 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/goxy"
+	"reflect"
+	"testing"
+)
+
+func TestFileResolver(t *testing.T) {
+	files := map[string]*goxy.CompoundDoc{
+		"/src/engine/console/console.h": {Id: "console_8h"},
+		"/src/engine/gfx/gfxdevice.h":   {Id: "gfxdevice_8h"},
+		"/src/engine/gfx/util.h":        {Id: "gfx_2util_8h"},
+		"/src/engine/math/util.h":       {Id: "math_2util_8h"},
+		"config.h":                      {Id: "config_8h"},
+	}
+	resolve := FileResolver(files)
+
+	tests := []struct {
+		name    string
+		include string
+		want    string
+	}{
+		{name: "exact", include: "/src/engine/console/console.h", want: "console_8h"},
+		{name: "exact without dir", include: "config.h", want: "config_8h"},
+		{name: "case", include: "Console/Console.h", want: "console_8h"},
+		{name: "suffix", include: "console/console.h", want: "console_8h"},
+		{name: "base name", include: "gfxDevice.h", want: "gfxdevice_8h"},
+		{name: "dot prefix", include: "./gfx/gfxDevice.h", want: "gfxdevice_8h"},
+		{name: "dot prefix without dir", include: "./config.h", want: "config_8h"},
+		{name: "backslash", include: "gfx\\util.h", want: "gfx_2util_8h"},
+		{name: "ambiguous", include: "util.h", want: ""},
+		{name: "partial dir", include: "fx/util.h", want: ""},
+		{name: "outside", include: "stdio.h", want: ""},
+		{name: "empty", include: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolve(tt.include); got != tt.want {
+				t.Errorf("resolve(%q) = %q, want %q", tt.include, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveIncludes(t *testing.T) {
+	resolve := FileResolver(map[string]*goxy.CompoundDoc{
+		"/src/engine/console/console.h": {Id: "console_8h"},
+		"/src/engine/gfx/gfxdevice.h":   {Id: "gfxdevice_8h"},
+	})
+	compound := &goxy.CompoundDoc{
+		Includes: []goxy.IncludeRef{
+			{Name: "console/console.h", Local: true},
+			{Name: "gfx/gfxDevice.h", RefId: "kept"},
+			{Name: "stdio.h"},
+		},
+		IncludedBy: []goxy.IncludeRef{{Name: "gfx\\gfxDevice.h"}},
+		IncludeGraph: goxy.Graph{Nodes: []goxy.GraphNode{
+			{Id: 1, Label: "console/console.h"},
+			{Id: 2, Label: "stdio.h"},
+		}},
+		IncludedByGraph: goxy.Graph{Nodes: []goxy.GraphNode{{Id: 1, Label: "./gfx/gfxDevice.h"}}},
+	}
+	ResolveIncludes(compound, resolve)
+
+	want := &goxy.CompoundDoc{
+		Includes: []goxy.IncludeRef{
+			{Name: "console/console.h", RefId: "console_8h", Local: true},
+			{Name: "gfx/gfxDevice.h", RefId: "kept"},
+			{Name: "stdio.h"},
+		},
+		IncludedBy: []goxy.IncludeRef{{Name: "gfx\\gfxDevice.h", RefId: "gfxdevice_8h"}},
+		IncludeGraph: goxy.Graph{Nodes: []goxy.GraphNode{
+			{Id: 1, Label: "console/console.h", RefId: "console_8h"},
+			{Id: 2, Label: "stdio.h"},
+		}},
+		IncludedByGraph: goxy.Graph{Nodes: []goxy.GraphNode{{Id: 1, Label: "./gfx/gfxDevice.h", RefId: "gfxdevice_8h"}}},
+	}
+	if !reflect.DeepEqual(compound, want) {
+		t.Errorf("ResolveIncludes() = %+v, want %+v", compound, want)
+	}
+}
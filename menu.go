@@ -0,0 +1,9 @@
+package main
+
+func RunMenu(cfg *Config, sets []*DocSet) error {
+	f, _, err := NewFormatter(cfg, sets)
+	if err != nil {
+		return err
+	}
+	return f.WriteMenu()
+}
@@ -0,0 +1,166 @@
+// Package bindings generates language bindings for the functions, enums and
+// structs of an engine API export.
+package bindings
+
+import (
+	"ScriptExecServer/pkg/engineapi"
+	"ScriptExecServer/pkg/goxy"
+	"strings"
+)
+
+type Options struct {
+	// Namespace is the C# namespace, or the prefix of the C include guard.
+	Namespace string
+	// Library is the native library the C# declarations import from.
+	Library string
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Namespace: "Torque3D.Engine",
+		Library:   "Torque3D",
+	}
+}
+
+type typeKind int
+
+const (
+	primitiveType typeKind = iota
+	enumType
+	bitfieldType
+	structType
+	classType
+)
+
+// typeTable knows the kind of every named type in an export, so argument and
+// field types can be mapped to the target language.
+type typeTable map[string]typeKind
+
+func newTypeTable(scope *engineapi.EngineExportScope) typeTable {
+	t := make(typeTable)
+	t.add(scope.Exports)
+	return t
+}
+
+func (t typeTable) add(exports engineapi.Exports) {
+	for _, e := range exports.Enums {
+		t[e.Name] = enumType
+	}
+	for _, b := range exports.Bitfields {
+		t[b.Name] = bitfieldType
+	}
+	for _, s := range exports.Structs {
+		t[s.Name] = structType
+	}
+	for _, c := range exports.Classes {
+		t[c.Name] = classType
+		t.add(c.Exports)
+	}
+	for _, s := range exports.Scopes {
+		t.add(s.Exports)
+	}
+}
+
+func (t typeTable) kind(name string) (typeKind, bool) {
+	k, ok := t[name]
+	return k, ok
+}
+
+// primitive maps the primitive type names of the export to a common spelling.
+func primitive(name string) string {
+	switch strings.TrimSpace(name) {
+	case "void":
+		return "void"
+	case "bool":
+		return "bool"
+	case "char", "S8":
+		return "int8"
+	case "byte", "U8":
+		return "uint8"
+	case "short", "S16":
+		return "int16"
+	case "ushort", "U16":
+		return "uint16"
+	case "int", "S32":
+		return "int32"
+	case "uint", "U32":
+		return "uint32"
+	case "long", "S64":
+		return "int64"
+	case "ulong", "U64":
+		return "uint64"
+	case "float", "F32":
+		return "float32"
+	case "double", "F64":
+		return "float64"
+	case "string", "const char*", "const UTF8*":
+		return "string"
+	default:
+		return ""
+	}
+}
+
+// returnTypeOf returns the return type of a function, functions without one
+// return nothing.
+func returnTypeOf(f engineapi.EngineFunction) string {
+	if strings.TrimSpace(f.ReturnType) == "" {
+		return "void"
+	}
+	return f.ReturnType
+}
+
+// arraySize returns the number of elements of a field or property, 1 if it
+// isn't an array.
+func arraySize(indexedSize string) string {
+	indexedSize = strings.TrimSpace(indexedSize)
+	if indexedSize == "" || indexedSize == "0" {
+		return "1"
+	}
+	return indexedSize
+}
+
+// docs is the plain text of a doc string of the export.
+type docs struct {
+	Summary string
+	Remarks string
+	Params  map[string]string
+	Returns string
+}
+
+func parseDocs(text string) docs {
+	d := docs{
+		Params: make(map[string]string),
+	}
+	descriptions := goxy.DescriptionsFromEngineDocs(text)
+	d.Summary = goxy.PlainText(descriptions.BriefDescription)
+
+	remarks := make([]string, 0)
+	for _, element := range descriptions.DetailedDescription.Content {
+		switch e := element.Value.(type) {
+		case goxy.DocStringParameterList:
+			for _, item := range e.Items {
+				d.Params[item.Name] = goxy.PlainText(item.Description)
+			}
+		case goxy.DocStringSection:
+			if e.Kind == "return" {
+				d.Returns = goxy.PlainText(e.Content)
+			} else {
+				remarks = append(remarks, goxy.PlainText(e.Content))
+			}
+		case goxy.DocStringHighlight:
+			// Examples are TorqueScript, which doesn't help binding users.
+		default:
+			remarks = append(remarks, goxy.PlainText(goxy.DocString{Content: []goxy.DocStringElement{element}}))
+		}
+	}
+	d.Remarks = strings.Join(remarks, "\n\n")
+	return d
+}
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
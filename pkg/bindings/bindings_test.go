@@ -0,0 +1,106 @@
+package bindings
+
+import (
+	"ScriptExecServer/pkg/engineapi"
+	"strings"
+	"testing"
+)
+
+func readExport(t *testing.T) *engineapi.EngineExportScope {
+	t.Helper()
+	scope, err := engineapi.ReadEngineApiExportXml("testdata/export.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scope
+}
+
+// checkLines checks that every line of want is a line of code, in the same
+// order, and that none of absent is.
+func checkLines(t *testing.T, code []byte, want []string, absent []string) {
+	t.Helper()
+	lines := strings.Split(string(code), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	next := 0
+	for _, w := range want {
+		found := false
+		for ; next < len(lines); next++ {
+			if lines[next] == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing or out of order: %s\n%s", w, code)
+			return
+		}
+	}
+	for _, a := range absent {
+		for _, l := range lines {
+			if l == a {
+				t.Errorf("unexpected: %s", a)
+			}
+		}
+	}
+}
+
+func TestGenerateCHeader(t *testing.T) {
+	code := GenerateCHeader(readExport(t), DefaultOptions())
+	checkLines(t, code, []string{
+		"#ifndef TORQUE3D_ENGINE_H",
+		"typedef struct SimObject SimObject;",
+		// The enums and structs of every scope and class come before the
+		// functions, the structs after those of their fields.
+		"typedef uint32_t TypeMasks;",
+		"TypeMasks_TerrainObjectType = 4,",
+		"typedef enum ObjectType",
+		"ObjectType_Static,",
+		"} ObjectType;",
+		"typedef struct Point3F",
+		"float yz[2];",
+		"} Point3F;",
+		"typedef struct Box3F",
+		"Point3F minExtents;",
+		"} Box3F;",
+		"* @param object the object",
+		"* @return the bounds",
+		"Box3F fn_getBounds(SimObject* object, bool default_ /* = true */);",
+		"void fn_echo(const char* format, ...);",
+		"/* fn_log is variadic without a named argument, which C can't declare. */",
+		"ObjectType SimObject_getType(SimObject* object);",
+		"typedef bool (*cb_SimObject_onAdd)(SimObject* object);",
+		"float fn_mLength(Point3F v);",
+		"#endif /* TORQUE3D_ENGINE_H */",
+	}, []string{
+		"void fn_log(...);",
+	})
+}
+
+func TestGenerateCSharp(t *testing.T) {
+	code := GenerateCSharp(readExport(t), DefaultOptions())
+	checkLines(t, code, []string{
+		"namespace Torque3D.Engine",
+		"public static partial class EngineApi",
+		"[Flags]",
+		"public enum TypeMasks : uint",
+		"public struct Box3F",
+		"public EngineApi.Math.Point3F minExtents;",
+		"/// <param name=\"object\">the object</param>",
+		"/// <returns>the bounds</returns>",
+		"[DllImport(\"Torque3D\", EntryPoint = \"fn_getBounds\", CallingConvention = CallingConvention.Cdecl)]",
+		"public static extern EngineApi.Box3F getBounds(IntPtr @object, [MarshalAs(UnmanagedType.U1)] bool @default = true);",
+		"// echo is variadic, which P/Invoke can't import.",
+		"public static partial class SimObject",
+		"public enum ObjectType",
+		"public static extern EngineApi.SimObject.ObjectType getType(IntPtr @this);",
+		"[return: MarshalAs(UnmanagedType.U1)]",
+		"public delegate bool onAddCallback(IntPtr @this);",
+		"public static partial class Math",
+		"[MarshalAs(UnmanagedType.ByValArray, SizeConst = 2)]",
+		"public float[] yz;",
+		"public static extern float mLength(EngineApi.Math.Point3F v);",
+	}, nil)
+}
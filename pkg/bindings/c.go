@@ -0,0 +1,300 @@
+package bindings
+
+import (
+	"ScriptExecServer/pkg/engineapi"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var cKeywords = wordSet(
+	"auto", "break", "case", "char", "const", "continue", "default", "do",
+	"double", "else", "enum", "extern", "float", "for", "goto", "if", "inline",
+	"int", "long", "register", "restrict", "return", "short", "signed",
+	"sizeof", "static", "struct", "switch", "typedef", "union", "unsigned",
+	"void", "volatile", "while", "bool", "class", "delete", "new", "this",
+	"template", "namespace", "private", "protected", "public", "operator",
+)
+
+var cPrimitives = map[string]string{
+	"void":    "void",
+	"bool":    "bool",
+	"int8":    "int8_t",
+	"uint8":   "uint8_t",
+	"int16":   "int16_t",
+	"uint16":  "uint16_t",
+	"int32":   "int32_t",
+	"uint32":  "uint32_t",
+	"int64":   "int64_t",
+	"uint64":  "uint64_t",
+	"float32": "float",
+	"float64": "double",
+	"string":  "const char*",
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+type cGenerator struct {
+	w     *codeWriter
+	types typeTable
+	// structs are the exported structs by name, they are written once the
+	// structs of their fields are.
+	structs map[string]engineapi.EngineStructType
+	written map[string]bool
+}
+
+// GenerateCHeader generates a C header declaring the exported functions by
+// their symbols, with the enums and structs they use. Engine objects are
+// declared as opaque structs, callbacks as function pointer types.
+func GenerateCHeader(scope *engineapi.EngineExportScope, opts Options) []byte {
+	g := &cGenerator{
+		w:       &codeWriter{unit: "    "},
+		types:   newTypeTable(scope),
+		structs: make(map[string]engineapi.EngineStructType),
+		written: make(map[string]bool),
+	}
+	guard := strings.ToUpper(nonIdentifier.ReplaceAllString(opts.Namespace, "_")) + "_H"
+
+	w := g.w
+	w.line("/* Generated from the engine API export, do not edit. */")
+	w.line("#ifndef %s", guard)
+	w.line("#define %s", guard)
+	w.line("")
+	w.line("#include <stdbool.h>")
+	w.line("#include <stdint.h>")
+	w.line("")
+	w.line("#ifdef __cplusplus")
+	w.line("extern \"C\" {")
+	w.line("#endif")
+
+	classes := make([]string, 0)
+	for name, kind := range g.types {
+		if kind == classType {
+			classes = append(classes, name)
+		}
+	}
+	sort.Strings(classes)
+	if len(classes) > 0 {
+		w.line("")
+	}
+	for _, name := range classes {
+		w.line("typedef struct %s %s;", name, name)
+	}
+
+	// Functions can take the enums and structs of any scope, and structs can
+	// hold those of another scope, so all of them are written first.
+	g.collectStructs(scope.Exports)
+	g.enums(scope.Exports)
+	g.structTypes(scope.Exports)
+	g.functions(scope.Exports, "")
+
+	w.line("")
+	w.line("#ifdef __cplusplus")
+	w.line("}")
+	w.line("#endif")
+	w.line("")
+	w.line("#endif /* %s */", guard)
+
+	return w.Bytes()
+}
+
+func (g *cGenerator) collectStructs(exports engineapi.Exports) {
+	for _, s := range exports.Structs {
+		g.structs[s.Name] = s
+	}
+	for _, c := range exports.Classes {
+		g.collectStructs(c.Exports)
+	}
+	for _, s := range exports.Scopes {
+		g.collectStructs(s.Exports)
+	}
+}
+
+func (g *cGenerator) enums(exports engineapi.Exports) {
+	for _, e := range exports.Enums {
+		g.enum(e, false)
+	}
+	for _, b := range exports.Bitfields {
+		g.enum(engineapi.EngineEnumType(b), true)
+	}
+	for _, c := range exports.Classes {
+		g.enums(c.Exports)
+	}
+	for _, s := range exports.Scopes {
+		g.enums(s.Exports)
+	}
+}
+
+func (g *cGenerator) structTypes(exports engineapi.Exports) {
+	for _, s := range exports.Structs {
+		g.structType(s)
+	}
+	for _, c := range exports.Classes {
+		g.structTypes(c.Exports)
+	}
+	for _, s := range exports.Scopes {
+		g.structTypes(s.Exports)
+	}
+}
+
+// functions writes the functions of a scope, or of a class if object is the
+// name of that class.
+func (g *cGenerator) functions(exports engineapi.Exports, object string) {
+	for _, f := range exports.Functions {
+		g.function(f, object)
+	}
+	for _, c := range exports.Classes {
+		g.functions(c.Exports, c.Name)
+	}
+	for _, s := range exports.Scopes {
+		g.functions(s.Exports, "")
+	}
+}
+
+// enum writes an enum, prefixing the values with the name of the enum as C
+// enumerators share a single scope. Bitfields are declared as uint32_t so
+// combined flags stay valid values.
+func (g *cGenerator) enum(e engineapi.EngineEnumType, bitfield bool) {
+	w := g.w
+	w.line("")
+	g.docs(parseDocs(e.Docs), nil)
+	if bitfield {
+		w.line("typedef uint32_t %s;", e.Name)
+		w.open("enum")
+	} else {
+		w.open("typedef enum %s", e.Name)
+	}
+	for _, v := range e.Enums {
+		g.docs(parseDocs(v.Docs), nil)
+		if v.Value != "" {
+			w.line("%s_%s = %s,", e.Name, v.Name, v.Value)
+		} else {
+			w.line("%s_%s,", e.Name, v.Name)
+		}
+	}
+	if bitfield {
+		w.close("};")
+	} else {
+		w.close("} %s;", e.Name)
+	}
+}
+
+// structType writes a struct after the structs of its fields, which C needs
+// to be complete.
+func (g *cGenerator) structType(s engineapi.EngineStructType) {
+	if g.written[s.Name] {
+		return
+	}
+	g.written[s.Name] = true
+	for _, f := range s.Fields {
+		if dependency, ok := g.structs[f.Type]; ok {
+			g.structType(dependency)
+		}
+	}
+
+	w := g.w
+	w.line("")
+	g.docs(parseDocs(s.Docs), nil)
+	w.open("typedef struct %s", s.Name)
+	for _, f := range s.Fields {
+		g.docs(parseDocs(f.Docs), nil)
+		suffix := ""
+		if size := arraySize(f.IndexedSize); size != "1" {
+			suffix = "[" + size + "]"
+		}
+		w.line("%s %s%s;", g.typeName(f.Type), cIdent(f.Name), suffix)
+	}
+	w.close("} %s;", s.Name)
+}
+
+func (g *cGenerator) function(f engineapi.EngineFunction, object string) {
+	w := g.w
+	variadic := engineapi.IsSet(f.IsVariadic)
+	if variadic && object == "" && len(f.Arguments) == 0 {
+		w.line("")
+		w.line("/* %s is variadic without a named argument, which C can't declare. */", f.Symbol)
+		return
+	}
+
+	params := make([]string, 0, len(f.Arguments)+2)
+	names := make([]string, 0, len(f.Arguments)+1)
+	if object != "" {
+		params = append(params, object+"* object")
+		names = append(names, "object")
+	}
+	for _, arg := range f.Arguments {
+		param := g.typeName(arg.Type) + " " + cIdent(arg.Name)
+		if arg.DefaultValue != "" {
+			param += " /* = " + strings.ReplaceAll(arg.DefaultValue, "*/", "* /") + " */"
+		}
+		params = append(params, param)
+		names = append(names, arg.Name)
+	}
+	if variadic {
+		params = append(params, "...")
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+
+	w.line("")
+	g.docs(parseDocs(f.Docs), names)
+	if engineapi.IsSet(f.IsCallback) {
+		w.line("typedef %s (*%s)(%s);", g.typeName(returnTypeOf(f)), f.Symbol, strings.Join(params, ", "))
+		return
+	}
+	w.line("%s %s(%s);", g.typeName(returnTypeOf(f)), f.Symbol, strings.Join(params, ", "))
+}
+
+// typeName maps an engine type to C, engine objects are passed as pointers to
+// their opaque struct.
+func (g *cGenerator) typeName(name string) string {
+	if kind, ok := g.types.kind(name); ok {
+		if kind == classType {
+			return name + "*"
+		}
+		return name
+	}
+
+	p := primitive(name)
+	if p == "" && strings.TrimSpace(name) == "" {
+		return "void*"
+	}
+	if p == "" {
+		return "void* /* " + strings.ReplaceAll(name, "*/", "* /") + " */"
+	}
+	return cPrimitives[p]
+}
+
+func (g *cGenerator) docs(d docs, params []string) {
+	lines := make([]string, 0)
+	if d.Summary != "" {
+		lines = append(lines, "@brief "+d.Summary)
+	}
+	if d.Remarks != "" {
+		lines = append(lines, d.Remarks)
+	}
+	for _, name := range params {
+		if text, ok := d.Params[name]; ok {
+			lines = append(lines, "@param "+cIdent(name)+" "+text)
+		}
+	}
+	if d.Returns != "" {
+		lines = append(lines, "@return "+d.Returns)
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	text := strings.ReplaceAll(strings.Join(lines, "\n"), "*/", "* /")
+	g.w.line("/**")
+	g.w.comment(" *", text)
+	g.w.line(" */")
+}
+
+func cIdent(name string) string {
+	if cKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
@@ -0,0 +1,312 @@
+package bindings
+
+import (
+	"ScriptExecServer/pkg/engineapi"
+	"strconv"
+	"strings"
+)
+
+var csharpKeywords = wordSet(
+	"abstract", "as", "base", "bool", "break", "byte", "case", "catch", "char",
+	"checked", "class", "const", "continue", "decimal", "default", "delegate",
+	"do", "double", "else", "enum", "event", "explicit", "extern", "false",
+	"finally", "fixed", "float", "for", "foreach", "goto", "if", "implicit",
+	"in", "int", "interface", "internal", "is", "lock", "long", "namespace",
+	"new", "null", "object", "operator", "out", "override", "params", "private",
+	"protected", "public", "readonly", "ref", "return", "sbyte", "sealed",
+	"short", "sizeof", "stackalloc", "static", "string", "struct", "switch",
+	"this", "throw", "true", "try", "typeof", "uint", "ulong", "unchecked",
+	"unsafe", "ushort", "using", "virtual", "void", "volatile", "while",
+)
+
+var csharpPrimitives = map[string]string{
+	"void":    "void",
+	"bool":    "bool",
+	"int8":    "sbyte",
+	"uint8":   "byte",
+	"int16":   "short",
+	"uint16":  "ushort",
+	"int32":   "int",
+	"uint32":  "uint",
+	"int64":   "long",
+	"uint64":  "ulong",
+	"float32": "float",
+	"float64": "double",
+	"string":  "string",
+}
+
+type csharpGenerator struct {
+	w     *codeWriter
+	opts  Options
+	types typeTable
+	// qualified maps the enums and structs to their names qualified with the
+	// classes they are nested in.
+	qualified map[string]string
+}
+
+// GenerateCSharp generates P/Invoke declarations for the export. Every scope and
+// class becomes a static class holding the extern declarations of its
+// functions, and the enums and structs exported in it. Engine objects are
+// passed as IntPtr.
+func GenerateCSharp(scope *engineapi.EngineExportScope, opts Options) []byte {
+	g := &csharpGenerator{
+		w:         &codeWriter{unit: "    "},
+		opts:      opts,
+		types:     newTypeTable(scope),
+		qualified: make(map[string]string),
+	}
+	g.qualify("EngineApi", scope.Exports)
+
+	g.w.line("// <auto-generated>")
+	g.w.line("// Generated from the engine API export, do not edit.")
+	g.w.line("// </auto-generated>")
+	g.w.line("using System;")
+	g.w.line("using System.Runtime.InteropServices;")
+	g.w.line("")
+	g.w.open("namespace %s", opts.Namespace)
+	g.class("EngineApi", scope.Docs, "", scope.Exports)
+	g.w.close("}")
+
+	return g.w.Bytes()
+}
+
+func (g *csharpGenerator) qualify(prefix string, exports engineapi.Exports) {
+	for _, e := range exports.Enums {
+		g.qualified[e.Name] = prefix + "." + e.Name
+	}
+	for _, b := range exports.Bitfields {
+		g.qualified[b.Name] = prefix + "." + b.Name
+	}
+	for _, s := range exports.Structs {
+		g.qualified[s.Name] = prefix + "." + s.Name
+	}
+	for _, c := range exports.Classes {
+		g.qualify(prefix+"."+c.Name, c.Exports)
+	}
+	for _, s := range exports.Scopes {
+		g.qualify(prefix+"."+s.Name, s.Exports)
+	}
+}
+
+// class writes the static class of a scope, or of an engine class if object is
+// the name of that class.
+func (g *csharpGenerator) class(name string, text string, object string, exports engineapi.Exports) {
+	w := g.w
+	g.docs(parseDocs(text), nil)
+	w.open("public static partial class %s", csharpIdent(name))
+
+	for _, e := range exports.Enums {
+		g.enum(e, false)
+	}
+	for _, b := range exports.Bitfields {
+		g.enum(engineapi.EngineEnumType(b), true)
+	}
+	for _, s := range exports.Structs {
+		g.structType(s)
+	}
+	for _, f := range exports.Functions {
+		g.function(f, object)
+	}
+	for _, c := range exports.Classes {
+		w.line("")
+		g.class(c.Name, c.Docs, c.Name, c.Exports)
+	}
+	for _, s := range exports.Scopes {
+		w.line("")
+		g.class(s.Name, s.Docs, "", s.Exports)
+	}
+
+	w.close("}")
+}
+
+func (g *csharpGenerator) enum(e engineapi.EngineEnumType, bitfield bool) {
+	w := g.w
+	w.line("")
+	g.docs(parseDocs(e.Docs), nil)
+	if bitfield {
+		w.line("[Flags]")
+		w.open("public enum %s : uint", csharpIdent(e.Name))
+	} else {
+		w.open("public enum %s", csharpIdent(e.Name))
+	}
+	for _, v := range e.Enums {
+		g.docs(parseDocs(v.Docs), nil)
+		if v.Value != "" {
+			w.line("%s = %s,", csharpIdent(v.Name), v.Value)
+		} else {
+			w.line("%s,", csharpIdent(v.Name))
+		}
+	}
+	w.close("}")
+}
+
+func (g *csharpGenerator) structType(s engineapi.EngineStructType) {
+	w := g.w
+	w.line("")
+	g.docs(parseDocs(s.Docs), nil)
+	w.line("[StructLayout(LayoutKind.Sequential)]")
+	w.open("public struct %s", csharpIdent(s.Name))
+	for _, f := range s.Fields {
+		g.docs(parseDocs(f.Docs), nil)
+		t, attr := g.typeName(f.Type, true)
+		if size := arraySize(f.IndexedSize); size != "1" {
+			w.line("[MarshalAs(UnmanagedType.ByValArray, SizeConst = %s)]", size)
+			w.line("public %s[] %s;", t, csharpIdent(f.Name))
+			continue
+		}
+		if attr != "" {
+			w.line("[MarshalAs(UnmanagedType.%s)]", attr)
+		}
+		w.line("public %s %s;", t, csharpIdent(f.Name))
+	}
+	w.close("}")
+}
+
+func (g *csharpGenerator) function(f engineapi.EngineFunction, object string) {
+	w := g.w
+	w.line("")
+	if engineapi.IsSet(f.IsVariadic) {
+		w.line("// %s is variadic, which P/Invoke can't import.", f.Name)
+		return
+	}
+
+	d := parseDocs(f.Docs)
+	params := make([]string, 0, len(f.Arguments)+1)
+	names := make([]string, 0, len(f.Arguments)+1)
+	if object != "" {
+		params = append(params, "IntPtr @this")
+		names = append(names, "this")
+	}
+
+	// Only trailing arguments can be optional.
+	optional := len(f.Arguments)
+	for optional > 0 && g.defaultValue(f.Arguments[optional-1]) != "" {
+		optional--
+	}
+	for i, arg := range f.Arguments {
+		t, attr := g.typeName(arg.Type, false)
+		param := t + " " + csharpIdent(arg.Name)
+		if attr != "" {
+			param = "[MarshalAs(UnmanagedType." + attr + ")] " + param
+		}
+		if i >= optional {
+			param += " = " + g.defaultValue(arg)
+		}
+		params = append(params, param)
+		names = append(names, arg.Name)
+	}
+
+	returnType, returnAttr := g.typeName(returnTypeOf(f), true)
+	if engineapi.IsSet(f.IsCallback) {
+		g.docs(d, names)
+		w.line("[UnmanagedFunctionPointer(CallingConvention.Cdecl)]")
+		if returnAttr != "" {
+			w.line("[return: MarshalAs(UnmanagedType.%s)]", returnAttr)
+		}
+		w.line("public delegate %s %sCallback(%s);", returnType, f.Name, strings.Join(params, ", "))
+		return
+	}
+
+	g.docs(d, names)
+	w.line("[DllImport(%q, EntryPoint = %q, CallingConvention = CallingConvention.Cdecl)]", g.opts.Library, f.Symbol)
+	if returnAttr != "" {
+		w.line("[return: MarshalAs(UnmanagedType.%s)]", returnAttr)
+	}
+	w.line("public static extern %s %s(%s);", returnType, csharpIdent(f.Name), strings.Join(params, ", "))
+}
+
+// typeName maps an engine type to C#, and returns the MarshalAs attribute it
+// needs if any. Strings returned by the engine are owned by it, so they are
+// returned as IntPtr, as are the engine objects.
+func (g *csharpGenerator) typeName(name string, returned bool) (string, string) {
+	if kind, ok := g.types.kind(name); ok {
+		if kind == classType {
+			return "IntPtr", ""
+		}
+		return g.qualified[name], ""
+	}
+
+	switch p := primitive(name); p {
+	case "":
+		return "IntPtr", ""
+	case "bool":
+		return "bool", "U1"
+	case "string":
+		if returned {
+			return "IntPtr", ""
+		}
+		return "string", "LPUTF8Str"
+	default:
+		return csharpPrimitives[p], ""
+	}
+}
+
+// defaultValue returns the C# default value of an argument, or an empty string
+// if it has none or it can't be written as a C# constant.
+func (g *csharpGenerator) defaultValue(arg engineapi.EngineFunctionArgument) string {
+	value := strings.TrimSpace(arg.DefaultValue)
+	if value == "" {
+		return ""
+	}
+
+	switch p := primitive(arg.Type); p {
+	case "bool":
+		switch strings.ToLower(value) {
+		case "true", "1":
+			return "true"
+		case "false", "0":
+			return "false"
+		}
+	case "string":
+		return strconv.Quote(strings.Trim(value, "\""))
+	case "float32", "float64":
+		if _, err := strconv.ParseFloat(strings.TrimSuffix(value, "f"), 64); err == nil {
+			value = strings.TrimSuffix(value, "f")
+			if p == "float32" {
+				return value + "f"
+			}
+			return value
+		}
+	case "", "void":
+	default:
+		if _, err := strconv.ParseInt(value, 0, 64); err == nil {
+			return value
+		}
+	}
+	return ""
+}
+
+func (g *csharpGenerator) docs(d docs, params []string) {
+	w := g.w
+	if d.Summary != "" {
+		w.line("/// <summary>")
+		w.comment("///", xmlEscape(d.Summary))
+		w.line("/// </summary>")
+	}
+	for _, name := range params {
+		if text, ok := d.Params[name]; ok {
+			w.comment("///", "<param name=\""+xmlEscape(name)+"\">"+xmlEscape(text)+"</param>")
+		}
+	}
+	if d.Returns != "" {
+		w.comment("///", "<returns>"+xmlEscape(d.Returns)+"</returns>")
+	}
+	if d.Remarks != "" {
+		w.line("/// <remarks>")
+		w.comment("///", xmlEscape(d.Remarks))
+		w.line("/// </remarks>")
+	}
+}
+
+func csharpIdent(name string) string {
+	if csharpKeywords[name] {
+		return "@" + name
+	}
+	return name
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+
+func xmlEscape(s string) string {
+	return xmlEscaper.Replace(s)
+}
@@ -0,0 +1,49 @@
+package bindings
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// codeWriter writes indented lines of code.
+type codeWriter struct {
+	buf    bytes.Buffer
+	indent int
+	unit   string
+}
+
+func (w *codeWriter) line(format string, args ...interface{}) {
+	text := format
+	if len(args) > 0 {
+		text = fmt.Sprintf(format, args...)
+	}
+	if text != "" {
+		_, _ = fmt.Fprint(&w.buf, strings.Repeat(w.unit, w.indent))
+	}
+	_, _ = fmt.Fprintln(&w.buf, text)
+}
+
+// comment writes text as comment lines starting with prefix.
+func (w *codeWriter) comment(prefix string, text string) {
+	for _, l := range strings.Split(text, "\n") {
+		w.line("%s", strings.TrimRight(prefix+" "+strings.TrimSpace(l), " "))
+	}
+}
+
+// open writes a declaration followed by an opening brace on its own line, and
+// indents the lines after it.
+func (w *codeWriter) open(format string, args ...interface{}) {
+	w.line(format, args...)
+	w.line("{")
+	w.indent++
+}
+
+func (w *codeWriter) close(format string, args ...interface{}) {
+	w.indent--
+	w.line(format, args...)
+}
+
+func (w *codeWriter) Bytes() []byte {
+	return w.buf.Bytes()
+}
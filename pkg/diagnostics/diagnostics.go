@@ -0,0 +1,184 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+type Severity int
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Element  string   `json:"element,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, d.Line)
+	}
+	if d.Element != "" {
+		location = fmt.Sprintf("%s (%s)", location, d.Element)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, d.Severity, d.Message)
+}
+
+// Collector gathers diagnostics from any number of goroutines.
+type Collector struct {
+	mu          sync.Mutex
+	diagnostics []Diagnostic
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		diagnostics: make([]Diagnostic, 0),
+	}
+}
+
+func (c *Collector) Add(d Diagnostic) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diagnostics = append(c.diagnostics, d)
+}
+
+func (c *Collector) Reportf(severity Severity, file string, format string, args ...interface{}) {
+	c.Add(Diagnostic{
+		File:     file,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Diagnostics returns the collected diagnostics ordered by file and line.
+func (c *Collector) Diagnostics() []Diagnostic {
+	c.mu.Lock()
+	r := make([]Diagnostic, len(c.diagnostics))
+	copy(r, c.diagnostics)
+	c.mu.Unlock()
+
+	sort.SliceStable(r, func(i, j int) bool {
+		if r[i].File != r[j].File {
+			return r[i].File < r[j].File
+		}
+		return r[i].Line < r[j].Line
+	})
+	return r
+}
+
+func (c *Collector) Count(severity Severity) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := 0
+	for _, d := range c.diagnostics {
+		if d.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+func (c *Collector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.diagnostics)
+}
+
+// WriteSummary writes the number of diagnostics per severity followed by the
+// most frequent messages, and every error.
+func (c *Collector) WriteSummary(w io.Writer) error {
+	diagnostics := c.Diagnostics()
+
+	_, err := fmt.Fprintf(w, "%d errors, %d warnings, %d infos\n", c.Count(Error), c.Count(Warning), c.Count(Info))
+	if err != nil {
+		return err
+	}
+
+	type messageCount struct {
+		Severity Severity
+		Message  string
+		Count    int
+	}
+	counts := make(map[string]*messageCount)
+	for _, d := range diagnostics {
+		if d.Severity == Error {
+			continue
+		}
+		key := d.Severity.String() + d.Message
+		if mc, ok := counts[key]; ok {
+			mc.Count++
+		} else {
+			counts[key] = &messageCount{d.Severity, d.Message, 1}
+		}
+	}
+	sorted := make([]*messageCount, 0, len(counts))
+	for _, mc := range counts {
+		sorted = append(sorted, mc)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Message < sorted[j].Message
+	})
+	for i, mc := range sorted {
+		if i >= 20 {
+			_, err = fmt.Fprintf(w, "  ... and %d more distinct messages\n", len(sorted)-i)
+			if err != nil {
+				return err
+			}
+			break
+		}
+		_, err = fmt.Fprintf(w, "  %5dx %s: %s\n", mc.Count, mc.Severity, mc.Message)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, d := range diagnostics {
+		if d.Severity != Error {
+			continue
+		}
+		_, err = fmt.Fprintf(w, "  %s\n", d)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Collector) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c.Diagnostics())
+}
@@ -0,0 +1,162 @@
+package doxygen
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// problem is something unknown that was skipped while decoding a file.
+type problem struct {
+	Offset   int64
+	Severity diagnostics.Severity
+	Message  string
+}
+
+// fileDecoder decodes a single file. The problems found in it are collected on
+// the decoder, decode reports or fails on them once the file is done.
+type fileDecoder struct {
+	*xml.Decoder
+	data     []byte
+	problems []problem
+}
+
+func (d *fileDecoder) add(severity diagnostics.Severity, message string) {
+	d.problems = append(d.problems, problem{Offset: d.InputOffset(), Severity: severity, Message: message})
+}
+
+// unknownElement notes an unknown element as a warning and skips it.
+func (d *fileDecoder) unknownElement(message string) error {
+	d.add(diagnostics.Warning, message)
+	return d.Skip()
+}
+
+// unknownAttribute notes an unknown attribute as a warning, the attribute is ignored.
+func (d *fileDecoder) unknownAttribute(message string) {
+	d.add(diagnostics.Warning, message)
+}
+
+// unknownToken notes an unknown token as info, the token is ignored.
+func (d *fileDecoder) unknownToken(message string) {
+	d.add(diagnostics.Info, message)
+}
+
+// locate turns problems into the diagnostics of the file in data. The lines
+// and element paths of all of them are found in a single pass over the file.
+func locate(path string, data []byte, list []problem) []diagnostics.Diagnostic {
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Offset < list[j].Offset
+	})
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	result := make([]diagnostics.Diagnostic, 0, len(list))
+	elements := make([]string, 0)
+	line := 1
+	counted := 0
+	// resolve turns the problems before end into diagnostics, with the
+	// elements that are open at that point.
+	resolve := func(end int64) {
+		for len(result) < len(list) && list[len(result)].Offset < end {
+			p := list[len(result)]
+			offset := int(p.Offset)
+			if offset > len(data) {
+				offset = len(data)
+			}
+			if offset > counted {
+				line += bytes.Count(data[counted:offset], []byte("\n"))
+				counted = offset
+			}
+			result = append(result, diagnostics.Diagnostic{
+				File:     path,
+				Line:     line,
+				Element:  strings.Join(elements, "/"),
+				Severity: p.Severity,
+				Message:  p.Message,
+			})
+		}
+	}
+
+	for len(result) < len(list) {
+		t, err := dec.RawToken()
+		if err != nil {
+			break
+		}
+		// A problem inside a token only sees the elements opened before it.
+		resolve(dec.InputOffset())
+
+		switch tt := t.(type) {
+		case xml.StartElement:
+			elements = append(elements, tt.Name.Local)
+		case xml.EndElement:
+			if len(elements) > 0 {
+				elements = elements[:len(elements)-1]
+			}
+		}
+	}
+	resolve(int64(len(data)) + 1)
+
+	return result
+}
+
+func report(path string, data []byte, opts ParseOptions, list []problem) {
+	if opts.Diagnostics == nil {
+		return
+	}
+	for _, d := range locate(path, data, list) {
+		opts.Diagnostics.Add(d)
+	}
+}
+
+// decode decodes the compound file in data into doc. Unknown elements,
+// attributes and tokens fail the file, unless it is decoded leniently in which
+// case they are reported and skipped.
+func decode(path string, data []byte, doc *Doxygen, opts ParseOptions) error {
+	d := &fileDecoder{
+		Decoder: xml.NewDecoder(bytes.NewReader(data)),
+		data:    data,
+	}
+
+	err := d.root(doc)
+	if err != nil && err != io.EOF {
+		report(path, data, opts, []problem{{Offset: d.InputOffset(), Severity: diagnostics.Error, Message: err.Error()}})
+		return err
+	}
+
+	list := d.problems
+	if len(list) == 0 {
+		return nil
+	}
+	if !opts.Lenient {
+		first := list[0]
+		for _, p := range list[1:] {
+			if p.Offset < first.Offset {
+				first = p
+			}
+		}
+		first.Severity = diagnostics.Error
+		report(path, data, opts, []problem{first})
+		return errors.New(first.Message)
+	}
+
+	report(path, data, opts, list)
+	return nil
+}
+
+// root decodes the root element of the file into doc.
+func (d *fileDecoder) root(doc *Doxygen) error {
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		if start, ok := t.(xml.StartElement); ok {
+			return doc.decode(d, start)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package doxygen
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"reflect"
+	"testing"
+)
+
+const unknownsXML = `<?xml version='1.0' encoding='UTF-8' standalone='no'?>
+<doxygen>
+  <compounddef id="classfoo" kind="class" color="red">
+    <compoundname>foo</compoundname>
+    <briefdescription>
+      <para>A <blink>foo</blink>.</para>
+    </briefdescription>
+    <sectiondef kind="public-func">
+      <memberdef kind="slot" id="classfoo_1a1"><name>bar</name></memberdef>
+    </sectiondef>
+    <detaileddescription>
+      <para wrap="no">Details<!-- a comment --></para>
+    </detaileddescription>
+  </compounddef>
+</doxygen>
+`
+
+func TestDecodeDiagnostics(t *testing.T) {
+	tests := []struct {
+		name    string
+		lenient bool
+		wantErr bool
+		want    []diagnostics.Diagnostic
+	}{
+		{
+			name:    "strict",
+			wantErr: true,
+			want: []diagnostics.Diagnostic{
+				{File: "foo.xml", Line: 6, Element: "doxygen/compounddef/briefdescription/para/blink", Severity: diagnostics.Error, Message: "unknown token `blink` in docstring element"},
+			},
+		},
+		{
+			name:    "lenient",
+			lenient: true,
+			want: []diagnostics.Diagnostic{
+				{File: "foo.xml", Line: 6, Element: "doxygen/compounddef/briefdescription/para/blink", Severity: diagnostics.Warning, Message: "unknown token `blink` in docstring element"},
+				{File: "foo.xml", Line: 9, Element: "doxygen/compounddef/sectiondef/memberdef", Severity: diagnostics.Warning, Message: "unknown member kind: slot"},
+				{File: "foo.xml", Line: 12, Element: "doxygen/compounddef/detaileddescription/para", Severity: diagnostics.Warning, Message: "unknown paragraph attribute: wrap"},
+				{File: "foo.xml", Line: 12, Element: "doxygen/compounddef/detaileddescription/para", Severity: diagnostics.Info, Message: "unknown token type xml.Comment in docstring element"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := diagnostics.NewCollector()
+			doc := &Doxygen{}
+			err := decode("foo.xml", []byte(unknownsXML), doc, ParseOptions{Diagnostics: collector, Lenient: tt.lenient})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := collector.Diagnostics(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diagnostics = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeSyntaxError(t *testing.T) {
+	collector := diagnostics.NewCollector()
+	err := decode("broken.xml", []byte("<doxygen>\n<compounddef>\n<compoundname>foo</oops>"), &Doxygen{}, ParseOptions{Diagnostics: collector, Lenient: true})
+	if err == nil {
+		t.Fatal("decode() of broken XML succeeded")
+	}
+
+	got := collector.Diagnostics()
+	if len(got) != 1 || got[0].Severity != diagnostics.Error || got[0].Line != 3 || got[0].Element != "doxygen/compounddef" {
+		t.Errorf("diagnostics = %+v, want one error on line 3 in doxygen/compounddef", got)
+	}
+}
@@ -1,14 +1,21 @@
 package doxygen
 
 import (
+	"ScriptExecServer/pkg/diagnostics"
 	"ScriptExecServer/pkg/xmlhelper"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 var (
@@ -57,6 +64,11 @@ type Ref struct {
 	Content DocString `xml:",any"`
 }
 
+type Ulink struct {
+	Url     string    `xml:"url,attr"`
+	Content DocString `xml:",any"`
+}
+
 type XRefSect struct {
 	Id          string    `xml:"id,attr"`
 	Title       string    `xml:"xreftitle"`
@@ -102,6 +114,22 @@ type ProgramListing struct {
 	Filename string `xml:"filename,attr"`
 }
 
+// CodeLine is a line of a program listing.
+type CodeLine struct {
+	LineNumber int    `xml:"lineno,attr"`
+	RefId      string `xml:"refid,attr"`
+	RefKind    string `xml:"refkind,attr"`
+	External   string `xml:"external,attr"`
+	Content    DocString
+}
+
+// Highlight is a run of a code line in one highlight class, like keyword or
+// comment.
+type Highlight struct {
+	Class   string `xml:"class,attr"`
+	Content DocString
+}
+
 type Paragraph struct {
 	Content DocString `xml:",any"`
 }
@@ -123,6 +151,63 @@ type Section struct {
 	Id      string    `xml:"id,attr"`
 	Kind    string    `xml:"kind,attr"`
 	Content DocString `xml:",any"`
+	// Level is the N of a sectN element, 0 for simple sections.
+	Level int `xml:"-"`
+}
+
+type BlockQuote struct {
+	Content DocString
+}
+
+// Details is a collapsible block, its Summary is part of the content.
+type Details struct {
+	Content DocString
+}
+
+type Summary struct {
+	Content DocString
+}
+
+type Strike struct {
+	Content DocString
+}
+
+type Underline struct {
+	Content DocString
+}
+
+type Subscript struct {
+	Content DocString
+}
+
+type Superscript struct {
+	Content DocString
+}
+
+type HorizontalRuler struct{}
+
+// Formula is a LaTeX formula, including its delimiters.
+type Formula struct {
+	Id      string `xml:"id,attr"`
+	Content string `xml:",chardata"`
+}
+
+// Diagram is a dot, msc or PlantUML diagram, either inline or from a file.
+type Diagram struct {
+	// Kind is the name of the element, like dot or dotfile.
+	Kind    string `xml:"-"`
+	Name    string `xml:"name,attr"`
+	Caption string `xml:"caption,attr"`
+	Content string `xml:",chardata"`
+}
+
+type TocList struct {
+	Items []TocItem `xml:"tocitem"`
+}
+
+type TocItem struct {
+	Id      string    `xml:"id,attr"`
+	Content DocString `xml:",any"`
 }
 
 type TableEntry struct {
@@ -208,6 +293,10 @@ type CompoundDef struct {
 	InnerDirs       []InnerCompound `xml:"innerdir"`
 
 	InheritanceGraph Graph `xml:"inheritancegraph"`
+	// IncDepGraph is the graph of the files a file includes, InvIncDepGraph
+	// the one of the files including it.
+	IncDepGraph    Graph `xml:"incdepgraph"`
+	InvIncDepGraph Graph `xml:"invincdepgraph"`
 }
 
 type Include struct {
@@ -380,7 +469,7 @@ type FriendMemberDef struct {
 	ReferencedBy []ReferencedBy `xml:"referencedby"`
 }
 
-func (ty *Section) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *Section) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
 		case "id":
@@ -388,14 +477,14 @@ func (ty *Section) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error
 		case "kind":
 			ty.Kind = attr.Value
 		default:
-			return errors.New(fmt.Sprintf("unknown section attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown section attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	return ty.Content.decode(d, start)
 }
 
-func (ty *TableEntry) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *TableEntry) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
 		case "thead":
@@ -404,17 +493,17 @@ func (ty *TableEntry) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) err
 			} else if attr.Value == "no" {
 				ty.TableHead = false
 			} else {
-				return errors.New(fmt.Sprintf("unknown boolean format: %s", attr.Value))
+				d.unknownAttribute(fmt.Sprintf("unknown boolean format: %s", attr.Value))
 			}
 		default:
-			return errors.New(fmt.Sprintf("unknown section attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown section attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	return ty.Content.decode(d, start)
 }
 
-func (ty *Ref) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *Ref) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
 		case "refid":
@@ -422,36 +511,142 @@ func (ty *Ref) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
 		case "kindref":
 			ty.KindRef = attr.Value
 		default:
-			return errors.New(fmt.Sprintf("unknown ref attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown ref attribute: %s", attr.Name.Local))
+		}
+	}
+
+	return ty.Content.decode(d, start)
+}
+
+func (ty *Ulink) decode(d *fileDecoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "url":
+			ty.Url = attr.Value
+		default:
+			d.unknownAttribute(fmt.Sprintf("unknown ulink attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	return ty.Content.decode(d, start)
+}
+
+// decodeContent decodes the content of an element without attributes of its
+// own.
+func decodeContent(d *fileDecoder, start xml.StartElement, content *DocString) error {
+	for _, attr := range start.Attr {
+		d.unknownAttribute(fmt.Sprintf("unknown %s attribute: %s", start.Name.Local, attr.Name.Local))
+	}
+
+	return content.decode(d, start)
+}
+
+func (ty *BlockQuote) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeContent(d, start, &ty.Content)
+}
+
+func (ty *Details) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeContent(d, start, &ty.Content)
 }
 
-func (ty *Paragraph) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *Summary) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeContent(d, start, &ty.Content)
+}
+
+func (ty *Strike) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeContent(d, start, &ty.Content)
+}
+
+func (ty *Underline) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeContent(d, start, &ty.Content)
+}
+
+func (ty *Subscript) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeContent(d, start, &ty.Content)
+}
+
+func (ty *Superscript) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeContent(d, start, &ty.Content)
+}
+
+func (ty *Formula) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
+		case "id":
+			ty.Id = attr.Value
 		default:
-			return errors.New(fmt.Sprintf("unknown paragraph attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown formula attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	var content string
+	err := d.DecodeElement(&content, &start)
+	ty.Content = strings.TrimSpace(content)
+	return err
 }
 
-func (ty *Title) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *Diagram) decode(d *fileDecoder, start xml.StartElement) error {
+	ty.Kind = start.Name.Local
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
+		case "name":
+			ty.Name = attr.Value
+		case "caption":
+			ty.Caption = attr.Value
+		case "width", "height", "engine":
 		default:
-			return errors.New(fmt.Sprintf("unknown title attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown %s attribute: %s", ty.Kind, attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	// The files have their caption as content, the inline diagrams their
+	// source.
+	var content string
+	err := d.DecodeElement(&content, &start)
+	if strings.HasSuffix(ty.Kind, "file") {
+		ty.Caption = strings.TrimSpace(content)
+	} else {
+		ty.Content = content
+	}
+	return err
+}
+
+func (ty *TocItem) decode(d *fileDecoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "id":
+			ty.Id = attr.Value
+		default:
+			d.unknownAttribute(fmt.Sprintf("unknown tocitem attribute: %s", attr.Name.Local))
+		}
+	}
+
+	return ty.Content.decode(d, start)
 }
 
-func (ty *Heading) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *Paragraph) decode(d *fileDecoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		default:
+			d.unknownAttribute(fmt.Sprintf("unknown paragraph attribute: %s", attr.Name.Local))
+		}
+	}
+
+	return ty.Content.decode(d, start)
+}
+
+func (ty *Title) decode(d *fileDecoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		default:
+			d.unknownAttribute(fmt.Sprintf("unknown title attribute: %s", attr.Name.Local))
+		}
+	}
+
+	return ty.Content.decode(d, start)
+}
+
+func (ty *Heading) decode(d *fileDecoder, start xml.StartElement) error {
 	var err error
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
@@ -461,95 +656,146 @@ func (ty *Heading) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error
 				return err
 			}
 		default:
-			return errors.New(fmt.Sprintf("unknown heading attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown heading attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	return ty.Content.decode(d, start)
 }
 
-func (ty *Bold) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *Bold) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
 		default:
-			return errors.New(fmt.Sprintf("unknown bold attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown bold attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	return ty.Content.decode(d, start)
 }
 
-func (ty *Emphasis) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *Emphasis) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
 		default:
-			return errors.New(fmt.Sprintf("unknown emphasis attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown emphasis attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	return ty.Content.decode(d, start)
 }
 
-func (ty *Verbatim) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *Verbatim) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
 		default:
-			return errors.New(fmt.Sprintf("unknown verbatim attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown verbatim attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	return ty.Content.decode(d, start)
 }
 
-func (ty *Preformatted) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *Preformatted) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
 		default:
-			return errors.New(fmt.Sprintf("unknown preformatted attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown preformatted attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	return ty.Content.decode(d, start)
 }
 
-func (ty *ComputerOutput) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *ComputerOutput) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
 		default:
-			return errors.New(fmt.Sprintf("unknown computeroutput attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown computeroutput attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	return ty.Content.decode(d, start)
 }
 
-func (ty *Term) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *Term) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
 		default:
-			return errors.New(fmt.Sprintf("unknown term attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown term attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	return ty.Content.decode(d, start)
 }
 
-func (ty *ProgramListing) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *ProgramListing) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
 		case "filename":
 			ty.Filename = attr.Value
 		default:
-			return errors.New(fmt.Sprintf("unknown type attribute: %s", attr.Name.Local))
+			d.unknownAttribute(fmt.Sprintf("unknown type attribute: %s", attr.Name.Local))
+		}
+	}
+
+	err := ty.Content.decode(d, start)
+	if err != nil {
+		return err
+	}
+
+	// Only the code lines matter, the whitespace between them is formatting of
+	// the XML.
+	lines := make([]interface{}, 0, len(ty.Content.Content))
+	for _, c := range ty.Content.Content {
+		if t, ok := c.(Text); ok && strings.TrimSpace(t.Content) == "" {
+			continue
+		}
+		lines = append(lines, c)
+	}
+	ty.Content.Content = lines
+	return nil
+}
+
+func (ty *CodeLine) decode(d *fileDecoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "lineno":
+			n, err := strconv.Atoi(attr.Value)
+			if err != nil {
+				return errors.New(fmt.Sprintf("invalid codeline lineno: %s", attr.Value))
+			}
+			ty.LineNumber = n
+		case "refid":
+			ty.RefId = attr.Value
+		case "refkind":
+			ty.RefKind = attr.Value
+		case "external":
+			ty.External = attr.Value
+		default:
+			d.unknownAttribute(fmt.Sprintf("unknown codeline attribute: %s", attr.Name.Local))
 		}
 	}
 
-	return ty.Content.UnmarshalXML(dec, start)
+	return ty.Content.decode(d, start)
 }
 
-func (ty *DocString) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (ty *Highlight) decode(d *fileDecoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "class":
+			ty.Class = attr.Value
+		default:
+			d.unknownAttribute(fmt.Sprintf("unknown highlight attribute: %s", attr.Name.Local))
+		}
+	}
+
+	return ty.Content.decode(d, start)
+}
+
+func (ty *DocString) decode(d *fileDecoder, start xml.StartElement) error {
 	for {
-		t, err := dec.Token()
+		t, err := d.Token()
 		if err != nil {
 			return err
 		}
@@ -558,152 +804,256 @@ func (ty *DocString) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) erro
 			switch tt.Name.Local {
 			case "ref":
 				var r Ref
-				err = dec.DecodeElement(&r, &tt)
+				err = r.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, r)
 			case "para":
 				var p Paragraph
-				err = dec.DecodeElement(&p, &tt)
+				err = p.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, p)
 			case "title":
 				var t Title
-				err = dec.DecodeElement(&t, &tt)
+				err = t.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, t)
 			case "heading":
 				var t Heading
-				err = dec.DecodeElement(&t, &tt)
+				err = t.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, t)
-			// TODO: Different sectnumbers?
-			case "sect1":
-				fallthrough
-			case "sect2":
-				fallthrough
-			case "simplesect":
+			case "sect1", "sect2", "sect3", "sect4", "sect5", "sect6", "simplesect", "internal":
 				var s Section
-				err = dec.DecodeElement(&s, &tt)
+				err = s.decode(d, tt)
 				if err != nil {
 					return err
 				}
+				switch tt.Name.Local {
+				case "simplesect":
+				case "internal":
+					// Internal docs are only exported with INTERNAL_DOCS, they
+					// are shown as a section of their own.
+					s.Kind = "internal"
+				default:
+					s.Level = int(tt.Name.Local[len("sect")] - '0')
+				}
 				ty.Content = append(ty.Content, s)
+			case "blockquote":
+				var b BlockQuote
+				err = b.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, b)
+			case "details":
+				var e Details
+				err = e.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, e)
+			case "summary":
+				var s Summary
+				err = s.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, s)
+			case "parblock", "copydoc", "small", "center", "cite", "language", "javadocliteral":
+				// These have no markup of their own, their content is kept in
+				// their place.
+				var c DocString
+				err = c.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, c.Content...)
+			case "strike", "s", "del":
+				var s Strike
+				err = s.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, s)
+			case "underline", "ins":
+				var u Underline
+				err = u.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, u)
+			case "subscript":
+				var s Subscript
+				err = s.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, s)
+			case "superscript":
+				var s Superscript
+				err = s.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, s)
+			case "formula":
+				var f Formula
+				err = f.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, f)
+			case "dot", "msc", "plantuml", "dotfile", "mscfile", "diafile":
+				var g Diagram
+				err = g.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, g)
+			case "toclist":
+				var l TocList
+				err = l.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, l)
+			case "javadoccode":
+				var c ComputerOutput
+				err = c.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, c)
+			case "emoji":
+				var t Text
+				for _, attr := range tt.Attr {
+					if attr.Name.Local == "unicode" || (attr.Name.Local == "name" && t.Content == "") {
+						t.Content = attr.Value
+					}
+				}
+				ty.Content = append(ty.Content, t)
+			case "indexentry", "htmlonly", "manonly", "rtfonly", "latexonly", "docbookonly", "xmlonly":
+				// Index entries and output specific content aren't shown.
+				err = d.Skip()
+				if err != nil {
+					return err
+				}
 			case "table":
 				var t Table
-				err = dec.DecodeElement(&t, &tt)
+				err = t.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, t)
 			case "parameterlist":
 				var p ParameterList
-				err = dec.DecodeElement(&p, &tt)
+				err = p.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, p)
 			case "variablelist":
 				var p VariableList
-				err = dec.DecodeElement(&p, &tt)
+				err = p.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, p)
 			case "xrefsect":
 				var x XRefSect
-				err = dec.DecodeElement(&x, &tt)
+				err = x.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, x)
 			case "itemizedlist":
 				var l ItemizedList
-				err = dec.DecodeElement(&l, &tt)
+				err = l.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, l)
 			case "orderedlist":
 				var l OrderedList
-				err = dec.DecodeElement(&l, &tt)
+				err = l.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, l)
 			case "bold":
 				var b Bold
-				err = dec.DecodeElement(&b, &tt)
+				err = b.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, b)
 			case "emphasis":
 				var b Emphasis
-				err = dec.DecodeElement(&b, &tt)
+				err = b.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, b)
 			case "verbatim":
 				var v Verbatim
-				err = dec.DecodeElement(&v, &tt)
+				err = v.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, v)
 			case "preformatted":
 				var p Preformatted
-				err = dec.DecodeElement(&p, &tt)
+				err = p.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, p)
 			case "computeroutput":
 				var c ComputerOutput
-				err = dec.DecodeElement(&c, &tt)
+				err = c.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, c)
 			case "term":
 				var c Term
-				err = dec.DecodeElement(&c, &tt)
+				err = c.decode(d, tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, c)
 			case "linebreak":
 				var l Linebreak
-				err = dec.DecodeElement(&l, &tt)
+				err = d.DecodeElement(&l, &tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, l)
 			case "anchor":
 				var l Anchor
-				err = dec.DecodeElement(&l, &tt)
+				err = d.DecodeElement(&l, &tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, l)
 			case "image":
 				var i Image
-				err = dec.DecodeElement(&i, &tt)
+				err = d.DecodeElement(&i, &tt)
 				if err != nil {
 					return err
 				}
 				ty.Content = append(ty.Content, i)
 			case "programlisting":
 				var p ProgramListing
-				err = dec.DecodeElement(&p, &tt)
+				err = p.decode(d, tt)
 				if err != nil {
 					return err
 				}
@@ -713,15 +1063,14 @@ func (ty *DocString) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) erro
 				t.Content = "\u2013"
 				ty.Content = append(ty.Content, t)
 			case "hruler":
-				//TODO
-				var t Text
-				t.Content = "---------------------------------"
-				ty.Content = append(ty.Content, t)
+				ty.Content = append(ty.Content, HorizontalRuler{})
 			case "ulink":
-				//TODO
-				var t Text
-				t.Content = "<href>"
-				ty.Content = append(ty.Content, t)
+				var u Ulink
+				err = u.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, u)
 			case "zwj":
 				var t Text
 				t.Content = "\u200D"
@@ -731,9 +1080,30 @@ func (ty *DocString) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) erro
 				t.Content = " "
 				ty.Content = append(ty.Content, t)
 			case "codeline":
+				var l CodeLine
+				err = l.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, l)
 			case "highlight":
+				var h Highlight
+				err = h.decode(d, tt)
+				if err != nil {
+					return err
+				}
+				ty.Content = append(ty.Content, h)
 			default:
-				return errors.New(fmt.Sprintf("unknown token `%s` in docstring element", tt.Name.Local))
+				if symbol, ok := SymbolText(tt.Name.Local); ok {
+					var t Text
+					t.Content = symbol
+					ty.Content = append(ty.Content, t)
+					continue
+				}
+				err = d.unknownElement(fmt.Sprintf("unknown token `%s` in docstring element", tt.Name.Local))
+				if err != nil {
+					return err
+				}
 			}
 		case xml.CharData:
 			var t Text
@@ -744,12 +1114,12 @@ func (ty *DocString) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) erro
 				return nil
 			}
 		default:
-			return errors.New(fmt.Sprintf("unknown token type %v in docstring element", t))
+			d.unknownToken(fmt.Sprintf("unknown token type %T in docstring element", t))
 		}
 	}
 }
 
-func (sec *SectionDef) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+func (sec *SectionDef) decode(d *fileDecoder, start xml.StartElement) error {
 	for _, attr := range start.Attr {
 		switch attr.Name.Local {
 		case "kind":
@@ -757,12 +1127,12 @@ func (sec *SectionDef) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) er
 		case "id":
 			sec.Kind = attr.Value
 		default:
-			return errors.New("unknown section attribute")
+			d.unknownAttribute(fmt.Sprintf("unknown section attribute: %s", attr.Name.Local))
 		}
 	}
 
 	for {
-		t, err := dec.Token()
+		t, err := d.Token()
 		if err != nil {
 			return err
 		}
@@ -778,71 +1148,81 @@ func (sec *SectionDef) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) er
 					}
 				}
 				if kind == "" {
-					return errors.New("missing kind on memberdef")
+					err = d.unknownElement("missing kind on memberdef")
+					if err != nil {
+						return err
+					}
+					continue
 				}
 
 				switch kind {
 				case FunctionMember:
 					var f FunctionMemberDef
-					err = dec.DecodeElement(&f, &tt)
+					err = f.decode(d, tt)
 					if err != nil {
 						return err
 					}
 					sec.Functions = append(sec.Functions, &f)
 				case EnumMember:
 					var e EnumMemberDef
-					err = dec.DecodeElement(&e, &tt)
+					err = e.decode(d, tt)
 					if err != nil {
 						return err
 					}
 					sec.Enums = append(sec.Enums, &e)
 				case VariableMember:
 					var v VariableMemberDef
-					err = dec.DecodeElement(&v, &tt)
+					err = v.decode(d, tt)
 					if err != nil {
 						return err
 					}
 					sec.Variables = append(sec.Variables, &v)
 				case DefineMember:
-					var d DefineMemberDef
-					err = dec.DecodeElement(&d, &tt)
+					var m DefineMemberDef
+					err = m.decode(d, tt)
 					if err != nil {
 						return err
 					}
-					sec.Defines = append(sec.Defines, &d)
+					sec.Defines = append(sec.Defines, &m)
 				case TypedefMember:
 					var t TypedefMemberDef
-					err = dec.DecodeElement(&t, &tt)
+					err = t.decode(d, tt)
 					if err != nil {
 						return err
 					}
 					sec.Typedefs = append(sec.Typedefs, &t)
 				case FriendMember:
 					var f FriendMemberDef
-					err = dec.DecodeElement(&f, &tt)
+					err = f.decode(d, tt)
 					if err != nil {
 						return err
 					}
 					sec.Friends = append(sec.Friends, &f)
 				default:
-					return errors.New(fmt.Sprintf("unknown member kind: %s", kind))
+					err = d.unknownElement(fmt.Sprintf("unknown member kind: %s", kind))
+					if err != nil {
+						return err
+					}
 				}
 			case "header":
 				var h string
-				err = dec.DecodeElement(&h, &tt)
+				err = d.DecodeElement(&h, &tt)
 				if err != nil {
 					return err
 				}
 				sec.Header = h
 			case "description":
-				var d DocString
-				err = dec.DecodeElement(&d, &tt)
+				var c DocString
+				err = c.decode(d, tt)
 				if err != nil {
 					return err
 				}
-				sec.Description = d
+				sec.Description = c
 			default:
-				return errors.New(fmt.Sprintf("unknown section element: %s", tt.Name.Local))
+				err = d.unknownElement(fmt.Sprintf("unknown section element: %s", tt.Name.Local))
+				if err != nil {
+					return err
+				}
 			}
 		case xml.EndElement:
 			if tt == start.End() {
@@ -852,45 +1232,631 @@ func (sec *SectionDef) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) er
 	}
 }
 
+// elements calls child with every element in start until start ends, child
+// has to consume the element it is given. Anything else in start is ignored.
+func (d *fileDecoder) elements(start xml.StartElement, child func(start xml.StartElement) error) error {
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tt := t.(type) {
+		case xml.StartElement:
+			err = child(tt)
+			if err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// intAttr parses an integer attribute the way encoding/xml does.
+func intAttr(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 0)
+	return int(n), err
+}
+
+func (ty *ParameterList) decode(d *fileDecoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "kind" {
+			ty.Kind = attr.Value
+		}
+	}
+
+	return d.elements(start, func(start xml.StartElement) error {
+		if start.Name.Local != "parameteritem" {
+			return d.Skip()
+		}
+		var item ParameterItem
+		err := item.decode(d, start)
+		ty.Items = append(ty.Items, item)
+		return err
+	})
+}
+
+func (ty *ParameterItem) decode(d *fileDecoder, start xml.StartElement) error {
+	return d.elements(start, func(start xml.StartElement) error {
+		switch start.Name.Local {
+		case "parameternamelist":
+			return d.elements(start, func(start xml.StartElement) error {
+				if start.Name.Local != "parametername" {
+					return d.Skip()
+				}
+				return d.DecodeElement(&ty.Name, &start)
+			})
+		case "parameterdescription":
+			return ty.Description.decode(d, start)
+		default:
+			return d.Skip()
+		}
+	})
+}
+
+func (ty *VariableList) decode(d *fileDecoder, start xml.StartElement) error {
+	return d.elements(start, func(start xml.StartElement) error {
+		var item DocString
+		err := item.decode(d, start)
+		ty.Items = append(ty.Items, item)
+		return err
+	})
+}
+
+func (ty *XRefSect) decode(d *fileDecoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "id" {
+			ty.Id = attr.Value
+		}
+	}
+
+	return d.elements(start, func(start xml.StartElement) error {
+		switch start.Name.Local {
+		case "xreftitle":
+			return d.DecodeElement(&ty.Title, &start)
+		case "xrefdescription":
+			return ty.Description.decode(d, start)
+		default:
+			return d.Skip()
+		}
+	})
+}
+
+// listItems decodes the items of an itemized or ordered list.
+func listItems(d *fileDecoder, start xml.StartElement, items *[]DocString) error {
+	return d.elements(start, func(start xml.StartElement) error {
+		if start.Name.Local != "listitem" {
+			return d.Skip()
+		}
+		var item DocString
+		err := item.decode(d, start)
+		*items = append(*items, item)
+		return err
+	})
+}
+
+func (ty *ItemizedList) decode(d *fileDecoder, start xml.StartElement) error {
+	return listItems(d, start, &ty.Items)
+}
+
+func (ty *OrderedList) decode(d *fileDecoder, start xml.StartElement) error {
+	return listItems(d, start, &ty.Items)
+}
+
+func (ty *TocList) decode(d *fileDecoder, start xml.StartElement) error {
+	return d.elements(start, func(start xml.StartElement) error {
+		if start.Name.Local != "tocitem" {
+			return d.Skip()
+		}
+		var item TocItem
+		err := item.decode(d, start)
+		ty.Items = append(ty.Items, item)
+		return err
+	})
+}
+
+func (ty *Table) decode(d *fileDecoder, start xml.StartElement) error {
+	var err error
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "rows":
+			ty.RowCount, err = intAttr(attr.Value)
+		case "cols":
+			ty.ColumnCount, err = intAttr(attr.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.elements(start, func(start xml.StartElement) error {
+		if start.Name.Local != "row" {
+			return d.Skip()
+		}
+		var row TableRow
+		err := row.decode(d, start)
+		ty.Rows = append(ty.Rows, row)
+		return err
+	})
+}
+
+func (ty *TableRow) decode(d *fileDecoder, start xml.StartElement) error {
+	return d.elements(start, func(start xml.StartElement) error {
+		if start.Name.Local != "entry" {
+			return d.Skip()
+		}
+		var entry TableEntry
+		err := entry.decode(d, start)
+		ty.Columns = append(ty.Columns, entry)
+		return err
+	})
+}
+
+// decodeElement decodes the descriptions in start, and returns false for the
+// other elements.
+func (ty *Descriptions) decodeElement(d *fileDecoder, start xml.StartElement) (bool, error) {
+	switch start.Name.Local {
+	case "briefdescription":
+		return true, ty.BriefDescription.decode(d, start)
+	case "detaileddescription":
+		return true, ty.DetailedDescription.decode(d, start)
+	case "inbodydescription":
+		return true, ty.InBodyDescription.decode(d, start)
+	default:
+		return false, nil
+	}
+}
+
+func (ty *Doxygen) decode(d *fileDecoder, start xml.StartElement) error {
+	return d.elements(start, func(start xml.StartElement) error {
+		if start.Name.Local != "compounddef" {
+			return d.Skip()
+		}
+		return ty.CompoundDef.decode(d, start)
+	})
+}
+
+func (ty *CompoundDef) decode(d *fileDecoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "id":
+			ty.Id = attr.Value
+		case "kind":
+			ty.Kind = attr.Value
+		case "language":
+			ty.Language = attr.Value
+		case "prot":
+			ty.Protection = attr.Value
+		case "virt":
+			ty.Virtual = attr.Value
+		}
+	}
+
+	return d.elements(start, func(start xml.StartElement) error {
+		if ok, err := ty.Descriptions.decodeElement(d, start); ok {
+			return err
+		}
+
+		switch start.Name.Local {
+		case "compoundname":
+			return d.DecodeElement(&ty.CompoundName, &start)
+		case "title":
+			return d.DecodeElement(&ty.Title, &start)
+		case "basecompoundref":
+			return d.DecodeElement(&ty.BaseCompoundRef, &start)
+		case "sectiondef":
+			var sec SectionDef
+			err := sec.decode(d, start)
+			ty.Sections = append(ty.Sections, sec)
+			return err
+		case "location":
+			return d.DecodeElement(&ty.Location, &start)
+		case "programlisting":
+			if ty.ProgramListing == nil {
+				ty.ProgramListing = &ProgramListing{}
+			}
+			return ty.ProgramListing.decode(d, start)
+		case "includes":
+			return d.DecodeElement(&ty.Includes, &start)
+		case "includedby":
+			return d.DecodeElement(&ty.IncludedBy, &start)
+		case "innerclass":
+			return d.DecodeElement(&ty.InnerClass, &start)
+		case "innerfile":
+			return d.DecodeElement(&ty.InnerFiles, &start)
+		case "innernamespace":
+			return d.DecodeElement(&ty.InnerNamespaces, &start)
+		case "innergroup":
+			return d.DecodeElement(&ty.InnerGroups, &start)
+		case "innerdir":
+			return d.DecodeElement(&ty.InnerDirs, &start)
+		case "inheritancegraph":
+			return d.DecodeElement(&ty.InheritanceGraph, &start)
+		case "incdepgraph":
+			return d.DecodeElement(&ty.IncDepGraph, &start)
+		case "invincdepgraph":
+			return d.DecodeElement(&ty.InvIncDepGraph, &start)
+		default:
+			return d.Skip()
+		}
+	})
+}
+
+// decodeMember decodes the attributes and descriptions every member of a
+// section has, element decodes the other elements it knows and returns false
+// for the rest, which are skipped.
+func decodeMember(d *fileDecoder, start xml.StartElement, m *MemberDef, desc *Descriptions, element func(start xml.StartElement) (bool, error)) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "kind":
+			m.Kind = attr.Value
+		case "id":
+			m.Id = attr.Value
+		case "prot":
+			m.Prot = attr.Value
+		case "static":
+			m.Static = attr.Value
+		case "const":
+			m.Const = attr.Value
+		case "explicit":
+			m.Explicit = attr.Value
+		case "inline":
+			m.Inline = attr.Value
+		case "strong":
+			m.Strong = attr.Value
+		case "mutable":
+			m.Mutable = attr.Value
+		}
+	}
+
+	inner := d.InputOffset()
+	err := d.elements(start, func(start xml.StartElement) error {
+		if ok, err := desc.decodeElement(d, start); ok {
+			return err
+		}
+		if ok, err := element(start); ok {
+			return err
+		}
+		return d.Skip()
+	})
+	if err != nil {
+		return err
+	}
+
+	// The inner XML ends where the end tag of the member starts.
+	raw := d.data[inner:d.InputOffset()]
+	if end := bytes.LastIndex(raw, []byte("</")); end >= 0 {
+		m.InnerXML = append([]byte(nil), raw[:end]...)
+	}
+	return nil
+}
+
+func (ty *FunctionMemberDef) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeMember(d, start, &ty.MemberDef, &ty.Descriptions, func(start xml.StartElement) (bool, error) {
+		switch start.Name.Local {
+		case "type":
+			return true, ty.Type.decode(d, start)
+		case "name":
+			return true, d.DecodeElement(&ty.Name, &start)
+		case "location":
+			return true, d.DecodeElement(&ty.Location, &start)
+		case "definition":
+			return true, d.DecodeElement(&ty.Definition, &start)
+		case "argsstring":
+			return true, d.DecodeElement(&ty.ArgsString, &start)
+		case "param":
+			var param FunctionParam
+			err := param.decode(d, start)
+			ty.Params = append(ty.Params, param)
+			return true, err
+		case "reimplements":
+			return true, d.DecodeElement(&ty.Reimplements, &start)
+		case "reimplementedby":
+			return true, d.DecodeElement(&ty.ReimplementedBy, &start)
+		default:
+			return false, nil
+		}
+	})
+}
+
+func (ty *FunctionParam) decode(d *fileDecoder, start xml.StartElement) error {
+	return d.elements(start, func(start xml.StartElement) error {
+		switch start.Name.Local {
+		case "type":
+			return ty.Type.decode(d, start)
+		case "declname":
+			return d.DecodeElement(&ty.DeclName, &start)
+		default:
+			return d.Skip()
+		}
+	})
+}
+
+func (ty *EnumMemberDef) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeMember(d, start, &ty.MemberDef, &ty.Descriptions, func(start xml.StartElement) (bool, error) {
+		switch start.Name.Local {
+		case "type":
+			return true, ty.Type.decode(d, start)
+		case "name":
+			return true, d.DecodeElement(&ty.Name, &start)
+		case "location":
+			return true, d.DecodeElement(&ty.Location, &start)
+		case "enumvalue":
+			var value EnumValue
+			err := value.decode(d, start)
+			ty.Values = append(ty.Values, value)
+			return true, err
+		default:
+			return false, nil
+		}
+	})
+}
+
+func (ty *EnumValue) decode(d *fileDecoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "id":
+			ty.Id = attr.Value
+		case "prot":
+			ty.Protection = attr.Value
+		}
+	}
+
+	return d.elements(start, func(start xml.StartElement) error {
+		if ok, err := ty.Descriptions.decodeElement(d, start); ok {
+			return err
+		}
+
+		switch start.Name.Local {
+		case "name":
+			return d.DecodeElement(&ty.Name, &start)
+		case "initializer":
+			return d.DecodeElement(&ty.Initializer, &start)
+		default:
+			return d.Skip()
+		}
+	})
+}
+
+func (ty *VariableMemberDef) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeMember(d, start, &ty.MemberDef, &ty.Descriptions, func(start xml.StartElement) (bool, error) {
+		switch start.Name.Local {
+		case "type":
+			return true, ty.Type.decode(d, start)
+		case "name":
+			return true, d.DecodeElement(&ty.Name, &start)
+		case "location":
+			return true, d.DecodeElement(&ty.Location, &start)
+		case "definition":
+			return true, d.DecodeElement(&ty.Definition, &start)
+		case "argsstring":
+			return true, ty.ArgsString.decode(d, start)
+		default:
+			return false, nil
+		}
+	})
+}
+
+func (ty *DefineMemberDef) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeMember(d, start, &ty.MemberDef, &ty.Descriptions, func(start xml.StartElement) (bool, error) {
+		switch start.Name.Local {
+		case "name":
+			return true, d.DecodeElement(&ty.Name, &start)
+		case "param":
+			return true, d.DecodeElement(&ty.Params, &start)
+		case "initializer":
+			return true, d.DecodeElement(&ty.Initializer, &start)
+		case "location":
+			return true, d.DecodeElement(&ty.Location, &start)
+		default:
+			return false, nil
+		}
+	})
+}
+
+func (ty *TypedefMemberDef) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeMember(d, start, &ty.MemberDef, &ty.Descriptions, func(start xml.StartElement) (bool, error) {
+		switch start.Name.Local {
+		case "name":
+			return true, d.DecodeElement(&ty.Name, &start)
+		case "type":
+			return true, ty.Type.decode(d, start)
+		case "definition":
+			return true, d.DecodeElement(&ty.Definition, &start)
+		case "argsstring":
+			return true, ty.ArgsString.decode(d, start)
+		case "location":
+			return true, d.DecodeElement(&ty.Location, &start)
+		default:
+			return false, nil
+		}
+	})
+}
+
+func (ty *FriendMemberDef) decode(d *fileDecoder, start xml.StartElement) error {
+	return decodeMember(d, start, &ty.MemberDef, &ty.Descriptions, func(start xml.StartElement) (bool, error) {
+		switch start.Name.Local {
+		case "name":
+			return true, d.DecodeElement(&ty.Name, &start)
+		case "type":
+			return true, ty.Type.decode(d, start)
+		case "definition":
+			return true, d.DecodeElement(&ty.Definition, &start)
+		case "location":
+			return true, d.DecodeElement(&ty.Location, &start)
+		case "referencedby":
+			return true, d.DecodeElement(&ty.ReferencedBy, &start)
+		default:
+			return false, nil
+		}
+	})
+}
+
+type ParsedFile struct {
+	Path string
+	// Hash is the hex encoded SHA-256 of the file content.
+	Hash string
+	Doc  *Doxygen
+	Err  error
+}
+
+type ParseOptions struct {
+	// Workers is the number of files parsed concurrently, defaults to the number of CPUs.
+	Workers int
+	// Progress is called with the number of parsed files each time a file is done.
+	Progress func(done, total int)
+	// Diagnostics receives the problems found while parsing, may be nil.
+	Diagnostics *diagnostics.Collector
+	// Lenient skips unknown elements and attributes with a warning instead of
+	// failing the file they are in.
+	Lenient bool
+}
+
+type indexedFile struct {
+	Index int
+	File  ParsedFile
+}
+
 func ParseDoxygenFolder(path string) []*Doxygen {
-	files, err := ioutil.ReadDir(path)
+	files, err := StreamDoxygenFolder(path, ParseOptions{})
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	doxygenDocs := make([]*Doxygen, 0, len(files))
+	doxygenDocs := make([]*Doxygen, 0)
 
-	for _, f := range files {
-		d, err := readFile(fmt.Sprintf("%s/%s", path, f.Name()))
-
-		if err != nil {
-			log.Printf("Error reading file (%s): %v\n", f.Name(), err)
+	for f := range files {
+		if f.Err != nil {
+			log.Printf("Error reading file (%s): %v\n", f.Path, f.Err)
 		}
-		if d != nil {
-			doxygenDocs = append(doxygenDocs, d)
+		if f.Doc != nil {
+			doxygenDocs = append(doxygenDocs, f.Doc)
 		}
 	}
 	return doxygenDocs
 }
 
-func readFile(path string) (*Doxygen, error) {
+// StreamDoxygenFolder parses the compound files in path on a pool of workers and
+// sends them on the returned channel in file name order. At most a few files per
+// worker are held in memory at any time, the channel is closed when every file
+// has been sent.
+func StreamDoxygenFolder(path string, opts ParseOptions) (<-chan ParsedFile, error) {
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() || !isCompoundFile(f.Name()) {
+			continue
+		}
+		paths = append(paths, filepath.Join(path, f.Name()))
+	}
+
+	return streamFiles(paths, opts), nil
+}
+
+func streamFiles(paths []string, opts ParseOptions) <-chan ParsedFile {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	// Every file that is being parsed or waiting to be sent holds a slot, so a
+	// single slow file can't make the reorder buffer grow without bounds.
+	slots := make(chan struct{}, workers*4)
+	jobs := make(chan int)
+	results := make(chan indexedFile, workers)
+	out := make(chan ParsedFile, workers)
+
+	go func() {
+		for i := range paths {
+			slots <- struct{}{}
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				d, hash, err := readFile(paths[i], opts)
+				results <- indexedFile{
+					Index: i,
+					File: ParsedFile{
+						Path: paths[i],
+						Hash: hash,
+						Doc:  d,
+						Err:  err,
+					},
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		pending := make(map[int]ParsedFile)
+		next := 0
+		done := 0
+		for r := range results {
+			done++
+			if opts.Progress != nil {
+				opts.Progress(done, len(paths))
+			}
+
+			pending[r.Index] = r.File
+			for {
+				f, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				out <- f
+				<-slots
+				next++
+			}
+		}
+		close(out)
+	}()
+
+	return out
+}
+
+func readFile(path string, opts ParseOptions) (*Doxygen, string, error) {
 	if !strings.HasSuffix(path, ".xml") {
-		return nil, nil
+		return nil, "", nil
 	}
 	if strings.HasSuffix(path, "index.xml") {
-		return nil, nil
+		return nil, "", nil
 	}
 
-	data, err := xmlhelper.ReadFileWithBadUTF8(fmt.Sprintf("%s", path))
+	data, err := xmlhelper.ReadFileWithBadUTF8(path)
 	if err != nil {
-		log.Fatal(fmt.Sprintf("Error parsing file (%s): %v\n", path, err))
+		if opts.Diagnostics != nil {
+			opts.Diagnostics.Reportf(diagnostics.Error, path, "unable to read file: %v", err)
+		}
+		return nil, "", err
 	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
 
 	doxygen := &Doxygen{}
-	err = xml.Unmarshal(data, doxygen)
+	err = decode(path, data, doxygen, opts)
 	if err != nil {
-		log.Printf("%s", string(data))
-		log.Fatal(fmt.Sprintf("Error parsing file (%s): %v\n", path, err))
+		return nil, hash, errors.New(fmt.Sprintf("unable to parse file: %v", err))
 	}
-	return doxygen, nil
+	return doxygen, hash, nil
 }
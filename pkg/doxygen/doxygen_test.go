@@ -0,0 +1,268 @@
+package doxygen
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readCompound(t *testing.T, path string) *Doxygen {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := &Doxygen{}
+	err = decode(path, data, doc, ParseOptions{Diagnostics: diagnostics.NewCollector()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// elements returns the content of a doc string without the line breaks
+// between its elements.
+func elements(d DocString) []interface{} {
+	content := make([]interface{}, 0)
+	for _, c := range d.Content {
+		if text, ok := c.(Text); ok && strings.Contains(text.Content, "\n") && strings.TrimSpace(text.Content) == "" {
+			continue
+		}
+		content = append(content, c)
+	}
+	return content
+}
+
+func text(content ...string) DocString {
+	d := DocString{}
+	for _, c := range content {
+		d.Content = append(d.Content, Text{Content: c})
+	}
+	return d
+}
+
+func TestDecodeVocabulary(t *testing.T) {
+	doc := readCompound(t, "testdata/vocab.xml")
+	brief := elements(doc.CompoundDef.BriefDescription)
+	detailed := elements(doc.CompoundDef.DetailedDescription)
+	sect1 := detailed[1].(Section)
+	first := elements(sect1.Content)
+	sect2 := first[4].(Section)
+	second := elements(sect2.Content)
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{
+			name: "symbols",
+			got:  brief[0],
+			want: Paragraph{Content: text("Symbols ", "©", " 2024 ", "—", " ", "Ä", "\u00a0", "™", " and ", "‘", "q", "’", ".")},
+		},
+		{
+			name: "toclist",
+			got:  detailed[0],
+			want: Paragraph{Content: DocString{Content: []interface{}{
+				TocList{Items: []TocItem{
+					{Id: "vocab_1First", Content: text("First")},
+					{Id: "vocab_1deep", Content: text("Deep")},
+				}},
+			}}},
+		},
+		{
+			name: "strike",
+			got:  first[1],
+			want: Paragraph{Content: DocString{Content: []interface{}{
+				Text{Content: "Text with "},
+				Strike{Content: text("struck")},
+				Text{Content: ", "},
+				Strike{Content: text("s")},
+				Text{Content: " and "},
+				Strike{Content: text("del")},
+				Text{Content: "."},
+			}}},
+		},
+		{
+			name: "formula",
+			got:  first[2],
+			want: Paragraph{Content: DocString{Content: []interface{}{
+				Text{Content: "Inline "},
+				Formula{Id: "0", Content: "$x^2 + y^2$"},
+				Text{Content: " and block "},
+				Formula{Id: "1", Content: `\[ \sum_{i=0}^n i \]`},
+			}}},
+		},
+		{
+			name: "details",
+			got:  first[3],
+			want: Details{Content: DocString{Content: []interface{}{
+				Summary{Content: DocString{Content: []interface{}{
+					Text{Content: "More "},
+					Emphasis{Content: text("info")},
+				}}},
+				Paragraph{Content: text("Hidden body.")},
+			}}},
+		},
+		{
+			name: "diagram",
+			got:  second[1],
+			want: Paragraph{Content: DocString{Content: []interface{}{
+				Diagram{Kind: "dot", Caption: "Graph", Content: "\ndigraph G { a -> b; }\n"},
+				Diagram{Kind: "dotfile", Name: "graph.dot", Caption: "File caption"},
+			}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeSections(t *testing.T) {
+	doc := readCompound(t, "testdata/vocab.xml")
+
+	type section struct {
+		Id    string
+		Level int
+		Title DocString
+	}
+	got := make([]section, 0)
+	content := elements(doc.CompoundDef.DetailedDescription)
+	for len(content) > 0 {
+		var next []interface{}
+		for _, c := range content {
+			s, ok := c.(Section)
+			if !ok {
+				continue
+			}
+			inner := elements(s.Content)
+			got = append(got, section{s.Id, s.Level, inner[0].(Title).Content})
+			next = inner
+		}
+		content = next
+	}
+
+	want := []section{
+		{"vocab_1First", 1, text("First")},
+		{"vocab_1second", 2, text("Second")},
+		{"vocab_1deep", 3, text("Deep")},
+		{"vocab_1deeper", 4, text("Deeper")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %+v, want %+v", got, want)
+	}
+}
+
+func TestSymbolText(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{name: "copy", want: "©", wantOk: true},
+		{name: "mdash", want: "—", wantOk: true},
+		{name: "nonbreakablespace", want: "\u00a0", wantOk: true},
+		{name: "Aumlaut", want: "Ä", wantOk: true},
+		{name: "umlaut", want: "¨", wantOk: true},
+		{name: "tm", want: "™", wantOk: true},
+		{name: "imaginary", want: "ℑ", wantOk: true},
+		// HTML entities that aren't doxygen symbols.
+		{name: "amp"},
+		{name: "lt"},
+		{name: "nbsp"},
+		{name: "uml"},
+		{name: "blink"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SymbolText(tt.name)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("SymbolText(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestDecodeUnknownSymbol(t *testing.T) {
+	const xml = `<doxygen>
+<compounddef id="foo" kind="page">
+<briefdescription><para>A<hellip/><amp/></para></briefdescription>
+</compounddef>
+</doxygen>`
+
+	collector := diagnostics.NewCollector()
+	doc := &Doxygen{}
+	err := decode("foo.xml", []byte(xml), doc, ParseOptions{Diagnostics: collector, Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []diagnostics.Diagnostic{
+		{File: "foo.xml", Line: 3, Element: "doxygen/compounddef/briefdescription/para", Severity: diagnostics.Warning, Message: "unknown token `amp` in docstring element"},
+	}
+	if got := collector.Diagnostics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %+v, want %+v", got, want)
+	}
+	para := doc.CompoundDef.BriefDescription.Content[0].(Paragraph)
+	if got := para.Content.Content; !reflect.DeepEqual(got, text("A", "…").Content) {
+		t.Errorf("paragraph = %+v, want the text and the ellipsis", got)
+	}
+}
+
+func TestDecodeUlink(t *testing.T) {
+	doc := readCompound(t, "testdata/ulink.xml")
+	sections := doc.CompoundDef.Sections
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{
+			name: "compound",
+			got:  elements(doc.CompoundDef.BriefDescription)[0],
+			want: Paragraph{Content: DocString{Content: []interface{}{
+				Text{Content: "Links to "},
+				Ulink{Url: "https://docs.example.org/linker", Content: DocString{Content: []interface{}{
+					Bold{Content: text("the")},
+					Text{Content: " docs"},
+				}}},
+				Text{Content: "."},
+			}}},
+		},
+		{
+			name: "enum value",
+			got:  elements(sections[0].Enums[0].Values[0].BriefDescription)[0],
+			want: Paragraph{Content: DocString{Content: []interface{}{
+				Text{Content: "See "},
+				Ulink{Url: "https://mirror.example.org/", Content: text("the mirror")},
+				Text{Content: "."},
+			}}},
+		},
+		{
+			name: "function",
+			got:  elements(sections[1].Functions[0].DetailedDescription)[0],
+			want: Paragraph{Content: DocString{Content: []interface{}{
+				Text{Content: "Follows "},
+				Ulink{Url: "http://internal.example.com/spec", Content: text("the spec")},
+				Text{Content: "."},
+			}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package doxygen
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"ScriptExecServer/pkg/xmlhelper"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type IndexMember struct {
+	RefId string `xml:"refid,attr"`
+	Kind  string `xml:"kind,attr"`
+	Name  string `xml:"name"`
+}
+
+type IndexCompound struct {
+	RefId   string        `xml:"refid,attr"`
+	Kind    string        `xml:"kind,attr"`
+	Name    string        `xml:"name"`
+	Members []IndexMember `xml:"member"`
+}
+
+type DoxygenIndex struct {
+	Version   string          `xml:"version,attr"`
+	Compounds []IndexCompound `xml:"compound"`
+}
+
+// ReadIndex reads the index.xml doxygen writes next to the compound files.
+func ReadIndex(path string) (*DoxygenIndex, error) {
+	data, err := xmlhelper.ReadFileWithBadUTF8(path)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &DoxygenIndex{}
+	err = xml.Unmarshal(data, index)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to parse index (%s): %v", path, err))
+	}
+	return index, nil
+}
+
+// CompoundIds returns the sorted ids of the compounds for which include returns
+// true, or of every compound if include is nil.
+func (i *DoxygenIndex) CompoundIds(include func(c IndexCompound) bool) []string {
+	ids := make([]string, 0, len(i.Compounds))
+	for _, c := range i.Compounds {
+		if include == nil || include(c) {
+			ids = append(ids, c.RefId)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// StreamDoxygenCompounds works like StreamDoxygenFolder, but only parses the
+// compound files of the given ids.
+func StreamDoxygenCompounds(path string, ids []string, opts ParseOptions) (<-chan ParsedFile, error) {
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(files))
+	for _, f := range files {
+		existing[f.Name()] = true
+	}
+
+	paths := make([]string, 0, len(ids))
+	for _, id := range ids {
+		name := id + ".xml"
+		if !existing[name] {
+			if opts.Diagnostics != nil {
+				opts.Diagnostics.Reportf(diagnostics.Warning, filepath.Join(path, name), "compound %s is listed in the index but has no file", id)
+			}
+			continue
+		}
+		paths = append(paths, filepath.Join(path, name))
+	}
+
+	return streamFiles(paths, opts), nil
+}
+
+func isCompoundFile(name string) bool {
+	return strings.HasSuffix(name, ".xml") && name != "index.xml"
+}
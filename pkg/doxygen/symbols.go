@@ -0,0 +1,261 @@
+package doxygen
+
+// symbols are the texts of the empty symbol elements of the doxygen schema, like
+// copy or mdash. Doxygen names most of them after the HTML entities.
+var symbols = map[string]string{
+	"nonbreakablespace": "\u00a0",
+	"iexcl":             "¡",
+	"cent":              "¢",
+	"pound":             "£",
+	"curren":            "¤",
+	"yen":               "¥",
+	"brvbar":            "¦",
+	"sect":              "§",
+	"umlaut":            "¨",
+	"copy":              "©",
+	"ordf":              "ª",
+	"laquo":             "«",
+	"not":               "¬",
+	"shy":               "\u00ad",
+	"registered":        "®",
+	"macr":              "¯",
+	"deg":               "°",
+	"plusmn":            "±",
+	"sup2":              "²",
+	"sup3":              "³",
+	"acute":             "´",
+	"micro":             "µ",
+	"para":              "¶",
+	"middot":            "·",
+	"cedil":             "¸",
+	"sup1":              "¹",
+	"ordm":              "º",
+	"raquo":             "»",
+	"frac14":            "¼",
+	"frac12":            "½",
+	"frac34":            "¾",
+	"iquest":            "¿",
+	"Agrave":            "À",
+	"Aacute":            "Á",
+	"Acirc":             "Â",
+	"Atilde":            "Ã",
+	"Aumlaut":           "Ä",
+	"Aring":             "Å",
+	"AElig":             "Æ",
+	"Ccedil":            "Ç",
+	"Egrave":            "È",
+	"Eacute":            "É",
+	"Ecirc":             "Ê",
+	"Eumlaut":           "Ë",
+	"Igrave":            "Ì",
+	"Iacute":            "Í",
+	"Icirc":             "Î",
+	"Iumlaut":           "Ï",
+	"ETH":               "Ð",
+	"Ntilde":            "Ñ",
+	"Ograve":            "Ò",
+	"Oacute":            "Ó",
+	"Ocirc":             "Ô",
+	"Otilde":            "Õ",
+	"Oumlaut":           "Ö",
+	"times":             "×",
+	"Oslash":            "Ø",
+	"Ugrave":            "Ù",
+	"Uacute":            "Ú",
+	"Ucirc":             "Û",
+	"Uumlaut":           "Ü",
+	"Yacute":            "Ý",
+	"THORN":             "Þ",
+	"szlig":             "ß",
+	"agrave":            "à",
+	"aacute":            "á",
+	"acirc":             "â",
+	"atilde":            "ã",
+	"aumlaut":           "ä",
+	"aring":             "å",
+	"aelig":             "æ",
+	"ccedil":            "ç",
+	"egrave":            "è",
+	"eacute":            "é",
+	"ecirc":             "ê",
+	"eumlaut":           "ë",
+	"igrave":            "ì",
+	"iacute":            "í",
+	"icirc":             "î",
+	"iumlaut":           "ï",
+	"eth":               "ð",
+	"ntilde":            "ñ",
+	"ograve":            "ò",
+	"oacute":            "ó",
+	"ocirc":             "ô",
+	"otilde":            "õ",
+	"oumlaut":           "ö",
+	"divide":            "÷",
+	"oslash":            "ø",
+	"ugrave":            "ù",
+	"uacute":            "ú",
+	"ucirc":             "û",
+	"uumlaut":           "ü",
+	"yacute":            "ý",
+	"thorn":             "þ",
+	"yumlaut":           "ÿ",
+	"fnof":              "ƒ",
+	"Alpha":             "Α",
+	"Beta":              "Β",
+	"Gamma":             "Γ",
+	"Delta":             "Δ",
+	"Epsilon":           "Ε",
+	"Zeta":              "Ζ",
+	"Eta":               "Η",
+	"Theta":             "Θ",
+	"Iota":              "Ι",
+	"Kappa":             "Κ",
+	"Lambda":            "Λ",
+	"Mu":                "Μ",
+	"Nu":                "Ν",
+	"Xi":                "Ξ",
+	"Omicron":           "Ο",
+	"Pi":                "Π",
+	"Rho":               "Ρ",
+	"Sigma":             "Σ",
+	"Tau":               "Τ",
+	"Upsilon":           "Υ",
+	"Phi":               "Φ",
+	"Chi":               "Χ",
+	"Psi":               "Ψ",
+	"Omega":             "Ω",
+	"alpha":             "α",
+	"beta":              "β",
+	"gamma":             "γ",
+	"delta":             "δ",
+	"epsilon":           "ε",
+	"zeta":              "ζ",
+	"eta":               "η",
+	"theta":             "θ",
+	"iota":              "ι",
+	"kappa":             "κ",
+	"lambda":            "λ",
+	"mu":                "μ",
+	"nu":                "ν",
+	"xi":                "ξ",
+	"omicron":           "ο",
+	"pi":                "π",
+	"rho":               "ρ",
+	"sigmaf":            "ς",
+	"sigma":             "σ",
+	"tau":               "τ",
+	"upsilon":           "υ",
+	"phi":               "φ",
+	"chi":               "χ",
+	"psi":               "ψ",
+	"omega":             "ω",
+	"thetasym":          "ϑ",
+	"upsih":             "ϒ",
+	"piv":               "ϖ",
+	"bull":              "•",
+	"hellip":            "…",
+	"prime":             "′",
+	"Prime":             "″",
+	"oline":             "‾",
+	"frasl":             "⁄",
+	"weierp":            "℘",
+	"imaginary":         "ℑ",
+	"real":              "ℜ",
+	"trademark":         "™",
+	"alefsym":           "ℵ",
+	"larr":              "←",
+	"uarr":              "↑",
+	"rarr":              "→",
+	"darr":              "↓",
+	"harr":              "↔",
+	"crarr":             "↵",
+	"lArr":              "⇐",
+	"uArr":              "⇑",
+	"rArr":              "⇒",
+	"dArr":              "⇓",
+	"hArr":              "⇔",
+	"forall":            "∀",
+	"part":              "∂",
+	"exist":             "∃",
+	"empty":             "∅",
+	"nabla":             "∇",
+	"isin":              "∈",
+	"notin":             "∉",
+	"ni":                "∋",
+	"prod":              "∏",
+	"sum":               "∑",
+	"minus":             "−",
+	"lowast":            "∗",
+	"radic":             "√",
+	"prop":              "∝",
+	"infin":             "∞",
+	"ang":               "∠",
+	"and":               "∧",
+	"or":                "∨",
+	"cap":               "∩",
+	"cup":               "∪",
+	"int":               "∫",
+	"there4":            "∴",
+	"sim":               "∼",
+	"cong":              "≅",
+	"asymp":             "≈",
+	"ne":                "≠",
+	"equiv":             "≡",
+	"le":                "≤",
+	"ge":                "≥",
+	"sub":               "⊂",
+	"sup":               "⊃",
+	"nsub":              "⊄",
+	"sube":              "⊆",
+	"supe":              "⊇",
+	"oplus":             "⊕",
+	"otimes":            "⊗",
+	"perp":              "⊥",
+	"sdot":              "⋅",
+	"lceil":             "⌈",
+	"rceil":             "⌉",
+	"lfloor":            "⌊",
+	"rfloor":            "⌋",
+	"lang":              "⟨",
+	"rang":              "⟩",
+	"loz":               "◊",
+	"spades":            "♠",
+	"clubs":             "♣",
+	"hearts":            "♥",
+	"diams":             "♦",
+	"OElig":             "Œ",
+	"oelig":             "œ",
+	"Scaron":            "Š",
+	"scaron":            "š",
+	"Yumlaut":           "Ÿ",
+	"circ":              "ˆ",
+	"tilde":             "˜",
+	"ensp":              "\u2002",
+	"emsp":              "\u2003",
+	"thinsp":            "\u2009",
+	"zwnj":              "\u200c",
+	"zwj":               "\u200d",
+	"lrm":               "\u200e",
+	"rlm":               "\u200f",
+	"ndash":             "–",
+	"mdash":             "—",
+	"lsquo":             "‘",
+	"rsquo":             "’",
+	"sbquo":             "‚",
+	"ldquo":             "“",
+	"rdquo":             "”",
+	"bdquo":             "„",
+	"dagger":            "†",
+	"Dagger":            "‡",
+	"permil":            "‰",
+	"lsaquo":            "‹",
+	"rsaquo":            "›",
+	"euro":              "€",
+	"tm":                "™",
+}
+
+// SymbolText returns the text of an empty symbol element, like copy or mdash.
+func SymbolText(name string) (string, bool) {
+	text, ok := symbols[name]
+	return text, ok
+}
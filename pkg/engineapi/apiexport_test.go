@@ -0,0 +1,213 @@
+package engineapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadEngineApiExportXml(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		functions  []EngineFunction
+		enums      []EngineEnumType
+		bitfields  int
+		structs    int
+		classes    []string
+		primitives int
+		// scopes are the names of the nested scopes, depth first.
+		scopes []string
+	}{
+		{
+			name: "old",
+			path: "testdata/export_old.xml",
+			functions: []EngineFunction{
+				{
+					Name:       "getRealTime",
+					ReturnType: "int",
+					Symbol:     "fn_getRealTime",
+					IsCallback: "0",
+					IsVariadic: "0",
+					Docs:       "Return the current real time in milliseconds.",
+				},
+				{
+					Name:       "echo",
+					ReturnType: "void",
+					Symbol:     "fn_echo",
+					IsCallback: "0",
+					IsVariadic: "1",
+					Docs:       "Print a message to the console.",
+					Arguments:  []EngineFunctionArgument{{Name: "text"}},
+				},
+			},
+			enums: []EngineEnumType{
+				{
+					Name:  "GFXFormat",
+					Docs:  "Texture formats.",
+					Enums: []EngineEnum{{Name: "GFXFormatR8G8B8"}, {Name: "GFXFormatA8"}},
+				},
+			},
+			scopes: []string{"Math"},
+		},
+		{
+			name: "new",
+			path: "testdata/export_new.xml",
+			functions: []EngineFunction{
+				{
+					Name:       "getRealTime",
+					ReturnType: "int",
+					Symbol:     "fn_getRealTime",
+					IsCallback: "false",
+					IsVariadic: "false",
+					Docs:       "@brief Return the current real time in milliseconds.\n\nReal time is platform defined.\n\n@param scale multiplies the time\n@param offset is added to the time\n@return the time in ms",
+					Arguments: []EngineFunctionArgument{
+						{Name: "scale", Type: "int", DefaultValue: "1"},
+						{Name: "offset", Type: "int"},
+					},
+				},
+				{
+					Name:       "echo",
+					ReturnType: "void",
+					Symbol:     "fn_echo",
+					IsCallback: "false",
+					IsVariadic: "true",
+					Docs:       "Print a message to the console.",
+					Arguments:  []EngineFunctionArgument{{Name: "text", Type: "string"}},
+				},
+				{
+					Name:       "onStart",
+					ReturnType: "void",
+					Symbol:     "cb_onStart",
+					IsCallback: "true",
+					IsVariadic: "false",
+					Docs:       "Called when the engine started.",
+				},
+			},
+			enums: []EngineEnumType{
+				{
+					Name: "GFXFormat",
+					Docs: "Texture formats.",
+					Enums: []EngineEnum{
+						{Name: "GFXFormatR8G8B8", Value: "0", Docs: "Red, green and blue."},
+						{Name: "GFXFormatA8", Value: "1"},
+					},
+				},
+			},
+			bitfields:  1,
+			structs:    1,
+			classes:    []string{"SimObject", "SceneObject"},
+			primitives: 1,
+			scopes:     []string{"Math", "Vector"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, err := ReadEngineApiExportXml(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exports := scope.Exports
+
+			if !reflect.DeepEqual(exports.Functions, tt.functions) {
+				t.Errorf("functions = %+v, want %+v", exports.Functions, tt.functions)
+			}
+			if !reflect.DeepEqual(exports.Enums, tt.enums) {
+				t.Errorf("enums = %+v, want %+v", exports.Enums, tt.enums)
+			}
+			if len(exports.Bitfields) != tt.bitfields || len(exports.Structs) != tt.structs || len(exports.Primitives) != tt.primitives {
+				t.Errorf("%d bitfields, %d structs and %d primitives, want %d, %d and %d",
+					len(exports.Bitfields), len(exports.Structs), len(exports.Primitives), tt.bitfields, tt.structs, tt.primitives)
+			}
+
+			classes := make([]string, 0)
+			for _, c := range exports.Classes {
+				classes = append(classes, c.Name)
+			}
+			if len(classes) != len(tt.classes) || (len(classes) > 0 && !reflect.DeepEqual(classes, tt.classes)) {
+				t.Errorf("classes = %v, want %v", classes, tt.classes)
+			}
+
+			scopes := make([]string, 0)
+			var walk func(exports Exports)
+			walk = func(exports Exports) {
+				for _, s := range exports.Scopes {
+					scopes = append(scopes, s.Name)
+					walk(s.Exports)
+				}
+			}
+			walk(exports)
+			if !reflect.DeepEqual(scopes, tt.scopes) {
+				t.Errorf("scopes = %v, want %v", scopes, tt.scopes)
+			}
+		})
+	}
+}
+
+func TestReadEngineApiExportXmlTypes(t *testing.T) {
+	scope, err := ReadEngineApiExportXml("testdata/export_new.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exports := scope.Exports
+
+	wantBitfield := EngineBitfieldType{
+		Name: "TypeMasks",
+		Docs: "Object types.",
+		Enums: []EngineEnum{
+			{Name: "StaticObjectType", Value: "1"},
+			{Name: "TerrainObjectType", Value: "4"},
+		},
+	}
+	if !reflect.DeepEqual(exports.Bitfields[0], wantBitfield) {
+		t.Errorf("bitfield = %+v, want %+v", exports.Bitfields[0], wantBitfield)
+	}
+
+	wantStruct := EngineStructType{
+		Name: "Point3F",
+		Size: "12",
+		Docs: "A point in 3D space.",
+		Fields: []EngineField{
+			{Name: "x", Type: "float", Offset: "0", IndexedSize: "1", Docs: "The x coordinate."},
+			{Name: "yz", Type: "float", Offset: "4", IndexedSize: "2"},
+		},
+	}
+	if !reflect.DeepEqual(exports.Structs[0], wantStruct) {
+		t.Errorf("struct = %+v, want %+v", exports.Structs[0], wantStruct)
+	}
+
+	class := exports.Classes[0]
+	wantProperties := []EngineProperty{
+		{Name: "name", Type: "string", IsConstant: "true", IsTransient: "false", IsVisible: "true", IndexedSize: "1", Docs: "Name of the object."},
+	}
+	if !reflect.DeepEqual(class.Properties, wantProperties) {
+		t.Errorf("properties = %+v, want %+v", class.Properties, wantProperties)
+	}
+	if len(class.Exports.Functions) != 2 || !IsSet(class.Exports.Functions[1].IsCallback) {
+		t.Errorf("class exports = %+v, want a method and a callback", class.Exports.Functions)
+	}
+	if super := exports.Classes[1].SuperType; super != "SimObject" {
+		t.Errorf("superType = %q, want SimObject", super)
+	}
+
+	mDot := exports.Scopes[0].Exports.Scopes[0].Exports.Functions[0]
+	if mDot.Name != "mDot" || len(mDot.Arguments) != 2 || mDot.Arguments[1].Type != "Point3F" {
+		t.Errorf("nested scope function = %+v, want mDot(Point3F a, Point3F b)", mDot)
+	}
+}
+
+func TestIsSet(t *testing.T) {
+	tests := map[string]bool{
+		"1":      true,
+		"true":   true,
+		" TRUE ": true,
+		"0":      false,
+		"false":  false,
+		"":       false,
+	}
+	for value, want := range tests {
+		if got := IsSet(value); got != want {
+			t.Errorf("IsSet(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
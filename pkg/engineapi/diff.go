@@ -0,0 +1,347 @@
+package engineapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ChangeType string
+
+const (
+	Added   ChangeType = "added"
+	Removed ChangeType = "removed"
+	Changed ChangeType = "changed"
+)
+
+// Change describes how a single export differs between two exports. Name is
+// qualified with the scopes and classes the export is nested in.
+type Change struct {
+	Type    ChangeType `json:"type"`
+	Kind    string     `json:"kind"`
+	Name    string     `json:"name"`
+	Old     string     `json:"old,omitempty"`
+	New     string     `json:"new,omitempty"`
+	Details []string   `json:"details,omitempty"`
+}
+
+type Diff struct {
+	Changes []Change `json:"changes"`
+}
+
+// Count returns the number of changes of the given type.
+func (d *Diff) Count(t ChangeType) int {
+	count := 0
+	for _, c := range d.Changes {
+		if c.Type == t {
+			count++
+		}
+	}
+	return count
+}
+
+// Kinds returns the kinds of the changes in the order they should be listed.
+func (d *Diff) Kinds() []string {
+	seen := make(map[string]bool)
+	kinds := make([]string, 0)
+	for _, kind := range kindOrder {
+		for _, c := range d.Changes {
+			if c.Kind == kind && !seen[kind] {
+				seen[kind] = true
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	return kinds
+}
+
+var kindOrder = []string{
+	"scope", "function", "callback", "class", "property",
+	"struct", "field", "enum", "bitfield", "enumvalue",
+}
+
+// entryKey identifies a flattened export. Exports of different kinds can share
+// a name, like a property and a method of a class, so the key holds the kind,
+// or the group of kinds an export can change between.
+type entryKey struct {
+	group string
+	name  string
+}
+
+func keyOf(kind string, name string) entryKey {
+	group := kind
+	switch kind {
+	case "function", "callback":
+		group = "function"
+	case "scope", "class", "struct", "enum", "bitfield":
+		group = "type"
+	}
+	return entryKey{group: group, name: name}
+}
+
+// entry is a flattened export, compared by signature and docs.
+type entry struct {
+	kind      string
+	signature string
+	docs      string
+	function  *EngineFunction
+}
+
+// DiffExports compares two engine API exports.
+func DiffExports(old *EngineExportScope, new *EngineExportScope) *Diff {
+	oldEntries := make(map[entryKey]entry)
+	flatten(oldEntries, "", old.Exports)
+	newEntries := make(map[entryKey]entry)
+	flatten(newEntries, "", new.Exports)
+
+	d := &Diff{
+		Changes: make([]Change, 0),
+	}
+	for key, o := range oldEntries {
+		n, ok := newEntries[key]
+		if !ok {
+			d.Changes = append(d.Changes, Change{
+				Type: Removed,
+				Kind: o.kind,
+				Name: key.name,
+				Old:  o.signature,
+			})
+			continue
+		}
+
+		details := make([]string, 0)
+		if o.kind != n.kind {
+			details = append(details, fmt.Sprintf("changed from %s to %s", o.kind, n.kind))
+		}
+		if o.function != nil && n.function != nil {
+			details = append(details, functionChanges(o.function, n.function)...)
+		} else if o.signature != n.signature {
+			details = append(details, signatureChange(n.kind))
+		}
+		if o.docs != n.docs {
+			details = append(details, "documentation changed")
+		}
+		if len(details) > 0 {
+			c := Change{
+				Type:    Changed,
+				Kind:    n.kind,
+				Name:    key.name,
+				Details: details,
+			}
+			if o.signature != n.signature {
+				c.Old = o.signature
+				c.New = n.signature
+			}
+			d.Changes = append(d.Changes, c)
+		}
+	}
+	for key, n := range newEntries {
+		if _, ok := oldEntries[key]; !ok {
+			d.Changes = append(d.Changes, Change{
+				Type: Added,
+				Kind: n.kind,
+				Name: key.name,
+				New:  n.signature,
+			})
+		}
+	}
+
+	sort.Slice(d.Changes, func(i, j int) bool {
+		a, b := d.Changes[i], d.Changes[j]
+		if a.Kind != b.Kind {
+			return kindIndex(a.Kind) < kindIndex(b.Kind)
+		}
+		return a.Name < b.Name
+	})
+	return d
+}
+
+func signatureChange(kind string) string {
+	switch kind {
+	case "class":
+		return "superclass changed"
+	case "enumvalue":
+		return "value changed"
+	case "field", "property":
+		return "type changed"
+	default:
+		return "signature changed"
+	}
+}
+
+func kindIndex(kind string) int {
+	for i, k := range kindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(kindOrder)
+}
+
+func qualify(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "::" + name
+}
+
+func flatten(entries map[entryKey]entry, prefix string, exports Exports) {
+	for i := range exports.Functions {
+		f := &exports.Functions[i]
+		kind := "function"
+		if IsSet(f.IsCallback) {
+			kind = "callback"
+		}
+		entries[keyOf(kind, qualify(prefix, f.Name))] = entry{
+			kind:      kind,
+			signature: FunctionSignature(*f),
+			docs:      strings.TrimSpace(f.Docs),
+			function:  f,
+		}
+	}
+	for _, e := range exports.Enums {
+		flattenEnum(entries, prefix, "enum", e)
+	}
+	for _, b := range exports.Bitfields {
+		flattenEnum(entries, prefix, "bitfield", EngineEnumType(b))
+	}
+	for _, s := range exports.Structs {
+		name := qualify(prefix, s.Name)
+		entries[keyOf("struct", name)] = entry{
+			kind:      "struct",
+			signature: "struct " + s.Name,
+			docs:      strings.TrimSpace(s.Docs),
+		}
+		for _, f := range s.Fields {
+			entries[keyOf("field", qualify(name, f.Name))] = entry{
+				kind:      "field",
+				signature: strings.TrimSpace(fmt.Sprintf("%s %s%s", f.Type, f.Name, arraySuffix(f.IndexedSize))),
+				docs:      strings.TrimSpace(f.Docs),
+			}
+		}
+	}
+	for _, c := range exports.Classes {
+		name := qualify(prefix, c.Name)
+		signature := "class " + c.Name
+		if c.SuperType != "" {
+			signature += " : " + c.SuperType
+		}
+		entries[keyOf("class", name)] = entry{
+			kind:      "class",
+			signature: signature,
+			docs:      strings.TrimSpace(c.Docs),
+		}
+		for _, p := range c.Properties {
+			t := p.Type
+			if IsSet(p.IsConstant) {
+				t = "const " + t
+			}
+			entries[keyOf("property", qualify(name, p.Name))] = entry{
+				kind:      "property",
+				signature: strings.TrimSpace(fmt.Sprintf("%s %s%s", t, p.Name, arraySuffix(p.IndexedSize))),
+				docs:      strings.TrimSpace(p.Docs),
+			}
+		}
+		flatten(entries, name, c.Exports)
+	}
+	for _, s := range exports.Scopes {
+		name := qualify(prefix, s.Name)
+		entries[keyOf("scope", name)] = entry{
+			kind:      "scope",
+			signature: "scope " + s.Name,
+			docs:      strings.TrimSpace(s.Docs),
+		}
+		flatten(entries, name, s.Exports)
+	}
+}
+
+func flattenEnum(entries map[entryKey]entry, prefix string, kind string, e EngineEnumType) {
+	name := qualify(prefix, e.Name)
+	entries[keyOf(kind, name)] = entry{
+		kind:      kind,
+		signature: kind + " " + e.Name,
+		docs:      strings.TrimSpace(e.Docs),
+	}
+	for _, v := range e.Enums {
+		signature := v.Name
+		if v.Value != "" {
+			signature += " = " + v.Value
+		}
+		entries[keyOf("enumvalue", qualify(name, v.Name))] = entry{
+			kind:      "enumvalue",
+			signature: signature,
+			docs:      strings.TrimSpace(v.Docs),
+		}
+	}
+}
+
+// FunctionSignature formats a function like a C declaration, with default
+// values and "..." for variadic functions.
+func FunctionSignature(f EngineFunction) string {
+	args := make([]string, 0, len(f.Arguments)+1)
+	for _, arg := range f.Arguments {
+		args = append(args, argumentSignature(arg))
+	}
+	if IsSet(f.IsVariadic) {
+		args = append(args, "...")
+	}
+	returnType := f.ReturnType
+	if returnType == "" {
+		returnType = "void"
+	}
+	return fmt.Sprintf("%s %s(%s)", returnType, f.Name, strings.Join(args, ", "))
+}
+
+func argumentSignature(arg EngineFunctionArgument) string {
+	s := strings.TrimSpace(arg.Type + " " + arg.Name)
+	if arg.DefaultValue != "" {
+		s += " = " + arg.DefaultValue
+	}
+	return s
+}
+
+func functionChanges(old *EngineFunction, new *EngineFunction) []string {
+	details := make([]string, 0)
+	if old.ReturnType != new.ReturnType {
+		details = append(details, fmt.Sprintf("return type changed from %q to %q", old.ReturnType, new.ReturnType))
+	}
+	if IsSet(old.IsVariadic) != IsSet(new.IsVariadic) {
+		if IsSet(new.IsVariadic) {
+			details = append(details, "became variadic")
+		} else {
+			details = append(details, "is no longer variadic")
+		}
+	}
+	if old.Symbol != new.Symbol {
+		details = append(details, fmt.Sprintf("symbol changed from %s to %s", old.Symbol, new.Symbol))
+	}
+
+	for i := 0; i < len(old.Arguments) || i < len(new.Arguments); i++ {
+		switch {
+		case i >= len(new.Arguments):
+			details = append(details, fmt.Sprintf("argument %d (%s) removed", i+1, argumentSignature(old.Arguments[i])))
+		case i >= len(old.Arguments):
+			details = append(details, fmt.Sprintf("argument %d (%s) added", i+1, argumentSignature(new.Arguments[i])))
+		default:
+			o, n := old.Arguments[i], new.Arguments[i]
+			if o.Name != n.Name {
+				details = append(details, fmt.Sprintf("argument %d renamed from %s to %s", i+1, o.Name, n.Name))
+			}
+			if o.Type != n.Type {
+				details = append(details, fmt.Sprintf("argument %d (%s) type changed from %q to %q", i+1, n.Name, o.Type, n.Type))
+			}
+			if o.DefaultValue != n.DefaultValue {
+				details = append(details, fmt.Sprintf("argument %d (%s) default changed from %q to %q", i+1, n.Name, o.DefaultValue, n.DefaultValue))
+			}
+		}
+	}
+	return details
+}
+
+func arraySuffix(indexedSize string) string {
+	indexedSize = strings.TrimSpace(indexedSize)
+	if indexedSize == "" || indexedSize == "0" || indexedSize == "1" {
+		return ""
+	}
+	return "[" + indexedSize + "]"
+}
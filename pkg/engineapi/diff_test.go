@@ -0,0 +1,85 @@
+package engineapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffExports(t *testing.T) {
+	old := &EngineExportScope{
+		Exports: Exports{
+			Functions: []EngineFunction{
+				{Name: "getRealTime", ReturnType: "int", Docs: "Real time."},
+				{Name: "onStart", ReturnType: "void"},
+			},
+			Enums: []EngineEnumType{
+				{Name: "GFXFormat", Enums: []EngineEnum{{Name: "R8G8B8", Value: "0"}, {Name: "A8", Value: "1"}}},
+			},
+			Classes: []EngineClassType{
+				{
+					Name:       "SimObject",
+					Properties: []EngineProperty{{Name: "name", Type: "string"}},
+					Exports: Exports{
+						Functions: []EngineFunction{{Name: "name", ReturnType: "string", Docs: "The name."}},
+					},
+				},
+			},
+		},
+	}
+	new := &EngineExportScope{
+		Exports: Exports{
+			Functions: []EngineFunction{
+				{Name: "getRealTime", ReturnType: "int", Docs: "Real time."},
+				{Name: "onStart", ReturnType: "void", IsCallback: "true"},
+			},
+			Enums: []EngineEnumType{
+				{Name: "GFXFormat", Enums: []EngineEnum{{Name: "R8G8B8", Value: "0"}}},
+			},
+			Structs: []EngineStructType{
+				{Name: "Point3F", Fields: []EngineField{{Name: "x", Type: "float"}}},
+			},
+			Classes: []EngineClassType{
+				{
+					Name:       "SimObject",
+					Properties: []EngineProperty{{Name: "name", Type: "string", IsConstant: "true"}},
+					Exports: Exports{
+						Functions: []EngineFunction{{Name: "name", ReturnType: "string", Docs: "The name of the object."}},
+					},
+				},
+			},
+		},
+	}
+
+	want := []Change{
+		{
+			Type:    Changed,
+			Kind:    "function",
+			Name:    "SimObject::name",
+			Details: []string{"documentation changed"},
+		},
+		{
+			Type:    Changed,
+			Kind:    "callback",
+			Name:    "onStart",
+			Details: []string{"changed from function to callback"},
+		},
+		{
+			Type:    Changed,
+			Kind:    "property",
+			Name:    "SimObject::name",
+			Old:     "string name",
+			New:     "const string name",
+			Details: []string{"type changed"},
+		},
+		{Type: Added, Kind: "struct", Name: "Point3F", New: "struct Point3F"},
+		{Type: Added, Kind: "field", Name: "Point3F::x", New: "float x"},
+		{Type: Removed, Kind: "enumvalue", Name: "GFXFormat::A8", Old: "A8 = 1"},
+	}
+	got := DiffExports(old, new)
+	if !reflect.DeepEqual(got.Changes, want) {
+		t.Errorf("DiffExports() = %+v, want %+v", got.Changes, want)
+	}
+	if n := DiffExports(new, new); len(n.Changes) != 0 {
+		t.Errorf("DiffExports() of the same export = %+v, want no changes", n.Changes)
+	}
+}
@@ -1,7 +1,18 @@
 package engineapi
 
+import "strings"
+
+// IsSet reads the boolean attributes of the export, which are written as either
+// "1" or "true".
+func IsSet(value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	return value == "1" || value == "true"
+}
+
 type EngineFunctionArgument struct {
-	Name string `xml:"name,attr"`
+	Name         string `xml:"name,attr"`
+	Type         string `xml:"type,attr"`
+	DefaultValue string `xml:"defaultValue,attr"`
 }
 
 type EngineFunction struct {
@@ -16,6 +27,9 @@ type EngineFunction struct {
 }
 
 type EngineEnum struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+	Docs  string `xml:"docs,attr"`
 }
 
 type EngineEnumType struct {
@@ -29,11 +43,73 @@ type EngineEnumType struct {
 	Enums []EngineEnum `xml:"enums>EngineEnum"`
 }
 
+// EngineBitfieldType is exported like an enum, its values are the flags that
+// can be combined.
+type EngineBitfieldType EngineEnumType
+
+type EngineField struct {
+	Name        string `xml:"name,attr"`
+	Type        string `xml:"type,attr"`
+	Offset      string `xml:"offset,attr"`
+	IndexedSize string `xml:"indexedSize,attr"`
+	Docs        string `xml:"docs,attr"`
+}
+
+type EngineStructType struct {
+	Name           string `xml:"name,attr"`
+	Size           string `xml:"size,attr"`
+	IsAbstract     string `xml:"isAbstract,attr"`
+	IsInstantiable string `xml:"isInstantiable,attr"`
+	IsDisposable   string `xml:"isDisposable,attr"`
+	IsSingleton    string `xml:"isSingleton,attr"`
+	Docs           string `xml:"docs,attr"`
+
+	Fields []EngineField `xml:"fields>EngineField"`
+}
+
+type EngineProperty struct {
+	Name        string `xml:"name,attr"`
+	Type        string `xml:"type,attr"`
+	IsConstant  string `xml:"isConstant,attr"`
+	IsTransient string `xml:"isTransient,attr"`
+	IsVisible   string `xml:"isVisible,attr"`
+	IndexedSize string `xml:"indexedSize,attr"`
+	Docs        string `xml:"docs,attr"`
+}
+
+type EngineClassType struct {
+	Name           string `xml:"name,attr"`
+	SuperType      string `xml:"superType,attr"`
+	Size           string `xml:"size,attr"`
+	IsAbstract     string `xml:"isAbstract,attr"`
+	IsInstantiable string `xml:"isInstantiable,attr"`
+	IsDisposable   string `xml:"isDisposable,attr"`
+	IsSingleton    string `xml:"isSingleton,attr"`
+	Docs           string `xml:"docs,attr"`
+
+	Properties []EngineProperty `xml:"properties>EngineProperty"`
+	// Exports holds the methods and callbacks of the class.
+	Exports Exports `xml:"exports"`
+}
+
+type EnginePrimitiveType struct {
+	Name string `xml:"name,attr"`
+	Size string `xml:"size,attr"`
+	Docs string `xml:"docs,attr"`
+}
+
 type Exports struct {
-	Functions []EngineFunction `xml:"EngineFunction"`
-	Enums     []EngineEnumType `xml:"EngineEnumType"`
+	Functions  []EngineFunction      `xml:"EngineFunction"`
+	Enums      []EngineEnumType      `xml:"EngineEnumType"`
+	Bitfields  []EngineBitfieldType  `xml:"EngineBitfieldType"`
+	Structs    []EngineStructType    `xml:"EngineStructType"`
+	Classes    []EngineClassType     `xml:"EngineClassType"`
+	Primitives []EnginePrimitiveType `xml:"EnginePrimitiveType"`
+	Scopes     []EngineExportScope   `xml:"EngineExportScope"`
 }
 
 type EngineExportScope struct {
+	Name    string  `xml:"name,attr"`
+	Docs    string  `xml:"docs,attr"`
 	Exports Exports `xml:"exports"`
 }
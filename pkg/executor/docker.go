@@ -0,0 +1,267 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/docker/pkg/term"
+)
+
+func BuildImage(tag string, cli client.Client, ctx context.Context) error {
+	contextPath, _ := filepath.Abs("./files/")
+	buildOpts := types.ImageBuildOptions{
+		Dockerfile: "./Dockerfile",
+		Tags:       []string{tag},
+	}
+
+	buildCtx, err := archive.TarWithOptions(contextPath, &archive.TarOptions{})
+	if err != nil {
+		return err
+	}
+
+	resp, err := cli.ImageBuild(ctx, buildCtx, buildOpts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to build image")
+	}
+	defer resp.Body.Close()
+
+	termFd, isTerm := term.GetFdInfo(os.Stderr)
+	return jsonmessage.DisplayJSONMessagesStream(resp.Body, os.Stderr, termFd, isTerm, nil)
+}
+
+func PullImageIfNotExists(cli *client.Client, tag string, ctx context.Context) error {
+	_, _, err := cli.ImageInspectWithRaw(ctx, tag)
+	if err != nil && !client.IsErrNotFound(err) {
+		return errors.Wrapf(err, "Failed to inspect image %s", tag)
+	}
+
+	if err != nil {
+		pullResp, err := cli.ImagePull(ctx, tag, types.ImagePullOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "Failed to pull image %s", tag)
+		}
+		defer pullResp.Close()
+
+		termFd, isTerm := term.GetFdInfo(os.Stderr)
+		return jsonmessage.DisplayJSONMessagesStream(pullResp, os.Stderr, termFd, isTerm, nil)
+	}
+	return nil
+}
+
+// DefaultImage is the image the Docker executor runs scripts in. Its main.cs
+// can't read scripts from a file, so it can't be used for warm containers.
+const DefaultImage = "lukaspj/t3deval:4_0Preview"
+
+// Docker runs every script in a fresh container of Image.
+type Docker struct {
+	Image  string
+	Limits Limits
+
+	cli *client.Client
+	// prefix and count make the container names unique, the process id keeps
+	// the names of executors sharing a docker daemon apart.
+	prefix string
+	count  int64
+
+	// imageMu guards imageReady, which caches that Image has been pulled.
+	imageMu    sync.Mutex
+	imageReady bool
+}
+
+func NewDocker(image string, limits Limits) (*Docker, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Docker{
+		Image:  image,
+		Limits: limits,
+		cli:    cli,
+		prefix: fmt.Sprintf("t3deval-%d", os.Getpid()),
+	}, nil
+}
+
+// ensureImage pulls Image if it's missing. Once the image is present it isn't
+// checked again, removing it while the executor runs makes creating containers
+// fail.
+func (d *Docker) ensureImage(ctx context.Context) error {
+	d.imageMu.Lock()
+	defer d.imageMu.Unlock()
+	if d.imageReady {
+		return nil
+	}
+
+	err := PullImageIfNotExists(d.cli, d.Image, ctx)
+	if err != nil {
+		return err
+	}
+	d.imageReady = true
+	return nil
+}
+
+func (d *Docker) Evaluate(script string, ctx context.Context) (*Result, error) {
+	err := d.ensureImage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	id, err := d.create([]string{script}, nil, ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer d.remove(id)
+
+	return d.run(id, start, ctx)
+}
+
+// create creates a sandboxed container running cmd with the additional mounts.
+func (d *Docker) create(cmd []string, mounts []mount.Mount, ctx context.Context) (string, error) {
+	limits := d.Limits
+	name := fmt.Sprintf("%s-%d", d.prefix, atomic.AddInt64(&d.count, 1))
+	pids := limits.PIDs
+	containerResp, err := d.cli.ContainerCreate(
+		ctx,
+		&container.Config{
+			Image:           d.Image,
+			Cmd:             cmd,
+			NetworkDisabled: true,
+			WorkingDir:      "/tmp",
+		},
+		&container.HostConfig{
+			Resources: container.Resources{
+				NanoCPUs:   int64(limits.CPUs * 1e9),
+				Memory:     limits.Memory,
+				MemorySwap: limits.Memory,
+				PidsLimit:  &pids,
+			},
+			ReadonlyRootfs: true,
+			Tmpfs: map[string]string{
+				"/tmp": fmt.Sprintf("rw,noexec,nosuid,size=%d", limits.Tmpfs),
+			},
+			Mounts:      mounts,
+			CapDrop:     []string{"ALL"},
+			SecurityOpt: []string{"no-new-privileges"},
+			// The output is read from the attached streams, the daemon
+			// doesn't need to keep it.
+			LogConfig: container.LogConfig{
+				Type: "none",
+			},
+		},
+		&network.NetworkingConfig{},
+		nil,
+		name,
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to create container %s", name)
+	}
+	return containerResp.ID, nil
+}
+
+func (d *Docker) remove(id string) {
+	err := d.cli.ContainerRemove(context.Background(), id, types.ContainerRemoveOptions{
+		Force: true,
+	})
+	if err != nil {
+		log.Printf("Failed to remove container %s", id)
+	}
+}
+
+// run starts a created container, collects its output while it runs and waits
+// for it to stop. The container is killed as soon as it exceeds the output limit.
+func (d *Docker) run(id string, start time.Time, ctx context.Context) (*Result, error) {
+	cli := d.cli
+	limits := d.Limits
+
+	// Attach before starting, so none of the output is missed.
+	attach, err := cli.ContainerAttach(ctx, id, types.ContainerAttachOptions{
+		Stream: true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to attach to container %s", id)
+	}
+	defer attach.Close()
+
+	stdout, stderr, exceeded := newOutput(limits.Output)
+	copied := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(stdout, stderr, attach.Reader)
+		copied <- err
+	}()
+
+	err = cli.ContainerStart(ctx, id, types.ContainerStartOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to run container %s", id)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+	result := &Result{}
+	var evalErr *Error
+	waitCh, errCh := cli.ContainerWait(timeoutCtx, id, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		if ctx.Err() != nil || timeoutCtx.Err() != context.DeadlineExceeded {
+			return nil, errors.Wrapf(err, "Failed to wait on container %s", id)
+		}
+		err = cli.ContainerKill(ctx, id, "KILL")
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to kill container %s", id)
+		}
+		result.ExitCode = -1
+		evalErr = &Error{Kind: Timeout, Result: result}
+	case <-exceeded:
+		err = cli.ContainerKill(ctx, id, "KILL")
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to kill container %s", id)
+		}
+		result.ExitCode = -1
+		evalErr = &Error{Kind: OutputLimit, Result: result}
+	case status := <-waitCh:
+		result.ExitCode = status.StatusCode
+	}
+	result.Duration = time.Since(start)
+
+	// The streams end once the container stopped.
+	select {
+	case err = <-copied:
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read the output of container %s", id)
+		}
+	case <-ctx.Done():
+		return nil, errors.WithStack(ctx.Err())
+	}
+
+	if evalErr == nil {
+		info, err := cli.ContainerInspect(ctx, id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to inspect container %s", id)
+		}
+		if info.ContainerJSONBase != nil && info.State != nil && info.State.OOMKilled {
+			evalErr = &Error{Kind: OutOfMemory, Result: result}
+		}
+	}
+
+	err = finish(result, stdout, stderr)
+	if evalErr != nil {
+		return result, evalErr
+	}
+	return result, err
+}
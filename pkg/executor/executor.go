@@ -0,0 +1,166 @@
+// Package executor runs TorqueScript snippets in a sandbox and reports what
+// they printed.
+package executor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Executor evaluates scripts. Implementations must be safe for concurrent use,
+// and return an *Error when the script ran but didn't finish successfully.
+type Executor interface {
+	Evaluate(script string, ctx context.Context) (*Result, error)
+}
+
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int64
+	Duration time.Duration
+}
+
+// Limits are the resources a single script evaluation may use. Not every
+// Executor can enforce all of them.
+type Limits struct {
+	// CPUs is the number of CPUs the container may use, fractions are allowed.
+	CPUs float64
+	// Memory is the memory limit in bytes, swap is disabled.
+	Memory int64
+	// PIDs limits the number of processes and threads in the container.
+	PIDs int64
+	// Output is the maximum number of bytes kept of stdout and stderr combined.
+	Output int64
+	// Tmpfs is the size in bytes of the writable /tmp workspace.
+	Tmpfs int64
+	// Timeout is the wall clock time the script may run for.
+	Timeout time.Duration
+}
+
+func DefaultLimits() Limits {
+	return Limits{
+		CPUs:    1,
+		Memory:  256 * 1024 * 1024,
+		PIDs:    64,
+		Output:  64 * 1024,
+		Tmpfs:   16 * 1024 * 1024,
+		Timeout: 10 * time.Second,
+	}
+}
+
+// RegisterFlags adds flags for the limits to fs, using the current values as
+// the defaults.
+func (l *Limits) RegisterFlags(fs *flag.FlagSet) {
+	fs.Float64Var(&l.CPUs, "cpus", l.CPUs, "number of CPUs a script may use")
+	fs.Int64Var(&l.Memory, "memory", l.Memory, "memory limit of a script in bytes")
+	fs.Int64Var(&l.PIDs, "pids", l.PIDs, "maximum number of processes of a script")
+	fs.Int64Var(&l.Output, "output", l.Output, "maximum number of output bytes kept of a script")
+	fs.Int64Var(&l.Tmpfs, "tmpfs", l.Tmpfs, "size of the /tmp workspace of a script in bytes")
+	fs.DurationVar(&l.Timeout, "timeout", l.Timeout, "wall clock time a script may run for")
+}
+
+type ErrorKind string
+
+const (
+	// Timeout means the script ran longer than Limits.Timeout.
+	Timeout ErrorKind = "timeout"
+	// OutOfMemory means the script was killed for using more than Limits.Memory.
+	OutOfMemory ErrorKind = "oom"
+	// OutputLimit means the script wrote more than Limits.Output bytes.
+	OutputLimit ErrorKind = "output"
+	// ScriptError means the script exited with a non-zero exit code.
+	ScriptError ErrorKind = "script"
+)
+
+// Error is returned when a script was evaluated, but didn't finish
+// successfully. Result holds whatever the script produced before it stopped.
+type Error struct {
+	Kind   ErrorKind
+	Result *Result
+}
+
+func (e *Error) Error() string {
+	switch e.Kind {
+	case Timeout:
+		return fmt.Sprintf("script timed out after %v", e.Result.Duration.Round(time.Millisecond))
+	case OutOfMemory:
+		return "script ran out of memory"
+	case OutputLimit:
+		return "script output exceeded the limit and was truncated"
+	case ScriptError:
+		return fmt.Sprintf("script exited with code %d", e.Result.ExitCode)
+	default:
+		return fmt.Sprintf("script failed: %s", e.Kind)
+	}
+}
+
+// finish returns the error for a script that ran to completion, or nil if it
+// finished successfully.
+func finish(result *Result, stdout *limitedBuffer, stderr *limitedBuffer) error {
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	if stdout.truncated || stderr.truncated {
+		return &Error{Kind: OutputLimit, Result: result}
+	}
+	if result.ExitCode != 0 {
+		return &Error{Kind: ScriptError, Result: result}
+	}
+	return nil
+}
+
+// outputLimit is the number of bytes stdout and stderr may still write
+// together.
+type outputLimit struct {
+	mu        sync.Mutex
+	remaining int64
+	// exceeded is closed the first time more than the limit is written.
+	exceeded chan struct{}
+}
+
+// limitedBuffer keeps the first bytes written to it up to its limit and
+// discards the rest.
+type limitedBuffer struct {
+	data      []byte
+	limit     *outputLimit
+	truncated bool
+}
+
+// newOutput returns the buffers for stdout and stderr, which together keep at
+// most limit bytes, and a channel that is closed once they were given more.
+func newOutput(limit int64) (*limitedBuffer, *limitedBuffer, <-chan struct{}) {
+	shared := &outputLimit{
+		remaining: limit,
+		exceeded:  make(chan struct{}),
+	}
+	return &limitedBuffer{limit: shared}, &limitedBuffer{limit: shared}, shared.exceeded
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.limit.mu.Lock()
+	defer b.limit.mu.Unlock()
+
+	n := int64(len(p))
+	if n > b.limit.remaining {
+		n = b.limit.remaining
+		if !b.truncated {
+			b.truncated = true
+			select {
+			case <-b.limit.exceeded:
+			default:
+				close(b.limit.exceeded)
+			}
+		}
+	}
+	b.data = append(b.data, p[:n]...)
+	b.limit.remaining -= n
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.limit.mu.Lock()
+	defer b.limit.mu.Unlock()
+	return string(b.data)
+}
@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"testing"
+)
+
+func TestOutputLimit(t *testing.T) {
+	stdout, stderr, exceeded := newOutput(8)
+
+	_, _ = stdout.Write([]byte("hello"))
+	_, _ = stderr.Write([]byte("abc"))
+	select {
+	case <-exceeded:
+		t.Fatal("exceeded is closed at exactly the limit")
+	default:
+	}
+
+	n, err := stderr.Write([]byte("def"))
+	if n != 3 || err != nil {
+		t.Errorf("Write() = %d, %v, want 3, nil", n, err)
+	}
+	_, _ = stdout.Write([]byte("world"))
+	select {
+	case <-exceeded:
+	default:
+		t.Fatal("exceeded isn't closed past the limit")
+	}
+
+	result := &Result{}
+	err = finish(result, stdout, stderr)
+	if evalErr, ok := err.(*Error); !ok || evalErr.Kind != OutputLimit {
+		t.Errorf("finish() = %v, want an OutputLimit error", err)
+	}
+	if result.Stdout != "hello" || result.Stderr != "abc" {
+		t.Errorf("kept %q and %q, want %q and %q", result.Stdout, result.Stderr, "hello", "abc")
+	}
+}
@@ -0,0 +1,55 @@
+package executor
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+// Fake doesn't run scripts. By default it echoes the script to stdout, Func can
+// replace that to simulate failures. It records the scripts it was given, which
+// makes it useful to exercise the code around an Executor.
+type Fake struct {
+	// Delay is how long an evaluation takes, it is cut short if ctx is done.
+	Delay time.Duration
+	Func  func(script string) (*Result, error)
+
+	mu      sync.Mutex
+	scripts []string
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Evaluate(script string, ctx context.Context) (*Result, error) {
+	f.mu.Lock()
+	f.scripts = append(f.scripts, script)
+	f.mu.Unlock()
+
+	if f.Delay > 0 {
+		timer := time.NewTimer(f.Delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, errors.WithStack(ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	if f.Func != nil {
+		return f.Func(script)
+	}
+	return &Result{
+		Stdout:   script,
+		Duration: f.Delay,
+	}, nil
+}
+
+// Scripts returns the scripts evaluated so far, in the order they were given.
+func (f *Fake) Scripts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.scripts...)
+}
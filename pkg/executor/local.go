@@ -0,0 +1,111 @@
+package executor
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Local runs scripts with a Torque3D dedicated server binary on this machine.
+// Every evaluation gets a temporary working directory containing MainScript, so
+// concurrent evaluations don't share files. Only the Timeout and Output limits
+// are enforced, by killing the process, it isn't sandboxed.
+type Local struct {
+	Binary     string
+	MainScript string
+	Limits     Limits
+}
+
+func NewLocal(binary string, mainScript string, limits Limits) (*Local, error) {
+	binary, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to find Torque3D binary")
+	}
+	binary, err = filepath.Abs(binary)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	_, err = os.Stat(mainScript)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to find main script")
+	}
+	return &Local{
+		Binary:     binary,
+		MainScript: mainScript,
+		Limits:     limits,
+	}, nil
+}
+
+func (l *Local) Evaluate(script string, ctx context.Context) (*Result, error) {
+	dir, err := ioutil.TempDir("", "t3deval")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	main, err := ioutil.ReadFile(l.MainScript)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "main.cs"), main, 0644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, l.Limits.Timeout)
+	defer cancel()
+
+	// The process is killed once it wrote more than the output limit, like
+	// the containers of the Docker executor.
+	runCtx, kill := context.WithCancel(timeoutCtx)
+	defer kill()
+	stdout, stderr, exceeded := newOutput(l.Limits.Output)
+	go func() {
+		select {
+		case <-exceeded:
+			kill()
+		case <-runCtx.Done():
+		}
+	}()
+
+	cmd := exec.CommandContext(runCtx, l.Binary, script)
+	cmd.Dir = dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	result := &Result{
+		Duration: time.Since(start),
+	}
+	if ctx.Err() != nil {
+		return nil, errors.WithStack(ctx.Err())
+	}
+	if timeoutCtx.Err() == context.DeadlineExceeded {
+		result.ExitCode = -1
+		result.Stdout = stdout.String()
+		result.Stderr = stderr.String()
+		return result, &Error{Kind: Timeout, Result: result}
+	}
+	select {
+	case <-exceeded:
+		result.ExitCode = -1
+		result.Stdout = stdout.String()
+		result.Stderr = stderr.String()
+		return result, &Error{Kind: OutputLimit, Result: result}
+	default:
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		result.ExitCode = int64(exitErr.ExitCode())
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "Failed to run %s", l.Binary)
+	}
+
+	return result, finish(result, stdout, stderr)
+}
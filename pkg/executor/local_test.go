@@ -0,0 +1,76 @@
+package executor
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// writeScript writes a shell script standing in for the Torque3D binary.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "t3d")
+	err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLocalLimits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in binary is a shell script")
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		wantKind ErrorKind
+		stdout   string
+	}{
+		{name: "success", body: `echo "$1"`, stdout: "echo();\n"},
+		{name: "script error", body: "exit 3", wantKind: ScriptError},
+		{name: "output flood", body: "while :; do echo flood; done", wantKind: OutputLimit},
+		{name: "timeout", body: "exec sleep 10", wantKind: Timeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			main := filepath.Join(t.TempDir(), "main.cs")
+			err := ioutil.WriteFile(main, []byte("// main"), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l, err := NewLocal(writeScript(t, tt.body), main, Limits{Output: 1024, Timeout: 2 * time.Second})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now()
+			result, err := l.Evaluate("echo();", context.Background())
+			if elapsed := time.Since(start); tt.wantKind != Timeout && elapsed > time.Second {
+				t.Errorf("evaluation took %v", elapsed)
+			}
+
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Fatalf("Evaluate() = %v", err)
+				}
+				if result.Stdout != tt.stdout {
+					t.Errorf("stdout = %q, want %q", result.Stdout, tt.stdout)
+				}
+				return
+			}
+			evalErr, ok := err.(*Error)
+			if !ok || evalErr.Kind != tt.wantKind {
+				t.Fatalf("Evaluate() = %v, want a %s error", err, tt.wantKind)
+			}
+			if tt.wantKind == OutputLimit && len(result.Stdout) > 1024 {
+				t.Errorf("kept %d bytes of output", len(result.Stdout))
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+// warmScript is where warm containers read the script from, main.cs evaluates
+// the file when it's started with "-file <path>".
+const warmScript = "/script/script.cs"
+
+// warmProbe is printed by the script Check evaluates.
+const warmProbe = "t3deval-warm-probe"
+
+type warmContainer struct {
+	id  string
+	dir string
+}
+
+// Warm keeps a number of created containers ready, so evaluating a script only
+// has to start one. Since the script isn't known when the container is created,
+// every container bind mounts its own directory under Dir, which the script is
+// written to before the container starts. Dir must therefore be a path the
+// docker daemon can see. Used containers are replaced in the background, and
+// when none are idle scripts are evaluated in a fresh container instead.
+type Warm struct {
+	Docker *Docker
+	Dir    string
+
+	idle chan *warmContainer
+	// slots holds a value for every container that is being prepared or is
+	// idle, so there are never more than the size of the pool.
+	slots  chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewWarm(d *Docker, size int, dir string) (*Warm, error) {
+	if size < 1 {
+		return nil, errors.Errorf("warm pool size must be at least 1, got %d", size)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Warm{
+		Docker: d,
+		Dir:    dir,
+		idle:   make(chan *warmContainer, size),
+		slots:  make(chan struct{}, size),
+	}, nil
+}
+
+// Check evaluates a script in a pre-created container, to make sure the image
+// reads scripts from a file. Images whose main.cs predates files/main.cs
+// don't, and would fail every script given to a warm container.
+func (w *Warm) Check(ctx context.Context) error {
+	c, err := w.prepare(ctx)
+	if err != nil {
+		return err
+	}
+	defer w.discard(c)
+
+	result, err := w.evaluate(c, fmt.Sprintf("echo(\"%s\");", warmProbe), ctx)
+	if result != nil && strings.Contains(result.Stdout, warmProbe) {
+		return nil
+	}
+	if err == nil {
+		err = errors.Errorf("the script printed %q", result.Stdout)
+	}
+	return errors.Wrapf(err, "Image %s can't evaluate scripts from a file, pre-created containers need an image with the files/main.cs of this repository", w.Docker.Image)
+}
+
+// Start fills the pool in the background until Close is called.
+func (w *Warm) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+	go w.fill(ctx)
+}
+
+func (w *Warm) fill(ctx context.Context) {
+	defer close(w.done)
+	for {
+		select {
+		case w.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		c, err := w.prepare(ctx)
+		if err != nil {
+			<-w.slots
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to prepare warm container: %+v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		// The slot makes sure there is room.
+		w.idle <- c
+	}
+}
+
+func (w *Warm) prepare(ctx context.Context) (*warmContainer, error) {
+	err := w.Docker.ensureImage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := ioutil.TempDir(w.Dir, "container")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// The container doesn't run as the user that owns the directory.
+	err = os.Chmod(dir, 0755)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, errors.WithStack(err)
+	}
+
+	id, err := w.Docker.create([]string{"-file", warmScript}, []mount.Mount{
+		{
+			Type:     mount.TypeBind,
+			Source:   dir,
+			Target:   filepath.Dir(warmScript),
+			ReadOnly: true,
+		},
+	}, ctx)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return &warmContainer{id: id, dir: dir}, nil
+}
+
+func (w *Warm) discard(c *warmContainer) {
+	w.Docker.remove(c.id)
+	err := os.RemoveAll(c.dir)
+	if err != nil {
+		log.Printf("Failed to remove %s: %v", c.dir, err)
+	}
+}
+
+func (w *Warm) Evaluate(script string, ctx context.Context) (*Result, error) {
+	var c *warmContainer
+	select {
+	case c = <-w.idle:
+		<-w.slots
+	default:
+		return w.Docker.Evaluate(script, ctx)
+	}
+	defer w.discard(c)
+
+	return w.evaluate(c, script, ctx)
+}
+
+func (w *Warm) evaluate(c *warmContainer, script string, ctx context.Context) (*Result, error) {
+	start := time.Now()
+	err := ioutil.WriteFile(filepath.Join(c.dir, filepath.Base(warmScript)), []byte(script), 0644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return w.Docker.run(c.id, start, ctx)
+}
+
+// Idle returns the number of containers ready to evaluate a script.
+func (w *Warm) Idle() int {
+	return len(w.idle)
+}
+
+// Close stops filling the pool and removes the idle containers.
+func (w *Warm) Close() error {
+	if w.cancel != nil {
+		w.cancel()
+		<-w.done
+	}
+	for {
+		select {
+		case c := <-w.idle:
+			w.discard(c)
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,515 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"ScriptExecServer/pkg/goxy"
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Core is the rendering state shared by the renderers of every output: the
+// compounds and refs of the doc set, the links to their pages and the tracking
+// of the refs a page depends on.
+type Core struct {
+	Section string
+
+	CompoundIdMap map[string]*goxy.CompoundDoc
+	CompoundRefs  map[string]goxy.CompoundRef
+
+	// Diagnostics receives the problems found while rendering, like refs
+	// missing from the ref table. They aren't reported if it is nil.
+	Diagnostics *diagnostics.Collector
+
+	pageHref     func(kind string, refId string) string
+	dependencies map[string]bool
+	// compound is the id of the compound being rendered, the problems found
+	// while rendering it are reported on it. reported holds the unknown refs
+	// that were reported, by compound.
+	compound string
+	reported map[string]bool
+}
+
+// NewCore creates the core of a renderer, pageHref returns the URL of the page
+// of a compound in the output.
+func NewCore(section string, idMap map[string]*goxy.CompoundDoc, refs map[string]goxy.CompoundRef, pageHref func(kind string, refId string) string) *Core {
+	return &Core{
+		Section:       section,
+		CompoundIdMap: idMap,
+		CompoundRefs:  refs,
+		pageHref:      pageHref,
+		reported:      make(map[string]bool),
+	}
+}
+
+// StartTracking makes the core record the id of every ref it resolves, until
+// StopTracking is called.
+func (c *Core) StartTracking() {
+	c.dependencies = make(map[string]bool)
+}
+
+// StopTracking returns the sorted ids of the refs resolved since StartTracking.
+func (c *Core) StopTracking() []string {
+	ids := make([]string, 0, len(c.dependencies))
+	for id := range c.dependencies {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	c.dependencies = nil
+	return ids
+}
+
+func (c *Core) track(refId string) {
+	if c.dependencies != nil {
+		c.dependencies[refId] = true
+	}
+}
+
+// startCompound notes the compound that is rendered next, so the problems
+// found while rendering it are reported on it.
+func (c *Core) startCompound(compound *goxy.CompoundDoc) {
+	c.compound = compound.Id
+}
+
+// reportf reports a problem on the compound being rendered, or on the section
+// outside of a compound.
+func (c *Core) reportf(severity diagnostics.Severity, format string, args ...interface{}) {
+	if c.Diagnostics == nil {
+		return
+	}
+	file := c.compound
+	if file == "" {
+		file = c.Section
+	}
+	c.Diagnostics.Reportf(severity, file, format, args...)
+}
+
+// unknownRef reports a ref that is rendered as plain text, as it is missing
+// from the ref table. Every ref is reported once per compound.
+func (c *Core) unknownRef(refId string) {
+	key := c.compound + "\x00" + refId
+	if c.reported[key] {
+		return
+	}
+	c.reported[key] = true
+	c.reportf(diagnostics.Warning, "unknown ref: %s", refId)
+}
+
+// PageHref returns the URL of the page of a compound.
+func (c *Core) PageHref(kind string, refId string) string {
+	return c.pageHref(kind, refId)
+}
+
+// LookupRef looks up an entry of the ref table.
+func (c *Core) LookupRef(refId string) (goxy.CompoundRef, bool) {
+	c.track(refId)
+	ref, ok := c.CompoundRefs[refId]
+	return ref, ok
+}
+
+// LookupHref returns the URL of a ref: the page of a compound, or the anchor of
+// a member on the page of its compound.
+func (c *Core) LookupHref(refId string) (string, bool) {
+	ref, ok := c.LookupRef(refId)
+	if !ok {
+		return "", false
+	}
+	if p, ok := c.LookupParent(ref); ok {
+		return fmt.Sprintf("%s#%s", c.PageHref(p.Kind, p.RefId), ref.RefId), true
+	}
+	return c.PageHref(ref.Kind, ref.RefId), true
+}
+
+// LookupCompound looks up a loaded compound.
+func (c *Core) LookupCompound(refId string) (*goxy.CompoundDoc, bool) {
+	c.track(refId)
+	compound, ok := c.CompoundIdMap[refId]
+	return compound, ok
+}
+
+// LookupParent looks up the entry of the compound a member ref belongs to.
+func (c *Core) LookupParent(ref goxy.CompoundRef) (goxy.CompoundRef, bool) {
+	if ref.ParentRef == "" || ref.ParentRef == goxy.NoParentRef {
+		return goxy.CompoundRef{}, false
+	}
+	return c.LookupRef(ref.ParentRef)
+}
+
+// CompoundKind returns the kind of a compound from the ref table, which is
+// empty if the ref is unknown.
+func (c *Core) CompoundKind(refId string) string {
+	ref, _ := c.LookupRef(refId)
+	return ref.Kind
+}
+
+// CompoundTitle returns the title of a compound, or its name from the ref table
+// if the compound wasn't loaded.
+func (c *Core) CompoundTitle(refId string) string {
+	if compound, ok := c.LookupCompound(refId); ok {
+		return compound.Title
+	}
+	return c.CompoundRefs[refId].Name
+}
+
+// CompoundBrief returns the brief description of a compound, which is empty if
+// the compound wasn't loaded.
+func (c *Core) CompoundBrief(refId string) goxy.DocString {
+	if compound, ok := c.LookupCompound(refId); ok {
+		return compound.BriefDescription
+	}
+	return goxy.DocString{}
+}
+
+type VariableListItem struct {
+	Term    bool
+	Content string
+}
+
+type ParameterListItem struct {
+	Name        string
+	Description string
+}
+
+type TableCell struct {
+	Head    bool
+	Content string
+}
+
+// Markup renders the elements of doc strings in the syntax of an output.
+// RenderDocString walks the doc string and renders the children of containers
+// first, passing them in as content. Code is passed in unrendered, as outputs
+// differ in whether links survive inside it.
+type Markup interface {
+	Text(text string) string
+	Paragraph(content string) string
+	Emphasis(content string) string
+	Bold(content string) string
+	Verbatim(content goxy.DocString) string
+	Preformatted(content goxy.DocString) string
+	ComputerOutput(content goxy.DocString) string
+	Highlight(language string, content goxy.DocString) string
+	ItemizedList(items []string) string
+	OrderedList(items []string) string
+	VariableList(items []VariableListItem) string
+	Term(content string) string
+	Heading(level int, content string) string
+	Title(content string) string
+	XRefSect(id string, title string, description string) string
+	Ref(refId string, content string) string
+	// Ulink links content to an external URL, content is empty when the
+	// link has no text of its own.
+	Ulink(url string, content string) string
+	Anchor(id string) string
+	SimpleSection(kind string, id string, content string) string
+	ParameterList(kind string, items []ParameterListItem) string
+	Table(rows [][]TableCell) string
+	Image(image goxy.DocStringImage) string
+	LineBreak() string
+	HorizontalRule() string
+	BlockQuote(content string) string
+	Details(summary string, content string) string
+	Strike(content string) string
+	Underline(content string) string
+	Subscript(content string) string
+	Superscript(content string) string
+	// Formula renders a LaTeX formula, including its delimiters.
+	Formula(formula string) string
+	Diagram(diagram goxy.DocStringDiagram) string
+}
+
+// RenderDocString renders a doc string with the markup of an output.
+func RenderDocString(m Markup, docstring goxy.DocString) string {
+	buf := bytes.NewBufferString("")
+
+	for _, element := range docstring.Content {
+		switch e := element.Value.(type) {
+		case goxy.DocStringText:
+			_, _ = fmt.Fprint(buf, m.Text(e.Content))
+		case goxy.DocStringParagraph:
+			_, _ = fmt.Fprint(buf, m.Paragraph(RenderDocString(m, e.Content)))
+		case goxy.DocStringEmphasis:
+			_, _ = fmt.Fprint(buf, m.Emphasis(RenderDocString(m, e.Content)))
+		case goxy.DocStringBold:
+			_, _ = fmt.Fprint(buf, m.Bold(RenderDocString(m, e.Content)))
+		case goxy.DocStringVerbatim:
+			_, _ = fmt.Fprint(buf, m.Verbatim(e.Content))
+		case goxy.DocStringPreformatted:
+			_, _ = fmt.Fprint(buf, m.Preformatted(e.Content))
+		case goxy.DocStringComputerOutput:
+			_, _ = fmt.Fprint(buf, m.ComputerOutput(e.Content))
+		case goxy.DocStringHighlight:
+			_, _ = fmt.Fprint(buf, m.Highlight(e.Language, e.Content))
+		case goxy.DocStringCodeLine:
+			_, _ = fmt.Fprint(buf, RenderDocString(m, e.Content)+"\n")
+		case goxy.DocStringCodeHighlight:
+			_, _ = fmt.Fprint(buf, RenderDocString(m, e.Content))
+		case goxy.DocStringItemizedList:
+			_, _ = fmt.Fprint(buf, m.ItemizedList(renderItems(m, e.Items)))
+		case goxy.DocStringOrderedList:
+			_, _ = fmt.Fprint(buf, m.OrderedList(renderItems(m, e.Items)))
+		case goxy.DocStringVariableList:
+			items := make([]VariableListItem, len(e.Items))
+			for i, item := range e.Items {
+				items[i] = VariableListItem{
+					Term:    item.Content[0].Type == goxy.Term,
+					Content: RenderDocString(m, item),
+				}
+			}
+			_, _ = fmt.Fprint(buf, m.VariableList(items))
+		case goxy.DocStringTerm:
+			_, _ = fmt.Fprint(buf, m.Term(RenderDocString(m, e.Content)))
+		case goxy.DocStringHeading:
+			_, _ = fmt.Fprint(buf, m.Heading(e.Level, RenderDocString(m, e.Content)))
+		case goxy.DocStringTitle:
+			_, _ = fmt.Fprint(buf, m.Title(RenderDocString(m, e.Content)))
+		case goxy.DocStringXRefSect:
+			_, _ = fmt.Fprint(buf, m.XRefSect(e.Id, e.Title, RenderDocString(m, e.Description)))
+		case goxy.DocStringRef:
+			_, _ = fmt.Fprint(buf, m.Ref(e.RefId, RenderDocString(m, e.Content)))
+		case goxy.DocStringUlink:
+			_, _ = fmt.Fprint(buf, m.Ulink(e.Url, RenderDocString(m, e.Content)))
+		case goxy.DocStringAnchor:
+			_, _ = fmt.Fprint(buf, m.Anchor(e.Id))
+		case goxy.DocStringSection:
+			_, _ = fmt.Fprint(buf, m.SimpleSection(e.Kind, e.Id, RenderDocString(m, e.Content)))
+		case goxy.DocStringParameterList:
+			items := make([]ParameterListItem, len(e.Items))
+			for i, item := range e.Items {
+				items[i] = ParameterListItem{
+					Name:        item.Name,
+					Description: RenderDocString(m, item.Description),
+				}
+			}
+			_, _ = fmt.Fprint(buf, m.ParameterList(e.Kind, items))
+		case goxy.DocStringTable:
+			rows := make([][]TableCell, len(e.Rows))
+			for i, row := range e.Rows {
+				rows[i] = make([]TableCell, len(row))
+				for j, entry := range row {
+					rows[i][j] = TableCell{
+						Head:    entry.Head,
+						Content: RenderDocString(m, entry.Content),
+					}
+				}
+			}
+			_, _ = fmt.Fprint(buf, m.Table(rows))
+		case goxy.DocStringImage:
+			_, _ = fmt.Fprint(buf, m.Image(e))
+		case goxy.DocStringLinebreak:
+			_, _ = fmt.Fprint(buf, m.LineBreak())
+		case goxy.DocStringHorizontalRule:
+			_, _ = fmt.Fprint(buf, m.HorizontalRule())
+		case goxy.DocStringBlockQuote:
+			_, _ = fmt.Fprint(buf, m.BlockQuote(RenderDocString(m, e.Content)))
+		case goxy.DocStringDetails:
+			_, _ = fmt.Fprint(buf, m.Details(RenderDocString(m, e.Summary), RenderDocString(m, e.Content)))
+		case goxy.DocStringStrike:
+			_, _ = fmt.Fprint(buf, m.Strike(RenderDocString(m, e.Content)))
+		case goxy.DocStringUnderline:
+			_, _ = fmt.Fprint(buf, m.Underline(RenderDocString(m, e.Content)))
+		case goxy.DocStringSubscript:
+			_, _ = fmt.Fprint(buf, m.Subscript(RenderDocString(m, e.Content)))
+		case goxy.DocStringSuperscript:
+			_, _ = fmt.Fprint(buf, m.Superscript(RenderDocString(m, e.Content)))
+		case goxy.DocStringFormula:
+			_, _ = fmt.Fprint(buf, m.Formula(e.Content))
+		case goxy.DocStringDiagram:
+			_, _ = fmt.Fprint(buf, m.Diagram(e))
+		default:
+			log.Fatalf("error: %+v", errors.WithStack(errors.New("unable to resolve docstring type: "+string(element.Type))))
+		}
+	}
+
+	return buf.String()
+}
+
+// FormulaTeX returns the LaTeX of a formula without its delimiters, and
+// whether it is a block rather than part of the text.
+func FormulaTeX(formula string) (string, bool) {
+	switch {
+	case len(formula) >= 2 && strings.HasPrefix(formula, "$") && strings.HasSuffix(formula, "$"):
+		return strings.TrimSpace(formula[1 : len(formula)-1]), false
+	case len(formula) >= 4 && strings.HasPrefix(formula, "\\(") && strings.HasSuffix(formula, "\\)"):
+		return strings.TrimSpace(formula[2 : len(formula)-2]), false
+	case len(formula) >= 4 && strings.HasPrefix(formula, "\\[") && strings.HasSuffix(formula, "\\]"):
+		return strings.TrimSpace(formula[2 : len(formula)-2]), true
+	case strings.HasPrefix(formula, "\\begin"):
+		return formula, true
+	}
+	return formula, false
+}
+
+func renderItems(m Markup, items []goxy.DocString) []string {
+	rendered := make([]string, len(items))
+	for i, item := range items {
+		rendered[i] = RenderDocString(m, item)
+	}
+	return rendered
+}
+
+// FunctionSignature returns the declaration of a function as plain text.
+func FunctionSignature(function *goxy.FunctionDoc) string {
+	params := make([]string, len(function.Params))
+	for i, param := range function.Params {
+		params[i] = strings.TrimSpace(fmt.Sprintf("%s %s", goxy.PlainText(param.Type), param.DeclName))
+		if param.DefaultValue != "" {
+			params[i] += " = " + param.DefaultValue
+		}
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s(%s)", goxy.PlainText(function.Type), function.Name, strings.Join(params, ", ")))
+}
+
+// DefineSignature returns the declaration of a define as plain text.
+func DefineSignature(define *goxy.DefineDoc) string {
+	decl := "#define " + define.Name
+	if len(define.Params) > 0 {
+		params := make([]string, len(define.Params))
+		for i, param := range define.Params {
+			params[i] = param.Defname
+		}
+		decl += "(" + strings.Join(params, ", ") + ")"
+	}
+	return strings.TrimSpace(decl + " " + define.Initializer)
+}
+
+// EnumSignature returns the declaration of an enum and its values as plain text.
+func EnumSignature(enum *goxy.EnumDoc) string {
+	buf := bytes.NewBufferString("enum " + EnumName(enum) + " {\n")
+	for _, value := range enum.Values {
+		_, _ = fmt.Fprintf(buf, "  %s\n", strings.TrimSpace(value.Name+" "+value.Initializer))
+	}
+	_, _ = fmt.Fprint(buf, "}")
+	return buf.String()
+}
+
+// EnumName returns the name of an enum, doxygen names anonymous enums "@<n>".
+func EnumName(enum *goxy.EnumDoc) string {
+	if strings.HasPrefix(enum.Name, "@") {
+		return "_Anonymous_"
+	}
+	return enum.Name
+}
+
+// AttributeSignature returns the declaration of an attribute as plain text.
+func AttributeSignature(attribute *goxy.ClassAttributeDoc) string {
+	return fmt.Sprintf("%s %s%s", goxy.PlainText(attribute.Type), attribute.Name, goxy.PlainText(attribute.ArgsString))
+}
+
+// TypedefSignature returns the declaration of a typedef as plain text.
+func TypedefSignature(typedef *goxy.TypedefDoc) string {
+	return fmt.Sprintf("typedef %s %s%s", goxy.PlainText(typedef.Type), typedef.Name, goxy.PlainText(typedef.ArgsString))
+}
+
+// FriendSignature returns the declaration of a friend as plain text.
+func FriendSignature(friend *goxy.FriendDoc) string {
+	return fmt.Sprintf("friend %s %s", goxy.PlainText(friend.Type), friend.Name)
+}
+
+// MemberDoc is a member of a section, reduced to what outputs without links or
+// layout show of it.
+type MemberDoc struct {
+	goxy.Descriptions
+
+	Id        string
+	Kind      string
+	Name      string
+	Signature string
+	// Values are the values of an enum.
+	Values []goxy.EnumValue
+}
+
+// Members lists the members of a section, by kind.
+func Members(section *goxy.SectionDoc) []MemberDoc {
+	members := make([]MemberDoc, 0)
+	add := func(descriptions goxy.Descriptions, id string, kind string, name string, signature string) *MemberDoc {
+		members = append(members, MemberDoc{
+			Descriptions: descriptions,
+			Id:           id,
+			Kind:         kind,
+			Name:         name,
+			Signature:    signature,
+		})
+		return &members[len(members)-1]
+	}
+
+	for _, enum := range section.Enums {
+		add(enum.Descriptions, enum.Id, "enum", EnumName(enum), EnumSignature(enum)).Values = enum.Values
+	}
+	for _, function := range section.Functions {
+		add(function.Descriptions, function.Id, "function", function.Name, FunctionSignature(function))
+	}
+	for _, attribute := range section.Attributes {
+		add(attribute.Descriptions, attribute.Id, "attribute", attribute.Name, AttributeSignature(attribute))
+	}
+	for _, define := range section.Defines {
+		add(define.Descriptions, define.Id, "define", define.Name, DefineSignature(define))
+	}
+	for _, typedef := range section.Typedefs {
+		add(typedef.Descriptions, typedef.Id, "typedef", typedef.Name, TypedefSignature(typedef))
+	}
+	for _, friend := range section.Friends {
+		add(friend.Descriptions, friend.Id, "friend", friend.Name, FriendSignature(friend))
+	}
+	return members
+}
+
+// FindMember returns the member of a compound with the id, the enum is returned
+// for the id of one of its values.
+func FindMember(compound *goxy.CompoundDoc, id string) (MemberDoc, bool) {
+	for _, section := range compound.Sections {
+		for _, member := range Members(section) {
+			if member.Id == id {
+				return member, true
+			}
+			for _, value := range member.Values {
+				if value.Id == id {
+					return member, true
+				}
+			}
+		}
+	}
+	return MemberDoc{}, false
+}
+
+// KindIndex is the index of the compounds of one kind in a doc set.
+type KindIndex struct {
+	Kind      goxy.Kind
+	Title     string
+	Compounds []*goxy.CompoundDoc
+}
+
+var indexKinds = []KindIndex{
+	{Kind: goxy.Class, Title: "Classes"},
+	{Kind: goxy.Struct, Title: "Structs"},
+	{Kind: goxy.Union, Title: "Unions"},
+	{Kind: goxy.Namespace, Title: "Namespaces"},
+	{Kind: goxy.File, Title: "Files"},
+	{Kind: goxy.Dir, Title: "Dirs"},
+	{Kind: goxy.Group, Title: "Groups"},
+	{Kind: goxy.Page, Title: "Pages"},
+}
+
+// KindIndexes groups the compounds of a doc set by kind, sorted by title. Kinds
+// without compounds are left out.
+func KindIndexes(compounds []*goxy.CompoundDoc) []KindIndex {
+	byKind := make(map[goxy.Kind][]*goxy.CompoundDoc)
+	for _, compound := range compounds {
+		byKind[compound.Kind] = append(byKind[compound.Kind], compound)
+	}
+
+	indexes := make([]KindIndex, 0)
+	for _, index := range indexKinds {
+		index.Compounds = byKind[index.Kind]
+		if len(index.Compounds) == 0 {
+			continue
+		}
+		sort.SliceStable(index.Compounds, func(i, j int) bool {
+			return strings.ToLower(index.Compounds[i].Title) < strings.ToLower(index.Compounds[j].Title)
+		})
+		indexes = append(indexes, index)
+	}
+	return indexes
+}
@@ -0,0 +1,41 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/goxy"
+	"reflect"
+	"testing"
+)
+
+func TestLookupParent(t *testing.T) {
+	refs := map[string]goxy.CompoundRef{
+		"classfoo":      {Kind: "class", Name: "Foo", RefId: "classfoo", ParentRef: goxy.NoParentRef},
+		"classfoo_1bar": {Kind: "function", Name: "bar", RefId: "classfoo_1bar", ParentRef: "classfoo"},
+		"classbaz_1qux": {Kind: "function", Name: "qux", RefId: "classbaz_1qux", ParentRef: "classbaz"},
+	}
+	tests := []struct {
+		name   string
+		ref    string
+		want   string
+		wantOk bool
+		// tracked are the refs the lookup depends on.
+		tracked []string
+	}{
+		{name: "member", ref: "classfoo_1bar", want: "classfoo", wantOk: true, tracked: []string{"classfoo"}},
+		{name: "compound", ref: "classfoo", tracked: []string{}},
+		{name: "unknown parent", ref: "classbaz_1qux", tracked: []string{"classbaz"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCore("scripting", map[string]*goxy.CompoundDoc{}, refs, nil)
+			c.StartTracking()
+			got, ok := c.LookupParent(refs[tt.ref])
+			if ok != tt.wantOk || got.RefId != tt.want {
+				t.Errorf("LookupParent() = %s, %v, want %s, %v", got.RefId, ok, tt.want, tt.wantOk)
+			}
+			if tracked := c.StopTracking(); !reflect.DeepEqual(tracked, tt.tracked) {
+				t.Errorf("tracked = %v, want %v", tracked, tt.tracked)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"ScriptExecServer/pkg/goxy"
+	"ScriptExecServer/pkg/sqlite"
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	gohtml "html"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Docset writes the doc sets as a Dash docset, which Dash and Zeal browse
+// offline. The bundle holds the pages of the standalone HTML site, an
+// Info.plist describing the docset and a search index in a SQLite database
+// listing every compound and member of the refs.
+type Docset struct {
+	*Site
+	// Bundle is the .docset folder.
+	Bundle string
+}
+
+// DocsetEntry is a row of the search index of a docset.
+type DocsetEntry struct {
+	Name string
+	// Type is one of the entry types of Dash, like Class or Method.
+	Type string
+	// Path is the page of the entry relative to the Documents folder, with the
+	// anchor of members.
+	Path string
+}
+
+// DocsetDocuments returns the folder the pages of a docset bundle are written
+// to.
+func DocsetDocuments(bundle string) string {
+	return filepath.Join(bundle, "Contents", "Resources", "Documents")
+}
+
+// NewDocset creates the docset formatter, opts.Dir is the bundle folder.
+func NewDocset(opts Options, sets []*DocSet) *Docset {
+	siteOpts := opts
+	siteOpts.Dir = DocsetDocuments(opts.Dir)
+
+	return &Docset{
+		Site:   NewSite(siteOpts, sets),
+		Bundle: opts.Dir,
+	}
+}
+
+// WriteData writes the search index and the assets of the site, the Info.plist
+// and the search index of the docset.
+func (d *Docset) WriteData() error {
+	err := d.Site.WriteData()
+	if err != nil {
+		return err
+	}
+
+	_, err = WriteFileIfChanged(filepath.Join(d.Bundle, "Contents", "Info.plist"), d.InfoPlist())
+	if err != nil {
+		return err
+	}
+
+	entries := make([]DocsetEntry, 0)
+	for _, set := range d.DocSets {
+		entries = append(entries, DocsetEntries(set)...)
+	}
+	path := filepath.Join(d.Bundle, "Contents", "Resources", "docSet.dsidx")
+	db := DocsetIndex(entries, func(entry DocsetEntry) {
+		if d.Diagnostics != nil {
+			d.Diagnostics.Reportf(diagnostics.Warning, path, "search index entry %s of %s is too large, skipping it", entry.Name, entry.Path)
+		}
+	})
+	err = db.WriteFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to write the search index of %s", d.Bundle)
+	}
+	return nil
+}
+
+var nonIdentifier = regexp.MustCompile(`[^a-z0-9]+`)
+
+// InfoPlist describes the docset, its identifier is the name of the bundle.
+func (d *Docset) InfoPlist() []byte {
+	id := strings.TrimSuffix(filepath.Base(d.Bundle), ".docset")
+	id = strings.Trim(nonIdentifier.ReplaceAllString(strings.ToLower(id), "-"), "-")
+
+	buf := bytes.NewBufferString("")
+	_, _ = fmt.Fprint(buf, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	entry := func(key string, value string) {
+		_, _ = fmt.Fprintf(buf, "\t<key>%s</key>\n\t%s\n", key, value)
+	}
+	entry("CFBundleIdentifier", "<string>"+gohtml.EscapeString(id)+"</string>")
+	entry("CFBundleName", "<string>"+gohtml.EscapeString(d.Title)+"</string>")
+	entry("DocSetPlatformFamily", "<string>"+gohtml.EscapeString(id)+"</string>")
+	entry("isDashDocset", "<true/>")
+	entry("dashIndexFilePath", "<string>index.html</string>")
+	// The search box of the pages works in the docset too.
+	entry("isJavaScriptEnabled", "<true/>")
+	_, _ = fmt.Fprint(buf, "</dict>\n</plist>\n")
+	return buf.Bytes()
+}
+
+// docsetTypes are the Dash entry types of the ref kinds, kinds not listed
+// aren't indexed.
+var docsetTypes = map[string]string{
+	string(goxy.Class):     "Class",
+	string(goxy.Struct):    "Struct",
+	string(goxy.Union):     "Union",
+	string(goxy.Namespace): "Namespace",
+	string(goxy.File):      "File",
+	string(goxy.Page):      "Guide",
+	string(goxy.Group):     "Category",
+	"function":             "Function",
+	"enum":                 "Enum",
+	"enumvalue":            "Constant",
+	"define":               "Define",
+	"typedef":              "Type",
+	"attribute":            "Attribute",
+}
+
+// DocsetEntries lists the compounds and members of the refs of a doc set that
+// have a page in the doc set, sorted by name.
+func DocsetEntries(set *DocSet) []DocsetEntry {
+	// Members are linked by the anchor of their entry on the page of their
+	// compound, enum values by the anchor of their enum.
+	anchors := make(map[string]string)
+	for _, compound := range set.Compounds {
+		for _, section := range compound.Sections {
+			for _, member := range Members(section) {
+				anchors[member.Id] = member.Id
+				for _, value := range member.Values {
+					anchors[value.Id] = member.Id
+				}
+			}
+		}
+	}
+
+	entries := make([]DocsetEntry, 0)
+	for _, ref := range set.Refs {
+		kind, ok := docsetTypes[ref.Kind]
+		if !ok || ref.Name == "N/A" || strings.HasPrefix(ref.Name, "@") {
+			continue
+		}
+
+		if compound, ok := set.Entities[ref.RefId]; ok {
+			name := compound.Name
+			if compound.Kind == goxy.Page || compound.Kind == goxy.Group {
+				name = compound.Title
+			}
+			entries = append(entries, DocsetEntry{
+				Name: name,
+				Type: kind,
+				Path: fmt.Sprintf("%s/%s/%s.html", set.Section, compound.Kind, compound.Id),
+			})
+			continue
+		}
+
+		compound, ok := set.Entities[ref.ParentRef]
+		anchor, found := anchors[ref.RefId]
+		if !ok || !found {
+			continue
+		}
+		name := ref.Name
+		switch compound.Kind {
+		case goxy.Class, goxy.Struct, goxy.Union:
+			if kind == "Function" {
+				kind = "Method"
+			}
+			name = compound.Name + "::" + ref.Name
+		}
+		entries = append(entries, DocsetEntry{
+			Name: name,
+			Type: kind,
+			Path: fmt.Sprintf("%s/%s/%s.html#%s", set.Section, compound.Kind, compound.Id, anchor),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		if entries[i].Type != entries[j].Type {
+			return entries[i].Type < entries[j].Type
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// DocsetIndex builds the search index database of a docset. The anchor index
+// is unique, so duplicate entries are dropped. Entries too large for the
+// database are passed to skipped and left out.
+func DocsetIndex(entries []DocsetEntry, skipped func(entry DocsetEntry)) *sqlite.Database {
+	rows := make([][]interface{}, 0, len(entries))
+	seen := make(map[DocsetEntry]bool, len(entries))
+	for _, entry := range entries {
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		row := []interface{}{nil, entry.Name, entry.Type, entry.Path}
+		if !sqlite.RowFits(row) || !sqlite.IndexEntryFits(row[1:]) {
+			skipped(entry)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	return &sqlite.Database{
+		Tables: []*sqlite.Table{
+			{
+				Name: "searchIndex",
+				SQL:  "CREATE TABLE searchIndex(id INTEGER PRIMARY KEY, name TEXT, type TEXT, path TEXT)",
+				Rows: rows,
+			},
+		},
+		Indexes: []*sqlite.Index{
+			{
+				Name:    "anchor",
+				Table:   "searchIndex",
+				SQL:     "CREATE UNIQUE INDEX anchor ON searchIndex (name, type, path)",
+				Columns: []int{1, 2, 3},
+			},
+		},
+	}
+}
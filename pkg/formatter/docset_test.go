@@ -0,0 +1,33 @@
+package formatter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDocsetIndexSkipsLargeEntries(t *testing.T) {
+	long := DocsetEntry{Name: strings.Repeat("Vector", 200), Type: "Function", Path: "scripting/class/classfoo.html#a1"}
+	entries := []DocsetEntry{
+		{Name: "Foo", Type: "Class", Path: "scripting/class/classfoo.html"},
+		long,
+		{Name: "Foo", Type: "Class", Path: "scripting/class/classfoo.html"},
+	}
+
+	skipped := make([]DocsetEntry, 0)
+	db := DocsetIndex(entries, func(entry DocsetEntry) {
+		skipped = append(skipped, entry)
+	})
+
+	if !reflect.DeepEqual(skipped, []DocsetEntry{long}) {
+		t.Errorf("skipped = %+v, want the long entry", skipped)
+	}
+	want := [][]interface{}{{nil, "Foo", "Class", "scripting/class/classfoo.html"}}
+	if rows := db.Tables[0].Rows; !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+	_, err := db.Bytes()
+	if err != nil {
+		t.Errorf("Bytes() = %v", err)
+	}
+}
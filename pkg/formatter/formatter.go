@@ -0,0 +1,152 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"ScriptExecServer/pkg/goxy"
+	"bufio"
+	"bytes"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// DocSet is a loaded doc set, as the formatters see it.
+type DocSet struct {
+	Name    string
+	Title   string
+	Section string
+	// Output is the folder the pages of the doc set are written to.
+	Output string
+	// RootDir is the title of the dir compound the files are listed from.
+	RootDir string
+
+	Compounds []*goxy.CompoundDoc
+	Entities  map[string]*goxy.CompoundDoc
+	Refs      map[string]goxy.CompoundRef
+}
+
+// Formatter writes the doc sets of an output in one format. The pages of the
+// compounds are written one at a time, so callers can skip unchanged ones, the
+// other files cover every doc set at once.
+type Formatter interface {
+	// Extension is the file extension of the pages, including the dot.
+	Extension() string
+	// Renderer returns the renderer of the pages of a doc set.
+	Renderer(set *DocSet) Renderer
+
+	// WriteCompound writes the page of a compound of a doc set to path.
+	WriteCompound(set *DocSet, compound *goxy.CompoundDoc, path string) error
+	// WriteIndex writes the pages listing the compounds of the doc sets.
+	WriteIndex() error
+	// WriteMenu writes the navigation between the doc sets.
+	WriteMenu() error
+	// WriteData writes the files the pages load at runtime.
+	WriteData() error
+}
+
+// Renderer renders the pages of the compounds of one doc set.
+type Renderer interface {
+	RenderCompound(compound *goxy.CompoundDoc) ([]byte, error)
+
+	// StartTracking makes the renderer record the id of every ref it
+	// resolves, until StopTracking returns them.
+	StartTracking()
+	StopTracking() []string
+}
+
+// Options configures the files of the formatters that aren't pages and where
+// the problems of the pages are reported.
+type Options struct {
+	// Dir is the root folder of the output, containing the doc set folders.
+	Dir string
+	// Title is the title of the whole output.
+	Title string
+	// DataFile and MenuFile are the files the Hugo theme reads the doc set
+	// data and the main menu from.
+	DataFile string
+	MenuFile string
+	// Diagnostics receives the problems found while rendering the pages.
+	Diagnostics *diagnostics.Collector
+}
+
+// Formats are the names accepted by New.
+var Formats = []string{"hugo", "markdown", "html", "man", "text", "docset"}
+
+// New creates the formatter registered under name, writing the doc sets.
+func New(name string, opts Options, sets []*DocSet) (Formatter, error) {
+	switch name {
+	case "hugo":
+		return NewHugoOutput(opts, sets), nil
+	case "markdown":
+		return NewMarkdownOutput(opts, sets), nil
+	case "html":
+		return NewSite(opts, sets), nil
+	case "man":
+		return NewManOutput(opts, sets), nil
+	case "text":
+		return NewTextOutput(opts, sets), nil
+	case "docset":
+		return NewDocset(opts, sets), nil
+	default:
+		return nil, errors.Errorf("unknown format %s", name)
+	}
+}
+
+// executeTemplate renders a template with the functions of the formatters.
+func executeTemplate(name string, text string, data interface{}) (string, error) {
+	t, err := template.New(name).
+		Funcs(funcMap).
+		Parse(text)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	buf := bytes.NewBufferString("")
+	err = t.ExecuteTemplate(buf, name, data)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return buf.String(), nil
+}
+
+// writeCompound renders a compound with r and writes it to path.
+func writeCompound(r Renderer, compound *goxy.CompoundDoc, path string) error {
+	content, err := r.RenderCompound(compound)
+	if err != nil {
+		return err
+	}
+	_, err = WriteFileIfChanged(path, content)
+	return err
+}
+
+// WriteFileIfChanged writes content to path, creating the folders leading up
+// to it. Files that already have the content are left alone, so they keep their
+// modification time, and false is returned.
+func WriteFileIfChanged(path string, content []byte) (bool, error) {
+	existing, err := ioutil.ReadFile(path)
+	if err == nil && bytes.Equal(existing, content) {
+		return false, nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	_, _ = w.Write(content)
+
+	err = w.Flush()
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, nil
+}
@@ -0,0 +1,274 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"ScriptExecServer/pkg/goxy"
+	"bytes"
+	"fmt"
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/pkg/errors"
+	gohtml "html"
+	"sort"
+	"strings"
+)
+
+// codeSpan links the text of a listing from Start to End, in bytes, to Href.
+type codeSpan struct {
+	Start int
+	End   int
+	Href  string
+}
+
+// codeListing is the text of a program listing with the links of its refs
+// kept aside, so the text can be highlighted as it is and linked afterwards.
+type codeListing struct {
+	Code string
+	// Numbers are the line numbers of the lines of Code, nil if the listing
+	// isn't numbered.
+	Numbers []int
+	Spans   []codeSpan
+}
+
+// listingFromDocString flattens the code lines of a listing into its text,
+// taking the line numbers from the lines and the spans from their refs.
+// Content without code lines is taken as a single block of code.
+func (h *Hugo) listingFromDocString(content goxy.DocString) codeListing {
+	listing := codeListing{}
+	buf := bytes.NewBufferString("")
+
+	numbered := false
+	lines := 0
+	for _, element := range content.Content {
+		line, ok := element.Value.(goxy.DocStringCodeLine)
+		if !ok {
+			h.addCode(buf, &listing, goxy.DocString{Content: []goxy.DocStringElement{element}})
+			continue
+		}
+
+		if lines > 0 {
+			_, _ = fmt.Fprint(buf, "\n")
+		}
+		lines++
+		if line.Number > 0 {
+			numbered = true
+		}
+		listing.Numbers = append(listing.Numbers, line.Number)
+		h.addCode(buf, &listing, line.Content)
+	}
+
+	listing.Code = buf.String()
+	if !numbered || lines != strings.Count(listing.Code, "\n")+1 {
+		listing.Numbers = nil
+	}
+	return listing
+}
+
+// addLink adds code linked to href, like the name of a member linked to its
+// anchor on the page.
+func addLink(buf *bytes.Buffer, listing *codeListing, code string, href string) {
+	start := buf.Len()
+	_, _ = fmt.Fprint(buf, code)
+	if buf.Len() > start {
+		listing.Spans = append(listing.Spans, codeSpan{Start: start, End: buf.Len(), Href: href})
+	}
+}
+
+func (h *Hugo) addCode(buf *bytes.Buffer, listing *codeListing, content goxy.DocString) {
+	for _, element := range content.Content {
+		switch e := element.Value.(type) {
+		case goxy.DocStringText:
+			_, _ = fmt.Fprint(buf, e.Content)
+		case goxy.DocStringLinebreak:
+			_, _ = fmt.Fprint(buf, "\n")
+		case goxy.DocStringCodeLine:
+			h.addCode(buf, listing, e.Content)
+			_, _ = fmt.Fprint(buf, "\n")
+		case goxy.DocStringCodeHighlight:
+			h.addCode(buf, listing, e.Content)
+		case goxy.DocStringRef:
+			start := buf.Len()
+			h.addCode(buf, listing, e.Content)
+			href, ok := h.LookupHref(e.RefId)
+			if !ok {
+				h.unknownRef(e.RefId)
+				continue
+			}
+			if buf.Len() > start {
+				listing.Spans = append(listing.Spans, codeSpan{Start: start, End: buf.Len(), Href: href})
+			}
+		default:
+			_, _ = fmt.Fprint(buf, goxy.PlainText(goxy.DocString{Content: []goxy.DocStringElement{element}}))
+		}
+	}
+}
+
+// normalizeSpans sorts spans by their start and cuts them to the code and to
+// each other, so they are ordered and don't overlap. Of overlapping spans the
+// one starting first keeps the shared text, the outer one of nested spans.
+func normalizeSpans(spans []codeSpan, length int) []codeSpan {
+	sorted := make([]codeSpan, len(spans))
+	copy(sorted, spans)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Start != sorted[j].Start {
+			return sorted[i].Start < sorted[j].Start
+		}
+		return sorted[i].End > sorted[j].End
+	})
+
+	normalized := make([]codeSpan, 0, len(sorted))
+	pos := 0
+	for _, span := range sorted {
+		if span.Start < pos {
+			span.Start = pos
+		}
+		if span.End > length {
+			span.End = length
+		}
+		if span.End <= span.Start {
+			continue
+		}
+		normalized = append(normalized, span)
+		pos = span.End
+	}
+	return normalized
+}
+
+// highlightTokens lexes code in a language, falling back to C++ for unknown
+// languages. The spans of a listing point into its text, so if the lexer
+// doesn't reproduce the code exactly it is returned as a single text token,
+// with the reason.
+func highlightTokens(language string, code string) ([]chroma.Token, error) {
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		lexer = lexers.Get("C++")
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	plain := []chroma.Token{{Type: chroma.Text, Value: code}}
+	iterator, err := lexer.Tokenise(nil, code)
+	if err != nil {
+		return plain, errors.Wrapf(err, "unable to lex %s code", lexer.Config().Name)
+	}
+	tokens := iterator.Tokens()
+
+	text := bytes.NewBufferString("")
+	for _, token := range tokens {
+		_, _ = fmt.Fprint(text, token.Value)
+	}
+	// Lexers end their input with a newline if it doesn't have one.
+	if text.String() == code+"\n" && len(tokens) > 0 {
+		last := &tokens[len(tokens)-1]
+		last.Value = strings.TrimSuffix(last.Value, "\n")
+	} else if text.String() != code {
+		return plain, errors.Errorf("the %s lexer changed the code", lexer.Config().Name)
+	}
+	return tokens, nil
+}
+
+// tokenClass returns the CSS class of the chroma styles for a token type,
+// like the HTML formatter of chroma does.
+func tokenClass(t chroma.TokenType) string {
+	for t != 0 {
+		if class, ok := chroma.StandardTypes[t]; ok {
+			return class
+		}
+		t = t.Parent()
+	}
+	return chroma.StandardTypes[t]
+}
+
+// renderListing highlights a listing like the HTML formatter of chroma, with
+// the links of the spans put around the parts of the tokens they cover.
+func (h *Hugo) renderListing(language string, listing codeListing) string {
+	buf := bytes.NewBufferString("")
+
+	digits := 0
+	for _, n := range listing.Numbers {
+		if d := len(fmt.Sprint(n)); d > digits {
+			digits = d
+		}
+	}
+
+	line := 0
+	startLine := func() {
+		_, _ = fmt.Fprint(buf, `<span class="line">`)
+		if line < len(listing.Numbers) {
+			_, _ = fmt.Fprintf(buf, `<span class="ln">%*d</span>`, digits, listing.Numbers[line])
+		}
+		_, _ = fmt.Fprint(buf, `<span class="cl">`)
+	}
+
+	listing.Spans = normalizeSpans(listing.Spans, len(listing.Code))
+
+	_, _ = fmt.Fprint(buf, `<pre tabindex="0" class="chroma"><code>`)
+
+	pos := 0
+	span := 0
+	open := false
+	linked := false
+	tokens, err := highlightTokens(language, listing.Code)
+	if err != nil {
+		h.reportf(diagnostics.Warning, "code is shown without highlighting: %v", err)
+	}
+	for _, token := range tokens {
+		class := tokenClass(token.Type)
+		value := token.Value
+		for value != "" {
+			if !open {
+				startLine()
+				open = true
+				if linked {
+					_, _ = fmt.Fprintf(buf, `<a href="%s">`, listing.Spans[span].Href)
+				}
+			}
+			if !linked && span < len(listing.Spans) && listing.Spans[span].Start == pos {
+				_, _ = fmt.Fprintf(buf, `<a href="%s">`, listing.Spans[span].Href)
+				linked = true
+			}
+
+			// Tokens are split where a line ends or a link starts or ends.
+			end := len(value)
+			if i := strings.Index(value, "\n"); i >= 0 {
+				end = i + 1
+			}
+			if linked && listing.Spans[span].End-pos < end {
+				end = listing.Spans[span].End - pos
+			} else if !linked && span < len(listing.Spans) && listing.Spans[span].Start-pos < end {
+				end = listing.Spans[span].Start - pos
+			}
+
+			piece := value[:end]
+			text := h.Text(gohtml.EscapeString(piece))
+			if class != "" {
+				text = fmt.Sprintf(`<span class="%s">%s</span>`, class, text)
+			}
+			_, _ = fmt.Fprint(buf, text)
+			pos += end
+			value = value[end:]
+
+			if linked && listing.Spans[span].End == pos {
+				_, _ = fmt.Fprint(buf, "</a>")
+				linked = false
+				span++
+			}
+			if strings.HasSuffix(piece, "\n") {
+				if linked {
+					_, _ = fmt.Fprint(buf, "</a>")
+				}
+				_, _ = fmt.Fprint(buf, "</span></span>")
+				open = false
+				line++
+			}
+		}
+	}
+
+	if linked {
+		_, _ = fmt.Fprint(buf, "</a>")
+	}
+	if open {
+		_, _ = fmt.Fprint(buf, "</span></span>")
+	}
+	_, _ = fmt.Fprint(buf, "</code></pre>")
+	return buf.String()
+}
@@ -0,0 +1,258 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/diagnostics"
+	"ScriptExecServer/pkg/goxy"
+	gohtml "html"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func newTestHugo() *Hugo {
+	refs := map[string]goxy.CompoundRef{
+		"classfoo":      {Kind: "class", Name: "Foo", RefId: "classfoo"},
+		"classfoo_1bar": {Kind: "function", Name: "bar", RefId: "classfoo_1bar", ParentRef: "classfoo"},
+	}
+	return NewHugoFormatter("scripting", map[string]*goxy.CompoundDoc{}, refs)
+}
+
+func codeText(s string) goxy.DocStringElement {
+	return goxy.DocStringElement{Type: goxy.Text, Value: goxy.DocStringText{Content: s}}
+}
+
+func codeRef(refId string, content ...goxy.DocStringElement) goxy.DocStringElement {
+	return goxy.DocStringElement{Type: goxy.Ref, Value: goxy.DocStringRef{RefId: refId, KindRef: "member", Content: goxy.DocString{Content: content}}}
+}
+
+func codeLine(number int, content ...goxy.DocStringElement) goxy.DocStringElement {
+	return goxy.DocStringElement{Type: goxy.CodeLine, Value: goxy.DocStringCodeLine{Number: number, Content: goxy.DocString{Content: content}}}
+}
+
+const (
+	fooHref = "/scripting/class/classfoo/__index_when_offline__"
+	barHref = fooHref + "#classfoo_1bar"
+)
+
+func TestListingFromDocString(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []goxy.DocStringElement
+		want    codeListing
+	}{
+		{
+			name: "multi-line",
+			content: []goxy.DocStringElement{
+				codeLine(3, codeRef("classfoo", codeText("Foo")), codeText(" x;")),
+				codeLine(4, codeText("x."), codeRef("classfoo_1bar", codeText("bar")), codeText("();")),
+			},
+			want: codeListing{
+				Code:    "Foo x;\nx.bar();",
+				Numbers: []int{3, 4},
+				Spans:   []codeSpan{{0, 3, fooHref}, {9, 12, barHref}},
+			},
+		},
+		{
+			name: "adjacent",
+			content: []goxy.DocStringElement{
+				codeRef("classfoo", codeText("Foo")),
+				codeRef("classfoo_1bar", codeText("bar")),
+			},
+			want: codeListing{
+				Code:  "Foobar",
+				Spans: []codeSpan{{0, 3, fooHref}, {3, 6, barHref}},
+			},
+		},
+		{
+			name: "nested",
+			content: []goxy.DocStringElement{
+				codeRef("classfoo", codeText("Foo::"), codeRef("classfoo_1bar", codeText("bar"))),
+			},
+			want: codeListing{
+				Code:  "Foo::bar",
+				Spans: []codeSpan{{5, 8, barHref}, {0, 8, fooHref}},
+			},
+		},
+		{
+			name: "unknown ref",
+			content: []goxy.DocStringElement{
+				codeRef("classbaz", codeText("Baz")),
+			},
+			want: codeListing{
+				Code: "Baz",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestHugo().listingFromDocString(goxy.DocString{Content: tt.content})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listingFromDocString() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderListing(t *testing.T) {
+	const (
+		pre  = `<pre tabindex="0" class="chroma"><code>`
+		post = `</code></pre>`
+	)
+
+	tests := []struct {
+		name    string
+		listing codeListing
+		want    string
+	}{
+		{
+			name:    "adjacent",
+			listing: codeListing{Code: "ab", Spans: []codeSpan{{0, 1, "/a"}, {1, 2, "/b"}}},
+			want:    pre + `<span class="line"><span class="cl"><a href="/a">a</a><a href="/b">b</a></span></span>` + post,
+		},
+		{
+			name:    "overlapping",
+			listing: codeListing{Code: "abcd", Spans: []codeSpan{{0, 3, "/a"}, {2, 4, "/b"}}},
+			want:    pre + `<span class="line"><span class="cl"><a href="/a">abc</a><a href="/b">d</a></span></span>` + post,
+		},
+		{
+			name:    "nested",
+			listing: codeListing{Code: "abcd", Spans: []codeSpan{{1, 2, "/in"}, {0, 4, "/out"}}},
+			want:    pre + `<span class="line"><span class="cl"><a href="/out">abcd</a></span></span>` + post,
+		},
+		{
+			name:    "unsorted",
+			listing: codeListing{Code: "abcd", Spans: []codeSpan{{2, 4, "/b"}, {0, 1, "/a"}}},
+			want:    pre + `<span class="line"><span class="cl"><a href="/a">a</a>b<a href="/b">cd</a></span></span>` + post,
+		},
+		{
+			name:    "out of range",
+			listing: codeListing{Code: "abcd", Spans: []codeSpan{{2, 10, "/a"}, {5, 1, "/b"}, {6, 8, "/c"}}},
+			want:    pre + `<span class="line"><span class="cl">ab<a href="/a">cd</a></span></span>` + post,
+		},
+		{
+			name:    "multi-line",
+			listing: codeListing{Code: "ab\ncd", Numbers: []int{1, 2}, Spans: []codeSpan{{1, 4, "/x"}}},
+			want: pre +
+				`<span class="line"><span class="ln">1</span><span class="cl">a<a href="/x">b` + "\n" + `</a></span></span>` +
+				`<span class="line"><span class="ln">2</span><span class="cl"><a href="/x">c</a>d</span></span>` + post,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestHugo().renderListing("text", tt.listing)
+			if got != tt.want {
+				t.Errorf("renderListing() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// tag matches the tags of rendered HTML, to get the highlighted code back, and
+// link the start tags of its links.
+var (
+	tag  = regexp.MustCompile(`<[^>]*>`)
+	link = regexp.MustCompile(`<a href="([^"]*)">`)
+)
+
+func TestRenderDeclarations(t *testing.T) {
+	vector := goxy.DocString{Content: []goxy.DocStringElement{codeRef("classfoo", codeText("Foo")), codeText("<a>")}}
+
+	tests := []struct {
+		name string
+		html string
+		// code is the text of the declaration, hrefs are the targets of its
+		// links in order.
+		code  string
+		hrefs []string
+	}{
+		{
+			name: "function",
+			html: newTestHugo().RenderBriefFunctionDecl(goxy.FunctionDoc{
+				Id:   "classfoo_1bar",
+				Name: "bar",
+				Params: []goxy.FunctionParam{
+					{Type: vector, DeclName: "v", DefaultValue: "{}"},
+					{Type: goxy.DocString{Content: []goxy.DocStringElement{codeText("int")}}, DeclName: "n"},
+				},
+			}),
+			code:  "bar(Foo<a> v = {}, int n)",
+			hrefs: []string{"#classfoo_1bar", fooHref},
+		},
+		{
+			name:  "define",
+			html:  newTestHugo().RenderBriefDefineDecl(goxy.DefineDoc{Id: "foo_8h_1max", Name: "MAX", Initializer: "((a) > (b) ? (a) : (b))", Params: []goxy.DefineParam{{Defname: "a"}, {Defname: "b"}}}),
+			code:  "MAX(a, b) ((a) > (b) ? (a) : (b))",
+			hrefs: []string{"#foo_8h_1max"},
+		},
+		{
+			name:  "anonymous enum",
+			html:  newTestHugo().RenderBriefEnumDecl(goxy.EnumDoc{Id: "foo_8h_1e", Name: "@0", Values: []goxy.EnumValue{{Name: "A"}}}),
+			code:  "_Anonymous_ {  A \n}",
+			hrefs: []string{"#foo_8h_1e"},
+		},
+		{
+			name:  "attribute",
+			html:  newTestHugo().RenderAttributeDecl(goxy.ClassAttributeDoc{Id: "classfoo_1v", Name: "v", Type: vector, ArgsString: goxy.DocString{Content: []goxy.DocStringElement{codeText("[2]")}}}),
+			code:  "Foo<a> v [2]",
+			hrefs: []string{fooHref},
+		},
+		{
+			name:  "typedef",
+			html:  newTestHugo().RenderTypedefDecl(goxy.TypedefDoc{Id: "foo_8h_1v", Name: "Vec", Type: vector}),
+			code:  "typedef Foo<a> Vec ",
+			hrefs: []string{fooHref},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := gohtml.UnescapeString(tag.ReplaceAllString(tt.html, "")); code != tt.code {
+				t.Errorf("code = %q, want %q", code, tt.code)
+			}
+			hrefs := make([]string, 0)
+			for _, m := range link.FindAllStringSubmatch(tt.html, -1) {
+				hrefs = append(hrefs, m[1])
+			}
+			if !reflect.DeepEqual(hrefs, tt.hrefs) {
+				t.Errorf("links = %v, want %v in %s", hrefs, tt.hrefs, tt.html)
+			}
+		})
+	}
+}
+
+func TestRenderDiagnostics(t *testing.T) {
+	h := newTestHugo()
+	h.Diagnostics = diagnostics.NewCollector()
+
+	h.startCompound(&goxy.CompoundDoc{Id: "classfoo"})
+	unknown := goxy.DocString{Content: []goxy.DocStringElement{codeRef("classbaz", codeText("Baz"))}}
+	code := h.RenderAttributeDecl(goxy.ClassAttributeDoc{Id: "classfoo_1b", Name: "b", Type: unknown})
+	if strings.Contains(code, "<a href") {
+		t.Errorf("unknown ref was linked: %s", code)
+	}
+	// Unknown refs are reported once per compound.
+	h.RenderRef("classbaz", "Baz")
+	h.RenderRef("classqux", "Qux")
+	// The lexer turns the line ends into newlines, so the code can't be
+	// highlighted.
+	code = h.RenderHighlight("C++", "int a;\r\nint b;")
+	if !strings.Contains(code, "int b;") {
+		t.Errorf("code without highlighting is missing: %s", code)
+	}
+
+	h.startCompound(&goxy.CompoundDoc{Id: "structbar"})
+	h.RenderRef("classbaz", "Baz")
+
+	want := []diagnostics.Diagnostic{
+		{File: "classfoo", Severity: diagnostics.Warning, Message: "unknown ref: classbaz"},
+		{File: "classfoo", Severity: diagnostics.Warning, Message: "unknown ref: classqux"},
+		{File: "classfoo", Severity: diagnostics.Warning, Message: "code is shown without highlighting: the C++ lexer changed the code"},
+		{File: "structbar", Severity: diagnostics.Warning, Message: "unknown ref: classbaz"},
+	}
+	if got := h.Diagnostics.Diagnostics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %+v, want %+v", got, want)
+	}
+}
@@ -3,27 +3,18 @@ package formatter
 import (
 	"ScriptExecServer/pkg/formatter/templates"
 	"ScriptExecServer/pkg/goxy"
-	"bufio"
 	"bytes"
 	"fmt"
-	"github.com/alecthomas/chroma"
-	"github.com/alecthomas/chroma/formatters/html"
-	"github.com/alecthomas/chroma/lexers"
-	"github.com/alecthomas/chroma/styles"
-	"github.com/pkg/errors"
+	gohtml "html"
 	"log"
-	"os"
-	"path/filepath"
-	"regexp"
 	"strings"
 	"text/template"
 )
 
+// Hugo renders the pages of a doc set as HTML with the shortcodes of the Hugo
+// site.
 type Hugo struct {
-	Section string
-
-	CompoundIdMap map[string]*goxy.CompoundDoc
-	CompoundRefs  map[string]goxy.CompoundRef
+	*Core
 }
 
 var funcMap = template.FuncMap{
@@ -32,9 +23,9 @@ var funcMap = template.FuncMap{
 
 func NewHugoFormatter(section string, idMap map[string]*goxy.CompoundDoc, refs map[string]goxy.CompoundRef) *Hugo {
 	return &Hugo{
-		Section:       section,
-		CompoundIdMap: idMap,
-		CompoundRefs:  refs,
+		Core: NewCore(section, idMap, refs, func(kind string, refId string) string {
+			return fmt.Sprintf("/%s/%s/%s/__index_when_offline__", section, kind, strings.ToLower(refId))
+		}),
 	}
 }
 
@@ -51,6 +42,10 @@ type HighlightOpts struct {
 }
 
 func (h *Hugo) MermaidEscape(label string) string {
+	return mermaidEscape(label)
+}
+
+func mermaidEscape(label string) string {
 	// Workaround for: https://github.com/mermaid-js/mermaid/issues/1506
 	return strings.ReplaceAll(
 		strings.ReplaceAll(
@@ -60,139 +55,112 @@ func (h *Hugo) MermaidEscape(label string) string {
 	// return strings.ReplaceAll(label, ":", "#58;")
 }
 
-func (h *Hugo) renderHighlight(language string, content string, formatter *html.Formatter) string {
-	buf := bytes.NewBufferString("")
-	style := styles.Get("swapoff")
-	if style == nil {
-		style = styles.Fallback
-	}
-
-	lexer := lexers.Get(language)
-	if lexer == nil {
-		lexer = lexers.Get("C++")
-	}
-	lexer = chroma.Coalesce(lexer)
-	iterator, _ := lexer.Tokenise(nil, content)
-	_ = formatter.Format(buf, style, iterator)
-
-	charactersToReplace := map[string]string {
-		"%": "&#37;",
-	}
-
-	output := buf.String()
-	for c, v := range charactersToReplace {
-		output = strings.ReplaceAll(output, c, v)
-	}
-
-	return ReplaceHrefInHighlight(buf.String())
+// RenderHighlight highlights code without links.
+func (h *Hugo) RenderHighlight(language string, code string) string {
+	return h.renderListing(language, codeListing{Code: code})
 }
 
-func (h *Hugo) RenderHighlight(language string, content string) string {
-	formatter := html.New(
-		html.WithClasses(true),
-	)
-
-	return h.renderHighlight(language, content, formatter)
+// renderDeclaration highlights the C++ declaration of a member that build
+// writes to buf, adding the links of its names and refs to listing.
+func (h *Hugo) renderDeclaration(build func(buf *bytes.Buffer, listing *codeListing)) string {
+	buf := bytes.NewBufferString("")
+	listing := codeListing{}
+	build(buf, &listing)
+	listing.Code = buf.String()
+	return h.renderListing("C++", listing)
 }
 
-func (h *Hugo) RenderHighlightWithLineNos(language string, content string) string {
-	formatter := html.New(
-		html.WithClasses(true),
-		html.WithLineNumbers(true),
-	)
-
-	return h.renderHighlight(language, content, formatter)
+func (h *Hugo) RenderBriefFunctionDecl(function goxy.FunctionDoc) string {
+	return h.renderDeclaration(func(buf *bytes.Buffer, listing *codeListing) {
+		addLink(buf, listing, function.Name, "#"+function.Id)
+		_, _ = fmt.Fprint(buf, "(")
+		for idx, param := range function.Params {
+			if idx > 0 {
+				_, _ = fmt.Fprint(buf, ", ")
+			}
+			h.addCode(buf, listing, param.Type)
+			_, _ = fmt.Fprintf(buf, " %s", param.DeclName)
+			if param.DefaultValue != "" {
+				_, _ = fmt.Fprintf(buf, " = %s", param.DefaultValue)
+			}
+		}
+		_, _ = fmt.Fprint(buf, ")")
+	})
 }
 
-func minInt(x, y int) int {
-	if x > y {
-		return y
-	} else {
-		return x
-	}
-}
+func (h *Hugo) RenderBriefDefineDecl(define goxy.DefineDoc) string {
+	return h.renderDeclaration(func(buf *bytes.Buffer, listing *codeListing) {
+		addLink(buf, listing, define.Name, "#"+define.Id)
 
-func ReplaceHrefInHighlight(s string) string {
-	// The simplest case
-	re1, _ := regexp.Compile("<span [^>]+>((?:-|\\+|!|\"|:|\\(|\\)|\\*|&amp;|&gt;|&lt;|&quot;)*)&lt;</span>\\s*<span [^>]+>a</span>\\s*<span [^>]+>href</span>\\s*<span [^>]+>=</span>\\s*<span [^>]+>(?:&#34;|\")([^<]+)(?:&#34;|\")</span>\\s*<span [^>]+>&gt;((?:::|~)*)</span>\\s*((?:<span [^>]+>[^<]+</span>\\s*)*?)\\s*(<span [^>]+>[^<]+</span>)\\s*<span [^>]+>((?:&lt;|&gt;|=|\\*|!|-|\\||&amp;|\\^|\\+|~|/|:)*)&lt;/</span>\\s*<span [^>]+>a</span>\\s*<span [^>]+>&gt;((?:\\*|&amp;|&lt;|&gt;|&quot;|-|\\(|\\)|:|\\+|=|!|/|\\?|~|\")*)</span>")
-	// Handle :: namespacing
-	// re2, _ := regexp.Compile("<span [^>]+>((?:-|\\(|\\)|\\*|&amp;|&gt;|&lt;)*)&lt;</span>\\s*<span [^>]+>a</span>\\s*<span [^>]+>href</span>\\s*<span [^>]+>=</span>\\s*<span [^>]+>(?:&#34;|\")([^<]+)(?:&#34;|\")</span>\\s*<span [^>]+>&gt;(~?)</span>\\s*(<span [^>]+>[^<]+</span>\\s*<span [^>]+>::</span>)?\\s*(<span [^>]+>[^<]+</span>)\\s*<span [^>]+>((?:&gt;|=)*)&lt;/</span>\\s*<span [^>]+>a</span>\\s*<span [^>]+>&gt;((?:\\*|&amp;|&lt;|&gt;|-|\\(|\\)|:)*)</span>")
-	// Handle Generics
-	// re3, _ := regexp.Compile("<span [^>]+>((?:-|\\(|\\)|\\*|&amp;|&gt;|&lt;)*)&lt;</span>\\s*<span [^>]+>a</span>\\s*<span [^>]+>href</span>\\s*<span [^>]+>=</span>\\s*<span [^>]+>(?:&#34;|\")([^<]+)(?:&#34;|\")</span>\\s*<span [^>]+>&gt;(~?)</span>\\s*(<span [^>]+>[^<]+</span>\\s*<span [^>]+>&lt;</span>)?\\s*(<span [^>]+>[^<]+</span>)\\s*<span [^>]+>((?:&gt;|=)*)&lt;/</span>\\s*<span [^>]+>a</span>\\s*<span [^>]+>&gt;((?:\\*|&amp;|&lt;|&gt;|-|\\(|\\)|:)*)</span>")
-
-	ret := re1.ReplaceAllString(s, "<span class=\"o\">$1</span><a href=$2><span class=\"n\">$3</span>$4$5$6</a><span class=\"o\">$7</span>")
-	tmp := ret
-	for {
-		tmp = re1.ReplaceAllString(ret, "<span class=\"o\">$1</span><a href=$2><span class=\"n\">$3</span>$4$5$6</a><span class=\"o\">$7</span>")
-		if tmp == ret {
-			break
-		} else {
-			ret = tmp
+		paramStrings := make([]string, len(define.Params))
+		for idx, param := range define.Params {
+			paramStrings[idx] = param.Defname
 		}
-	}
-
-	re2, _ := regexp.Compile("&lt;a href=(?:\"|&#34;)([^\"]+)(?:\"|&#34;)&gt;([^<]+)&lt;/a&gt;")
-
-	ret = re2.ReplaceAllString(ret, "<a href=\"$1\">$2</a>")
-
-	failIdx := strings.Index(ret, "<span class=\"o\">&lt;</span><span class=\"n\">a</span> <span class=\"n\">href")
-	if failIdx >= 0 {
-		panic(fmt.Sprintf("unable to transform link\n\n\nreturn: %s\n\n\ncontext: %s", ret, ret[failIdx:minInt(len(ret), failIdx + 2000)]))
-	}
-	return ret
-	/*re3.ReplaceAllString(
-		re2.ReplaceAllString(
-			re1.ReplaceAllString(s, "<span class=\"o\">$1</span><a href=$2><span class=\"n\">$3</span>$4$5$6</a><span class=\"o\">$7</span>"),
-			"<span class=\"o\">$1</span><a href=$2><span class=\"n\">$3</span>$4$5$6</a><span class=\"o\">$7</span>",
-		),
-		"<span class=\"o\">$1</span><a href=$2><span class=\"n\">$3</span>$4$5$6</a><span class=\"o\">&gt;$7</span>",
-	)*/
+		_, _ = fmt.Fprintf(buf, "(%s) %s", strings.Join(paramStrings, ", "), define.Initializer)
+	})
 }
 
-func (h *Hugo) RenderBriefFunctionDecl(function goxy.FunctionDoc) string {
-	buf := bytes.NewBufferString("")
-
-	_, _ = fmt.Fprintf(buf, "<a href=\"#%s\">%s</a>(", function.Id, function.Name)
-
-	paramStrings := make([]string, len(function.Params))
-	for idx, param := range function.Params {
-		paramStrings[idx] = fmt.Sprintf("%s %s", h.RenderDocstring(param.Type), param.DeclName)
+func (h *Hugo) RenderBriefEnumDecl(enum goxy.EnumDoc) string {
+	name := enum.Name
+	if strings.HasPrefix(name, "@") {
+		name = "_Anonymous_"
 	}
-
-	_, _ = fmt.Fprint(buf, strings.Join(paramStrings, ", "))
-	_, _ = fmt.Fprint(buf, ")")
-
-	return buf.String()
+	return h.renderDeclaration(func(buf *bytes.Buffer, listing *codeListing) {
+		addLink(buf, listing, name, "#"+enum.Id)
+		_, _ = fmt.Fprintf(buf, " %s", h.RenderEnumBody(enum.Values))
+	})
 }
 
-func (h *Hugo) RenderBriefDefineDecl(define goxy.DefineDoc) string {
-	buf := bytes.NewBufferString("")
+func (h *Hugo) RenderBriefAttributeDecl(attribute goxy.ClassAttributeDoc) string {
+	return h.renderDeclaration(func(buf *bytes.Buffer, listing *codeListing) {
+		addLink(buf, listing, attribute.Name, "#"+attribute.Id)
+		_, _ = fmt.Fprint(buf, " ")
+		h.addCode(buf, listing, attribute.ArgsString)
+	})
+}
 
-	_, _ = fmt.Fprintf(buf, "<a href=\"#%s\">%s</a>(", define.Id, define.Name)
+func (h *Hugo) RenderAttributeDecl(attribute goxy.ClassAttributeDoc) string {
+	return h.renderDeclaration(func(buf *bytes.Buffer, listing *codeListing) {
+		h.addCode(buf, listing, attribute.Type)
+		_, _ = fmt.Fprintf(buf, " %s ", attribute.Name)
+		h.addCode(buf, listing, attribute.ArgsString)
+	})
+}
 
-	paramStrings := make([]string, len(define.Params))
-	for idx, param := range define.Params {
-		paramStrings[idx] = fmt.Sprintf("%s", param.Defname)
-	}
+func (h *Hugo) RenderBriefTypedefDecl(typedef goxy.TypedefDoc) string {
+	return h.renderDeclaration(func(buf *bytes.Buffer, listing *codeListing) {
+		_, _ = fmt.Fprintf(buf, "%s ", typedef.Name)
+		h.addCode(buf, listing, typedef.ArgsString)
+	})
+}
 
-	_, _ = fmt.Fprint(buf, strings.Join(paramStrings, ", "))
-	_, _ = fmt.Fprintf(buf, ") %s", define.Initializer)
+func (h *Hugo) RenderTypedefDecl(typedef goxy.TypedefDoc) string {
+	return h.renderDeclaration(func(buf *bytes.Buffer, listing *codeListing) {
+		_, _ = fmt.Fprint(buf, "typedef ")
+		h.addCode(buf, listing, typedef.Type)
+		_, _ = fmt.Fprintf(buf, " %s ", typedef.Name)
+		h.addCode(buf, listing, typedef.ArgsString)
+	})
+}
 
-	return buf.String()
+func (h *Hugo) RenderBriefFriendDecl(friend goxy.FriendDoc) string {
+	return h.renderDeclaration(func(buf *bytes.Buffer, listing *codeListing) {
+		addLink(buf, listing, friend.Name, "#"+friend.Id)
+	})
 }
 
 func (h *Hugo) RenderReimplementedFrom(f goxy.FunctionDoc) string {
-	ref, ok := h.CompoundRefs[f.Reimplements.RefId]
+	ref, ok := h.LookupRef(f.Reimplements.RefId)
 	if !ok {
 		return "&lt;UNKNOWN TYPE&gt;"
 	}
 
-	pRef, ok := h.CompoundRefs[ref.ParentRef]
+	pRef, ok := h.LookupParent(ref)
 	if !ok {
 		return "&lt;UNKNOWN PARENT TYPE&gt;"
 	}
 
-	return fmt.Sprintf("<a href=\"/%s/%s/%s/__index_when_offline__#%s\">%s</a>", h.Section, pRef.Kind, strings.ToLower(pRef.RefId), ref.RefId, pRef.Name)
+	return fmt.Sprintf("<a href=\"%s#%s\">%s</a>", h.PageHref(pRef.Kind, pRef.RefId), ref.RefId, pRef.Name)
 }
 
 func (h *Hugo) RenderReimplementedBy(f goxy.FunctionDoc) string {
@@ -203,169 +171,243 @@ func (h *Hugo) RenderReimplementedBy(f goxy.FunctionDoc) string {
 			_, _ = fmt.Fprint(buf, ", ")
 		}
 
-		ref, ok := h.CompoundRefs[reimplements.RefId]
+		ref, ok := h.LookupRef(reimplements.RefId)
 		if !ok {
 			_, _ = fmt.Fprint(buf, "&lt;UNKNOWN TYPE&gt;")
 			continue
 
 		}
 
-		pRef, ok := h.CompoundRefs[ref.ParentRef]
+		pRef, ok := h.LookupParent(ref)
 		if !ok {
 			_, _ = fmt.Fprint(buf, "&lt;UNKNOWN PARENT TYPE&gt;")
 			continue
 		}
 
-		_, _ = fmt.Fprintf(buf, "<a href=\"/%s/%s/%s/__index_when_offline__#%s\">%s</a>", h.Section, pRef.Kind, strings.ToLower(pRef.RefId), ref.RefId, pRef.Name)
+		_, _ = fmt.Fprintf(buf, "<a href=\"%s#%s\">%s</a>", h.PageHref(pRef.Kind, pRef.RefId), ref.RefId, pRef.Name)
 	}
 
 	return buf.String()
 }
 
 func (h *Hugo) HrefForRefId(refId string) string {
-	if c, ok := h.CompoundRefs[refId]; !ok {
+	href, ok := h.LookupHref(refId)
+	if !ok {
 		return "#unknown-refid"
-	} else {
-		if p, ok := h.CompoundRefs[c.ParentRef]; ok {
-			return fmt.Sprintf("/%s/%s/%s/__index_when_offline__#%s", h.Section, p.Kind, strings.ToLower(p.RefId), c.RefId)
-		} else {
-			return fmt.Sprintf("/%s/%s/%s/__index_when_offline__", h.Section, c.Kind, strings.ToLower(c.RefId))
-		}
 	}
+	return href
 }
 
 func (h *Hugo) RenderRef(refId, content string) string {
 	href := h.HrefForRefId(refId)
 	if href == "#unknown-refid" {
-		log.Printf("error: %+v", fmt.Errorf("unknown ref: %s", refId))
+		h.unknownRef(refId)
 		return content
 	}
 	return fmt.Sprintf("<a href=\"%s\">%s</a>", href, content)
 }
 
 func (h *Hugo) RenderDocstring(docstring goxy.DocString) string {
-	buf := bytes.NewBufferString("")
+	return RenderDocString(h, docstring)
+}
 
-	for _, element := range docstring.Content {
-		switch e := element.Value.(type) {
-		case goxy.DocStringText:
-			_, _ = fmt.Fprint(buf, strings.ReplaceAll(e.Content, "{{", "££@$$"))
-		case goxy.DocStringParagraph:
-			_, _ = fmt.Fprintf(buf, "<p>%s</p>", h.RenderDocstring(e.Content))
-		case goxy.DocStringEmphasis:
-			_, _ = fmt.Fprintf(buf, "<em>%s</em>", h.RenderDocstring(e.Content))
-		case goxy.DocStringBold:
-			_, _ = fmt.Fprintf(buf, "<b>%s</b>", h.RenderDocstring(e.Content))
-		case goxy.DocStringVerbatim:
-			_, _ = fmt.Fprintf(buf, "<pre>%s</pre>", h.RenderDocstring(e.Content))
-		case goxy.DocStringPreformatted:
-			_, _ = fmt.Fprintf(buf, "<pre>%s</pre>", h.RenderDocstring(e.Content))
-		case goxy.DocStringComputerOutput:
-			_, _ = fmt.Fprintf(buf, "<pre>%s</pre>", h.RenderDocstring(e.Content))
-		case goxy.DocStringItemizedList:
-			_, _ = fmt.Fprintf(buf, "<ul>")
-			for _, item := range e.Items {
-				_, _ = fmt.Fprintf(buf, "<li>%s</li>", h.RenderDocstring(item))
-			}
-			_, _ = fmt.Fprintf(buf, "</ul>")
-		case goxy.DocStringOrderedList:
-			_, _ = fmt.Fprintf(buf, "<ol>")
-			for _, item := range e.Items {
-				_, _ = fmt.Fprintf(buf, "<li>%s</li>", h.RenderDocstring(item))
-			}
-			_, _ = fmt.Fprintf(buf, "</ol>")
-		case goxy.DocStringVariableList:
-			_, _ = fmt.Fprintf(buf, "<dl>")
-			for _, item := range e.Items {
-				if item.Content[0].Type == goxy.Term {
-					_, _ = fmt.Fprintf(buf, "<dt>%s</dt>", h.RenderDocstring(item))
-				} else {
-					_, _ = fmt.Fprintf(buf, "<dd>%s</dd>", h.RenderDocstring(item))
-				}
-			}
-			_, _ = fmt.Fprintf(buf, "</dl>")
-		case goxy.DocStringTerm:
-			_, _ = fmt.Fprintf(buf, "%s", h.RenderDocstring(e.Content))
-		case goxy.DocStringHeading:
-			_, _ = fmt.Fprintf(buf, "<h%d>%s</h%d>", e.Level, h.RenderDocstring(e.Content), e.Level)
-		case goxy.DocStringXRefSect:
-			_, _ = fmt.Fprintf(buf, h.RenderRef(e.Id, fmt.Sprintf("<b>%s</b>: %s", e.Title, h.RenderDocstring(e.Description))))
-		case goxy.DocStringRef:
-			_, _ = fmt.Fprintf(buf, h.RenderRef(e.RefId, h.RenderDocstring(e.Content)))
-		case goxy.DocStringAnchor:
-			_, _ = fmt.Fprintf(buf, "<a id=\"%s\"></a>", e.Id)
-		case goxy.DocStringSection:
-			if e.Kind == "note" {
-				_, _ = fmt.Fprintf(buf, `<blockquote class="gdoc-hint warning">
+func (h *Hugo) Text(text string) string {
+	return strings.ReplaceAll(text, "{{", "££@$$")
+}
+
+func (h *Hugo) Paragraph(content string) string {
+	return fmt.Sprintf("<p>%s</p>", content)
+}
+
+func (h *Hugo) Emphasis(content string) string {
+	return fmt.Sprintf("<em>%s</em>", content)
+}
+
+func (h *Hugo) Bold(content string) string {
+	return fmt.Sprintf("<b>%s</b>", content)
+}
+
+func (h *Hugo) Verbatim(content goxy.DocString) string {
+	return fmt.Sprintf("<pre>%s</pre>", h.RenderDocstring(content))
+}
+
+func (h *Hugo) Preformatted(content goxy.DocString) string {
+	return fmt.Sprintf("<pre>%s</pre>", h.RenderDocstring(content))
+}
+
+func (h *Hugo) ComputerOutput(content goxy.DocString) string {
+	return fmt.Sprintf("<pre>%s</pre>", h.RenderDocstring(content))
+}
+
+func (h *Hugo) Highlight(language string, content goxy.DocString) string {
+	return h.renderListing(language, h.listingFromDocString(content))
+}
+
+func (h *Hugo) ItemizedList(items []string) string {
+	buf := bytes.NewBufferString("<ul>")
+	for _, item := range items {
+		_, _ = fmt.Fprintf(buf, "<li>%s</li>", item)
+	}
+	_, _ = fmt.Fprint(buf, "</ul>")
+	return buf.String()
+}
+
+func (h *Hugo) OrderedList(items []string) string {
+	buf := bytes.NewBufferString("<ol>")
+	for _, item := range items {
+		_, _ = fmt.Fprintf(buf, "<li>%s</li>", item)
+	}
+	_, _ = fmt.Fprint(buf, "</ol>")
+	return buf.String()
+}
+
+func (h *Hugo) VariableList(items []VariableListItem) string {
+	buf := bytes.NewBufferString("<dl>")
+	for _, item := range items {
+		if item.Term {
+			_, _ = fmt.Fprintf(buf, "<dt>%s</dt>", item.Content)
+		} else {
+			_, _ = fmt.Fprintf(buf, "<dd>%s</dd>", item.Content)
+		}
+	}
+	_, _ = fmt.Fprint(buf, "</dl>")
+	return buf.String()
+}
+
+func (h *Hugo) Term(content string) string {
+	return content
+}
+
+func (h *Hugo) Heading(level int, content string) string {
+	return fmt.Sprintf("<h%d>%s</h%d>", level, content, level)
+}
+
+func (h *Hugo) Title(content string) string {
+	return fmt.Sprintf("<h2>%s</h2>", content)
+}
+
+func (h *Hugo) XRefSect(id string, title string, description string) string {
+	return h.RenderRef(id, fmt.Sprintf("<b>%s</b>: %s", title, description))
+}
+
+func (h *Hugo) Ref(refId string, content string) string {
+	return h.RenderRef(refId, content)
+}
+
+func (h *Hugo) Ulink(url string, content string) string {
+	if strings.TrimSpace(content) == "" {
+		content = h.Text(url)
+	}
+	return fmt.Sprintf("<a href=\"%s\" class=\"external\">%s</a>", strings.ReplaceAll(url, "\"", "%22"), content)
+}
+
+func (h *Hugo) Anchor(id string) string {
+	return fmt.Sprintf("<a id=\"%s\"></a>", id)
+}
+
+func (h *Hugo) SimpleSection(kind string, id string, content string) string {
+	if kind == "note" {
+		return fmt.Sprintf(`<blockquote class="gdoc-hint warning">
 <strong>note:</strong><br />
   %s
-</blockquote>`, h.RenderDocstring(e.Content))
-			} else {
-				t, err := template.New("docstringsection").
-					Funcs(funcMap).
-					Parse(templates.DocstringSection)
-				if err != nil {
-					log.Fatalf("error: %+v", errors.WithStack(err))
-				}
-
-				err = t.ExecuteTemplate(buf, "docstringsection", map[string]interface{}{
-					"H":       h,
-					"Id":      e.Id,
-					"Kind":    e.Kind,
-					"Content": e.Content,
-				})
-				if err != nil {
-					log.Fatalf("error: %+v", errors.WithStack(err))
-				}
-			}
+</blockquote>`, content)
+	}
 
-		case goxy.DocStringTitle:
-			_, _ = fmt.Fprintf(buf, "<h2>%s</h2>", h.RenderDocstring(e.Content))
-		case goxy.DocStringParameterList:
-			t, err := template.New("docstringparamlist").
-				Funcs(funcMap).
-				Parse(templates.DocstringParameterList)
-			if err != nil {
-				log.Fatalf("error: %+v", errors.WithStack(err))
-			}
+	return h.executeTemplate("docstringsection", templates.DocstringSection, map[string]interface{}{
+		"Id":      id,
+		"Kind":    kind,
+		"Content": content,
+	})
+}
 
-			err = t.ExecuteTemplate(buf, "docstringparamlist", map[string]interface{}{
-				"H":     h,
-				"Kind":  e.Kind,
-				"Items": e.Items,
-			})
-			if err != nil {
-				log.Fatalf("error: %+v", errors.WithStack(err))
-			}
-		case goxy.DocStringTable:
-			t, err := template.New("docstringtable").
-				Funcs(funcMap).
-				Parse(templates.DocstringTable)
-			if err != nil {
-				log.Fatalf("error: %+v", errors.WithStack(err))
-			}
+func (h *Hugo) ParameterList(kind string, items []ParameterListItem) string {
+	return h.executeTemplate("docstringparamlist", templates.DocstringParameterList, map[string]interface{}{
+		"Kind":  kind,
+		"Items": items,
+	})
+}
 
-			err = t.ExecuteTemplate(buf, "docstringtable", map[string]interface{}{
-				"H":    h,
-				"Rows": e.Rows,
-			})
-			if err != nil {
-				log.Fatalf("error: %+v", errors.WithStack(err))
-			}
-		case goxy.DocStringImage:
-			_, _ = fmt.Fprintf(buf, "<img src=\"%s\" alt=\"%s\" />", e.Name, e.Description)
-		case goxy.DocStringHighlight:
-			_, _ = fmt.Fprintf(buf, "%s", h.RenderHighlight(e.Language, h.RenderDocstring(e.Content)))
-		case goxy.DocStringLinebreak:
-			_, _ = fmt.Fprint(buf, "<br />")
-		default:
-			log.Fatalf("error: %+v", errors.WithStack(errors.New("unable to resolve docstring type: "+string(element.Type))))
-		}
+func (h *Hugo) Table(rows [][]TableCell) string {
+	return h.executeTemplate("docstringtable", templates.DocstringTable, map[string]interface{}{
+		"Rows": rows,
+	})
+}
+
+func (h *Hugo) Image(image goxy.DocStringImage) string {
+	return fmt.Sprintf("<img src=\"%s\" alt=\"%s\" />", image.Name, image.Description)
+}
+
+func (h *Hugo) LineBreak() string {
+	return "<br />"
+}
+
+func (h *Hugo) HorizontalRule() string {
+	return "<hr />"
+}
+
+func (h *Hugo) BlockQuote(content string) string {
+	return fmt.Sprintf("<blockquote>%s</blockquote>", content)
+}
+
+func (h *Hugo) Details(summary string, content string) string {
+	if strings.TrimSpace(summary) == "" {
+		summary = "Details"
 	}
+	return fmt.Sprintf("<details><summary>%s</summary>%s</details>", summary, content)
+}
+
+func (h *Hugo) Strike(content string) string {
+	return fmt.Sprintf("<del>%s</del>", content)
+}
+
+func (h *Hugo) Underline(content string) string {
+	return fmt.Sprintf("<u>%s</u>", content)
+}
+
+func (h *Hugo) Subscript(content string) string {
+	return fmt.Sprintf("<sub>%s</sub>", content)
+}
 
+func (h *Hugo) Superscript(content string) string {
+	return fmt.Sprintf("<sup>%s</sup>", content)
+}
+
+// Formula renders a formula with the delimiters of KaTeX and MathJax, they
+// typeset it if the theme loads them.
+func (h *Hugo) Formula(formula string) string {
+	tex, block := FormulaTeX(formula)
+	if block {
+		return fmt.Sprintf("<div class=\"goxy-formula\">\\[%s\\]</div>", h.Text(gohtml.EscapeString(tex)))
+	}
+	return fmt.Sprintf("<span class=\"goxy-formula\">\\(%s\\)</span>", h.Text(gohtml.EscapeString(tex)))
+}
+
+// Diagram shows the source of a diagram, there is nothing to draw it with.
+func (h *Hugo) Diagram(diagram goxy.DocStringDiagram) string {
+	buf := bytes.NewBufferString("")
+	_, _ = fmt.Fprintf(buf, "<figure class=\"goxy-diagram goxy-diagram--%s\">", diagram.Language)
+	if diagram.File != "" {
+		_, _ = fmt.Fprintf(buf, "<code>%s</code>", gohtml.EscapeString(diagram.File))
+	} else {
+		_, _ = fmt.Fprintf(buf, "<pre>%s</pre>", h.Text(gohtml.EscapeString(diagram.Content)))
+	}
+	if diagram.Caption != "" {
+		_, _ = fmt.Fprintf(buf, "<figcaption>%s</figcaption>", h.Text(gohtml.EscapeString(diagram.Caption)))
+	}
+	_, _ = fmt.Fprint(buf, "</figure>")
 	return buf.String()
 }
 
+// executeTemplate renders one of the templates of the doc string elements,
+// they are part of the formatter, so failing to render them is fatal.
+func (h *Hugo) executeTemplate(name string, text string, data interface{}) string {
+	content, err := executeTemplate(name, text, data)
+	if err != nil {
+		log.Fatalf("error: %+v", err)
+	}
+	return content
+}
+
 func (h *Hugo) RenderEnumBody(values []goxy.EnumValue) string {
 	buf := bytes.NewBufferString("{")
 	if len(values) > 1 {
@@ -379,68 +421,65 @@ func (h *Hugo) RenderEnumBody(values []goxy.EnumValue) string {
 }
 
 func (h *Hugo) RenderInnerCompound(compound goxy.InnerCompoundRef) string {
-	buf := bytes.NewBufferString("")
-
-	t, err := template.New("innercompound").
-		Funcs(funcMap).
-		Parse(templates.InnerCompound)
-	if err != nil {
-		log.Fatalf("error: %+v", errors.WithStack(err))
-	}
-
-	err = t.ExecuteTemplate(buf, "innercompound", map[string]interface{}{
+	return h.executeTemplate("innercompound", templates.InnerCompound, map[string]interface{}{
 		"H":          h,
 		"RefId":      compound.RefId,
 		"Value":      compound.Value,
 		"Protection": compound.Protection,
 	})
-	if err != nil {
-		log.Fatalf("error: %+v", errors.WithStack(err))
-	}
+}
 
-	return buf.String()
+// RenderIncludes lists the files a file includes and the files including it,
+// each with a diagram of the include graph.
+func (h *Hugo) RenderIncludes(compound *goxy.CompoundDoc) string {
+	return h.renderIncludes("includes", "Includes", "Include dependency graph for "+compound.Title, compound.Includes, compound.IncludeGraph) +
+		h.renderIncludes("includedby", "Included by", "Files including "+compound.Title, compound.IncludedBy, compound.IncludedByGraph.Reversed())
 }
 
-func (h *Hugo) RenderSectionBrief(section *goxy.SectionDoc) string {
-	buf := bytes.NewBufferString("")
+func (h *Hugo) renderIncludes(id string, title string, graphTitle string, includes []goxy.IncludeRef, graph goxy.Graph) string {
+	definition := ""
+	if len(graph.Nodes) > 1 {
+		definition = mermaidIncludeGraph(graph, h.LookupHref)
+	}
+	if len(includes) == 0 && definition == "" {
+		return ""
+	}
+	return h.executeTemplate("includes", templates.Includes, map[string]interface{}{
+		"H":          h,
+		"Id":         id,
+		"Title":      title,
+		"GraphTitle": graphTitle,
+		"Includes":   includes,
+		"Graph":      definition,
+	})
+}
 
-	t, err := template.New("sectionbrief").
-		Funcs(funcMap).
-		Parse(templates.SectionBrief)
-	if err != nil {
-		log.Fatalf("error: %+v", errors.WithStack(err))
+// RenderInclude renders an include as written in the source, linked to the
+// page of the file if it's in the doc set.
+func (h *Hugo) RenderInclude(include goxy.IncludeRef) string {
+	name := gohtml.EscapeString(IncludeName(include))
+	if include.RefId == "" {
+		return name
+	}
+	href, ok := h.LookupHref(include.RefId)
+	if !ok {
+		return name
 	}
+	return fmt.Sprintf("<a href=\"%s\">%s</a>", href, name)
+}
 
-	err = t.ExecuteTemplate(buf, "sectionbrief", map[string]interface{}{
+func (h *Hugo) RenderSectionBrief(section *goxy.SectionDoc) string {
+	return h.executeTemplate("sectionbrief", templates.SectionBrief, map[string]interface{}{
 		"H":       h,
 		"Section": section,
 	})
-	if err != nil {
-		log.Fatalf("error: %+v", errors.WithStack(err))
-	}
-
-	return buf.String()
 }
 
 func (h *Hugo) RenderSection(section *goxy.SectionDoc) string {
-	buf := bytes.NewBufferString("")
-
-	t, err := template.New("section").
-		Funcs(funcMap).
-		Parse(templates.Section)
-	if err != nil {
-		log.Fatalf("error: %+v", errors.WithStack(err))
-	}
-
-	err = t.ExecuteTemplate(buf, "section", map[string]interface{}{
+	return h.executeTemplate("section", templates.Section, map[string]interface{}{
 		"H":       h,
 		"Section": section,
 	})
-	if err != nil {
-		log.Fatalf("error: %+v", errors.WithStack(err))
-	}
-
-	return buf.String()
 }
 
 func (h *Hugo) RenderDirJson(compound *goxy.CompoundDoc) string {
@@ -449,18 +488,29 @@ func (h *Hugo) RenderDirJson(compound *goxy.CompoundDoc) string {
 	_, _ = fmt.Fprintf(buf, "{\"Name\":\"%s\",\"Id\":\"%s\"", compound.Name, compound.Id)
 
 	_, _ = fmt.Fprint(buf, ",\"Dirs\":[")
-	for idx, dir := range compound.InnerDirs {
-		if idx > 0 {
+	first := true
+	for _, dir := range compound.InnerDirs {
+		dirCompound, ok := h.LookupCompound(dir.RefId)
+		if !ok {
+			continue
+		}
+		if !first {
 			_, _ = fmt.Fprint(buf, ",")
 		}
-		_, _ = fmt.Fprint(buf, h.RenderDirJson(h.CompoundIdMap[dir.RefId]))
+		first = false
+		_, _ = fmt.Fprint(buf, h.RenderDirJson(dirCompound))
 	}
 	_, _ = fmt.Fprint(buf, "],\"Files\":[")
-	for idx, file := range compound.InnerFiles {
-		if idx > 0 {
+	first = true
+	for _, file := range compound.InnerFiles {
+		fileCompound, ok := h.LookupCompound(file.RefId)
+		if !ok {
+			continue
+		}
+		if !first {
 			_, _ = fmt.Fprint(buf, ",")
 		}
-		fileCompound := h.CompoundIdMap[file.RefId]
+		first = false
 		_, _ = fmt.Fprintf(buf, "{\"Name\":\"%s\",\"Id\":\"%s\"}", fileCompound.Name, fileCompound.Id)
 	}
 	_, _ = fmt.Fprint(buf, "]}")
@@ -468,14 +518,8 @@ func (h *Hugo) RenderDirJson(compound *goxy.CompoundDoc) string {
 	return buf.String()
 }
 
-func (h *Hugo) WriteCompound(compound *goxy.CompoundDoc, path string) error {
-	var err error
-
-	err = os.MkdirAll(fmt.Sprintf("%s", filepath.Dir(path)), 0644)
-	if err != nil {
-		return errors.WithStack(err)
-	}
-
+func (h *Hugo) RenderCompound(compound *goxy.CompoundDoc) ([]byte, error) {
+	h.startCompound(compound)
 	var mdType string
 	switch compound.Kind {
 	case goxy.Dir:
@@ -496,41 +540,15 @@ func (h *Hugo) WriteCompound(compound *goxy.CompoundDoc, path string) error {
 		compoundTemplate = templates.DirCompound
 	}
 
-	t, err := template.New("compound").
-		Funcs(funcMap).
-		Parse(compoundTemplate)
-
-	if err != nil {
-		return errors.WithStack(err)
-	}
-
-	f, err := os.Create(path)
+	content, err := executeTemplate("compound", compoundTemplate, model)
 	if err != nil {
-		return errors.WithStack(err)
+		return nil, err
 	}
-	defer f.Close()
 
-	buf := bytes.NewBufferString("")
-	err = t.ExecuteTemplate(buf, "compound", model)
-	if err != nil {
-		return errors.WithStack(err)
-	}
-	w := bufio.NewWriter(f)
-	_, _ = w.WriteString(
+	return []byte(
 		strings.ReplaceAll(
-			strings.ReplaceAll(buf.String(), "££@$$", "{{\"{\"}}"),
+			strings.ReplaceAll(content, "££@$$", "{{\"{\"}}"),
 			"__index_when_offline__",
-			"{{< index-when-offline >}}"))
-
-	err = w.Flush()
-	if err != nil {
-		return errors.WithStack(err)
-	}
-	/*
-		err = ioutil.WriteFile(fmt.Sprintf("hugo/content/coding/%s/%s.md", compound.Kind, compound.Id), []byte(mdContent), 0644)
-		if err != nil {
-			return err
-		}
-	*/
-	return nil
+			"{{< index-when-offline >}}")), nil
 }
+
@@ -0,0 +1,158 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/goxy"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// HugoOutput writes the doc sets as content of the Hugo site, with the data
+// file and main menu its theme reads.
+type HugoOutput struct {
+	Options
+	DocSets []*DocSet
+}
+
+func NewHugoOutput(opts Options, sets []*DocSet) *HugoOutput {
+	return &HugoOutput{
+		Options: opts,
+		DocSets: sets,
+	}
+}
+
+func (o *HugoOutput) Extension() string {
+	return ".html"
+}
+
+func (o *HugoOutput) Renderer(set *DocSet) Renderer {
+	h := NewHugoFormatter(set.Section, set.Entities, set.Refs)
+	h.Diagnostics = o.Diagnostics
+	return h
+}
+
+func (o *HugoOutput) WriteCompound(set *DocSet, compound *goxy.CompoundDoc, path string) error {
+	return writeCompound(o.Renderer(set), compound, path)
+}
+
+// WriteIndex does nothing, Hugo renders the list pages of the sections itself.
+func (o *HugoOutput) WriteIndex() error {
+	return nil
+}
+
+type GeekdocBundleMenuItem struct {
+	Name string                  `yaml:"name,omitempty"`
+	Ref  string                  `yaml:"ref,omitempty"`
+	Icon string                  `yaml:"icon,omitempty"`
+	Sub  []GeekdocBundleMenuItem `yaml:"sub,omitempty"`
+}
+
+type GeekdocBundleMenu struct {
+	Menus map[string][]GeekdocBundleMenuItem
+}
+
+func BuildDocSetMenu(set *DocSet) GeekdocBundleMenuItem {
+	pages := make([]GeekdocBundleMenuItem, 0)
+	var rootDirRefId string
+	hasUnions := false
+	for _, compound := range set.Compounds {
+		switch compound.Kind {
+		case goxy.Page:
+			pages = append(pages, GeekdocBundleMenuItem{
+				Name: compound.Title,
+				Ref:  fmt.Sprintf("%s/page/%s", set.Section, compound.Id),
+			})
+		case goxy.Dir:
+			if set.RootDir != "" && compound.Title == set.RootDir {
+				rootDirRefId = compound.Id
+			}
+		case goxy.Union:
+			hasUnions = true
+		}
+	}
+
+	sub := []GeekdocBundleMenuItem{
+		{
+			Name: "Classes",
+			Ref:  fmt.Sprintf("%s/class", set.Section),
+		},
+	}
+	if rootDirRefId != "" {
+		sub = append(sub, GeekdocBundleMenuItem{
+			Name: "Files",
+			Ref:  fmt.Sprintf("%s/dir/%s", set.Section, rootDirRefId),
+		})
+	}
+	sub = append(sub,
+		GeekdocBundleMenuItem{
+			Name: "Groups",
+			Ref:  fmt.Sprintf("%s/group", set.Section),
+		},
+		GeekdocBundleMenuItem{
+			Name: "Namespaces",
+			Ref:  fmt.Sprintf("%s/namespace", set.Section),
+		},
+		GeekdocBundleMenuItem{
+			Name: "Pages",
+			Ref:  fmt.Sprintf("%s/page", set.Section),
+			Sub:  pages,
+		},
+	)
+	if hasUnions {
+		sub = append(sub, GeekdocBundleMenuItem{
+			Name: "Unions",
+			Ref:  fmt.Sprintf("%s/union", set.Section),
+		})
+	}
+
+	return GeekdocBundleMenuItem{
+		Name: set.Title,
+		Sub:  sub,
+	}
+}
+
+// WriteMenu writes the main menu of the geekdoc theme, with an entry per doc
+// set.
+func (o *HugoOutput) WriteMenu() error {
+	items := make([]GeekdocBundleMenuItem, len(o.DocSets))
+	for i, set := range o.DocSets {
+		items[i] = BuildDocSetMenu(set)
+	}
+
+	menu := map[string][]GeekdocBundleMenuItem{
+		"main": items,
+	}
+
+	bytes, err := yaml.Marshal(menu)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = WriteFileIfChanged(o.MenuFile, bytes)
+	return err
+}
+
+// hugoData is the data of a doc set the templates of the site look compounds
+// and refs up in.
+type hugoData struct {
+	Entities map[string]*goxy.CompoundDoc
+	Refs     map[string]goxy.CompoundRef
+}
+
+// WriteData writes the data of every doc set, by name, to the data file.
+func (o *HugoOutput) WriteData() error {
+	data := make(map[string]hugoData, len(o.DocSets))
+	for _, set := range o.DocSets {
+		data[set.Name] = hugoData{
+			Entities: set.Entities,
+			Refs:     set.Refs,
+		}
+	}
+
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = WriteFileIfChanged(o.DataFile, bytes)
+	return err
+}
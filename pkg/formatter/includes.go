@@ -0,0 +1,39 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/goxy"
+	"bytes"
+	"fmt"
+)
+
+// IncludeName returns an include the way it's written in the source, like
+// <string> or "foo.h".
+func IncludeName(include goxy.IncludeRef) string {
+	if include.Local {
+		return fmt.Sprintf("\"%s\"", include.Name)
+	}
+	return fmt.Sprintf("<%s>", include.Name)
+}
+
+// mermaidIncludeGraph draws an include graph, with edges from the files to the
+// files they include, as a mermaid flowchart with the included files below.
+// The files of the doc set link to their pages with href.
+func mermaidIncludeGraph(graph goxy.Graph, href func(refId string) (string, bool)) string {
+	buf := bytes.NewBufferString("graph TD\n")
+	for _, node := range graph.Nodes {
+		_, _ = fmt.Fprintf(buf, "n%d[\"%s\"]\n", node.Id, mermaidEscape(node.Label))
+		if node.RefId == "" {
+			continue
+		}
+		if h, ok := href(node.RefId); ok {
+			_, _ = fmt.Fprintf(buf, "click n%d \"%s\" \"See documentation for %s\"\n", node.Id, h, mermaidEscape(node.Label))
+		}
+	}
+	for _, edge := range graph.Edges {
+		if graph.ResolveId(edge.FromId) == nil || graph.ResolveId(edge.ToId) == nil {
+			continue
+		}
+		_, _ = fmt.Fprintf(buf, "n%d --> n%d\n", edge.FromId, edge.ToId)
+	}
+	return buf.String()
+}
@@ -0,0 +1,402 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/goxy"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Man renders compounds and their members as roff man pages, for reading the
+// docs with man(1) on machines without a browser. Man pages can't link, refs
+// are rendered as their text.
+type Man struct {
+	*Core
+
+	// Manual is the title of the manual the pages belong to, shown in their
+	// header.
+	Manual string
+}
+
+func NewManFormatter(section string, manual string, idMap map[string]*goxy.CompoundDoc, refs map[string]goxy.CompoundRef) *Man {
+	return &Man{
+		Core: NewCore(section, idMap, refs, func(kind string, refId string) string {
+			return fmt.Sprintf("../%s/%s.3", kind, refId)
+		}),
+		Manual: manual,
+	}
+}
+
+// manEscape escapes the backslashes of s and keeps lines of it from being read
+// as requests. Whitespace is collapsed, roff fills the lines itself.
+func manEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\e")
+	s = strings.ReplaceAll(s, "-", "\\-")
+	s = markdownWhitespace.ReplaceAllString(s, " ")
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
+
+// manLiteral renders text in no-fill mode, keeping its lines.
+func manLiteral(s string) string {
+	lines := strings.Split(strings.Trim(s, "\n"), "\n")
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "\\", "\\e")
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			line = "\\&" + line
+		}
+		lines[i] = line
+	}
+	return fmt.Sprintf("\n.PP\n.RS 4\n.nf\n%s\n.fi\n.RE\n", strings.Join(lines, "\n"))
+}
+
+// manInline joins rendered roff into text for a single line, like the tag of a
+// .TP or a table cell.
+func manInline(s string) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ".") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, " ")
+}
+
+// manQuote quotes an argument of a request.
+func manQuote(s string) string {
+	return "\"" + strings.ReplaceAll(s, "\"", "\\(dq") + "\""
+}
+
+// collapseRequests removes the blank lines between the requests, which roff
+// would print as vertical space, and paragraphs left without text.
+func collapseRequests(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	out := make([]string, 0, len(lines))
+	literal := false
+	for _, line := range lines {
+		switch {
+		case line == ".nf":
+			literal = true
+		case line == ".fi":
+			literal = false
+		case !literal && strings.TrimSpace(line) == "":
+			continue
+		case line == ".PP" && len(out) > 0 && (out[len(out)-1] == ".PP" || strings.HasPrefix(out[len(out)-1], ".S")):
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+func (m *Man) RenderDocstring(docstring goxy.DocString) string {
+	return RenderDocString(m, docstring)
+}
+
+func (m *Man) Text(text string) string {
+	return manEscape(text)
+}
+
+func (m *Man) Paragraph(content string) string {
+	return fmt.Sprintf("\n.PP\n%s\n", strings.TrimSpace(content))
+}
+
+func (m *Man) Emphasis(content string) string {
+	return fmt.Sprintf("\\fI%s\\fP", strings.TrimSpace(content))
+}
+
+func (m *Man) Bold(content string) string {
+	return fmt.Sprintf("\\fB%s\\fP", strings.TrimSpace(content))
+}
+
+func (m *Man) Verbatim(content goxy.DocString) string {
+	return manLiteral(goxy.PlainText(content))
+}
+
+func (m *Man) Preformatted(content goxy.DocString) string {
+	return manLiteral(goxy.PlainText(content))
+}
+
+func (m *Man) ComputerOutput(content goxy.DocString) string {
+	return fmt.Sprintf("\\fB%s\\fP", manEscape(goxy.PlainText(content)))
+}
+
+func (m *Man) Highlight(language string, content goxy.DocString) string {
+	return manLiteral(goxy.CodeText(content))
+}
+
+func (m *Man) ItemizedList(items []string) string {
+	buf := bytes.NewBufferString("\n")
+	for _, item := range items {
+		_, _ = fmt.Fprintf(buf, ".IP \\(bu 2\n%s\n", manInline(item))
+	}
+	return buf.String()
+}
+
+func (m *Man) OrderedList(items []string) string {
+	buf := bytes.NewBufferString("\n")
+	for i, item := range items {
+		_, _ = fmt.Fprintf(buf, ".IP %d. 4\n%s\n", i+1, manInline(item))
+	}
+	return buf.String()
+}
+
+func (m *Man) VariableList(items []VariableListItem) string {
+	buf := bytes.NewBufferString("\n")
+	for _, item := range items {
+		if item.Term {
+			_, _ = fmt.Fprintf(buf, ".TP\n%s\n", manInline(item.Content))
+		} else {
+			_, _ = fmt.Fprintf(buf, "%s\n", manInline(item.Content))
+		}
+	}
+	return buf.String()
+}
+
+func (m *Man) Term(content string) string {
+	return content
+}
+
+func (m *Man) Heading(level int, content string) string {
+	return fmt.Sprintf("\n.SS %s\n", manQuote(manInline(content)))
+}
+
+func (m *Man) Title(content string) string {
+	return fmt.Sprintf("\n.SS %s\n", manQuote(manInline(content)))
+}
+
+func (m *Man) XRefSect(id string, title string, description string) string {
+	return fmt.Sprintf("\n.PP\n\\fB%s:\\fP %s\n", manEscape(title), manInline(description))
+}
+
+func (m *Man) Ref(refId string, content string) string {
+	m.track(refId)
+	return content
+}
+
+func (m *Man) Ulink(url string, content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" || content == manEscape(url) {
+		return fmt.Sprintf("\\fI%s\\fP", manEscape(url))
+	}
+	return fmt.Sprintf("%s <\\fI%s\\fP>", content, manEscape(url))
+}
+
+func (m *Man) Anchor(id string) string {
+	return ""
+}
+
+func (m *Man) SimpleSection(kind string, id string, content string) string {
+	if kind == "" {
+		return content
+	}
+	return fmt.Sprintf("\n.PP\n\\fB%s:\\fP\n.RS 4\n%s\n.RE\n", strings.Title(kind), strings.TrimSpace(content))
+}
+
+func (m *Man) ParameterList(kind string, items []ParameterListItem) string {
+	buf := bytes.NewBufferString("")
+	_, _ = fmt.Fprintf(buf, "\n.PP\n\\fB%s:\\fP\n.RS 4\n", parameterListTitle(kind))
+	for _, item := range items {
+		_, _ = fmt.Fprintf(buf, ".TP\n\\fB%s\\fP\n%s\n", manEscape(item.Name), manInline(item.Description))
+	}
+	_, _ = fmt.Fprint(buf, ".RE\n")
+	return buf.String()
+}
+
+func (m *Man) Table(rows [][]TableCell) string {
+	buf := bytes.NewBufferString("\n.PP\n.RS 4\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = manInline(cell.Content)
+			if cell.Head {
+				cells[i] = fmt.Sprintf("\\fB%s\\fP", cells[i])
+			}
+		}
+		_, _ = fmt.Fprintf(buf, "%s\n.br\n", strings.Join(cells, " | "))
+	}
+	_, _ = fmt.Fprint(buf, ".RE\n")
+	return buf.String()
+}
+
+func (m *Man) Image(image goxy.DocStringImage) string {
+	if image.Description != "" {
+		return fmt.Sprintf("[%s]", manEscape(image.Description))
+	}
+	return fmt.Sprintf("[%s]", manEscape(image.Name))
+}
+
+func (m *Man) LineBreak() string {
+	return "\n.br\n"
+}
+
+func (m *Man) HorizontalRule() string {
+	return "\n.PP\n\\l'\\n(.lu'\n"
+}
+
+func (m *Man) BlockQuote(content string) string {
+	return fmt.Sprintf("\n.PP\n.RS 4\n%s\n.RE\n", strings.TrimSpace(content))
+}
+
+func (m *Man) Details(summary string, content string) string {
+	return fmt.Sprintf("\n.PP\n\\fB%s\\fP\n.RS 4\n%s\n.RE\n", manInline(summary), strings.TrimSpace(content))
+}
+
+// Strike keeps the text, roff has no strikethrough.
+func (m *Man) Strike(content string) string {
+	return content
+}
+
+// Underline renders italics, which terminals underline.
+func (m *Man) Underline(content string) string {
+	return fmt.Sprintf("\\fI%s\\fP", strings.TrimSpace(content))
+}
+
+func (m *Man) Subscript(content string) string {
+	return "_" + content
+}
+
+func (m *Man) Superscript(content string) string {
+	return "^" + content
+}
+
+func (m *Man) Formula(formula string) string {
+	tex, block := FormulaTeX(formula)
+	if block {
+		return manLiteral(tex)
+	}
+	return fmt.Sprintf("\\fI%s\\fP", manEscape(tex))
+}
+
+func (m *Man) Diagram(diagram goxy.DocStringDiagram) string {
+	if diagram.File != "" {
+		return m.Image(goxy.DocStringImage{Name: diagram.File, Description: diagram.Caption})
+	}
+	caption := ""
+	if diagram.Caption != "" {
+		caption = fmt.Sprintf("\n.PP\n%s\n", manEscape(diagram.Caption))
+	}
+	return manLiteral(diagram.Content) + caption
+}
+
+// header starts a man page, the pages of the engine API all go to section 3 of
+// the manual, like library functions.
+func (m *Man) header(buf *bytes.Buffer, name string) {
+	_, _ = fmt.Fprintf(buf, ".TH %s 3 \"\" %s %s\n", manQuote(manEscape(name)), manQuote(manEscape(m.Section)), manQuote(manEscape(m.Manual)))
+	_, _ = fmt.Fprint(buf, ".ad l\n.nh\n")
+}
+
+func (m *Man) renderName(buf *bytes.Buffer, name string, brief goxy.DocString) {
+	_, _ = fmt.Fprintf(buf, ".SH NAME\n%s", manEscape(name))
+	if brief := manInline(m.RenderDocstring(brief)); brief != "" {
+		_, _ = fmt.Fprintf(buf, " \\- %s", brief)
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+}
+
+func (m *Man) renderDescriptions(buf *bytes.Buffer, descriptions goxy.Descriptions) {
+	_, _ = fmt.Fprint(buf, m.RenderDocstring(descriptions.BriefDescription))
+	_, _ = fmt.Fprint(buf, m.RenderDocstring(descriptions.DetailedDescription))
+}
+
+func (m *Man) renderValues(buf *bytes.Buffer, values []goxy.EnumValue) {
+	if len(values) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprint(buf, "\n.PP\n\\fBValues:\\fP\n.RS 4\n")
+	for _, value := range values {
+		_, _ = fmt.Fprintf(buf, ".TP\n\\fB%s\\fP\n%s\n", manEscape(strings.TrimSpace(value.Name+" "+value.Initializer)), manInline(m.RenderDocstring(value.BriefDescription)+" "+m.RenderDocstring(value.DetailedDescription)))
+	}
+	_, _ = fmt.Fprint(buf, ".RE\n")
+}
+
+// RenderMember renders the man page of a single member of a compound.
+func (m *Man) RenderMember(compound *goxy.CompoundDoc, member MemberDoc) []byte {
+	buf := bytes.NewBufferString("")
+	m.header(buf, compound.Title+"::"+member.Name)
+	m.renderName(buf, member.Name, member.BriefDescription)
+
+	_, _ = fmt.Fprint(buf, ".SH SYNOPSIS\n")
+	_, _ = fmt.Fprint(buf, manLiteral(member.Signature))
+
+	_, _ = fmt.Fprint(buf, ".SH DESCRIPTION\n")
+	_, _ = fmt.Fprintf(buf, "%s of \\fB%s\\fP.\n", strings.Title(member.Kind), manEscape(compound.Title))
+	m.renderDescriptions(buf, member.Descriptions)
+	m.renderValues(buf, member.Values)
+
+	return []byte(collapseRequests(buf.String()) + "\n")
+}
+
+func (m *Man) RenderCompound(compound *goxy.CompoundDoc) ([]byte, error) {
+	m.startCompound(compound)
+	buf := bytes.NewBufferString("")
+	m.header(buf, compound.Title)
+	m.renderName(buf, compound.Title, compound.BriefDescription)
+
+	synopsis := make([]string, 0)
+	for _, include := range compound.Includes {
+		synopsis = append(synopsis, "#include "+IncludeName(include))
+	}
+	if len(synopsis) > 0 {
+		synopsis = append(synopsis, "")
+	}
+	for _, section := range compound.Sections {
+		for _, member := range Members(section) {
+			if member.Kind != "enum" {
+				synopsis = append(synopsis, member.Signature)
+			}
+		}
+	}
+	if len(synopsis) > 0 {
+		_, _ = fmt.Fprint(buf, ".SH SYNOPSIS\n")
+		_, _ = fmt.Fprint(buf, manLiteral(strings.Join(synopsis, "\n")))
+	}
+
+	_, _ = fmt.Fprint(buf, ".SH DESCRIPTION\n")
+	if compound.Location.File != "" {
+		_, _ = fmt.Fprintf(buf, "Defined in \\fI%s\\fP.\n", manEscape(compound.Location.File))
+	}
+	m.renderDescriptions(buf, compound.Descriptions)
+
+	for _, section := range compound.Sections {
+		members := Members(section)
+		if len(members) == 0 {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(buf, ".SH %s\n", manQuote(manEscape(strings.ToUpper(section.Header))))
+		_, _ = fmt.Fprint(buf, m.RenderDocstring(section.Description))
+		for _, member := range members {
+			_, _ = fmt.Fprintf(buf, ".SS %s\n", manQuote(manEscape(member.Name)))
+			_, _ = fmt.Fprint(buf, manLiteral(member.Signature))
+			m.renderDescriptions(buf, member.Descriptions)
+			m.renderValues(buf, member.Values)
+		}
+	}
+
+	inner := make([]string, 0)
+	for _, refs := range [][]goxy.InnerCompoundRef{compound.InnerClasses, compound.InnerNamespaces, compound.InnerGroups, compound.InnerDirs, compound.InnerFiles} {
+		for _, ref := range refs {
+			inner = append(inner, fmt.Sprintf("\\fB%s\\fP(3)", manEscape(m.CompoundTitle(ref.RefId))))
+		}
+	}
+	for _, include := range compound.IncludedBy {
+		if include.RefId == "" {
+			continue
+		}
+		if _, ok := m.LookupRef(include.RefId); ok {
+			inner = append(inner, fmt.Sprintf("\\fB%s\\fP(3)", manEscape(m.CompoundTitle(include.RefId))))
+		}
+	}
+	if len(inner) > 0 {
+		_, _ = fmt.Fprintf(buf, ".SH \"SEE ALSO\"\n%s\n", strings.Join(inner, ",\n"))
+	}
+
+	return []byte(collapseRequests(buf.String()) + "\n"), nil
+}
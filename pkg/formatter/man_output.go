@@ -0,0 +1,48 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/goxy"
+)
+
+// ManOutput writes the doc sets as man pages, a page per compound in section 3
+// of the manual. They are read with man -l or by adding the output to MANPATH.
+type ManOutput struct {
+	Options
+	DocSets []*DocSet
+}
+
+func NewManOutput(opts Options, sets []*DocSet) *ManOutput {
+	return &ManOutput{
+		Options: opts,
+		DocSets: sets,
+	}
+}
+
+func (o *ManOutput) Extension() string {
+	return ".3"
+}
+
+func (o *ManOutput) Renderer(set *DocSet) Renderer {
+	m := NewManFormatter(set.Section, set.Title, set.Entities, set.Refs)
+	m.Diagnostics = o.Diagnostics
+	return m
+}
+
+func (o *ManOutput) WriteCompound(set *DocSet, compound *goxy.CompoundDoc, path string) error {
+	return writeCompound(o.Renderer(set), compound, path)
+}
+
+// WriteIndex does nothing, man pages are found by name.
+func (o *ManOutput) WriteIndex() error {
+	return nil
+}
+
+// WriteMenu does nothing, the pages refer to each other under SEE ALSO.
+func (o *ManOutput) WriteMenu() error {
+	return nil
+}
+
+// WriteData does nothing, man pages are self-contained.
+func (o *ManOutput) WriteData() error {
+	return nil
+}
@@ -0,0 +1,529 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/goxy"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Markdown renders compounds as plain CommonMark with the GitHub flavored
+// tables, so the docs can be published without Hugo, e.g. to GitHub wikis,
+// MkDocs or Docusaurus. Pages link to each other relative to their own folder,
+// <kind>/<id>.md, and only use HTML for anchors.
+type Markdown struct {
+	*Core
+}
+
+func NewMarkdownFormatter(section string, idMap map[string]*goxy.CompoundDoc, refs map[string]goxy.CompoundRef) *Markdown {
+	return &Markdown{
+		Core: NewCore(section, idMap, refs, func(kind string, refId string) string {
+			return fmt.Sprintf("../%s/%s.md", kind, refId)
+		}),
+	}
+}
+
+var (
+	markdownSpecial    = regexp.MustCompile("([\\\\`*_\\[\\]<>|])")
+	markdownWhitespace = regexp.MustCompile("\\s+")
+	markdownBlankLines = regexp.MustCompile("\n{3,}")
+
+	// markdownUrlEscaper escapes the characters that end the destination of
+	// a link.
+	markdownUrlEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29")
+)
+
+// markdownEscape escapes the characters of s that CommonMark would read as
+// markup and collapses its whitespace, which is insignificant in doxygen text.
+func markdownEscape(s string) string {
+	return markdownSpecial.ReplaceAllString(markdownWhitespace.ReplaceAllString(s, " "), "\\$1")
+}
+
+// markdownCode renders s as a code span, with a fence longer than any run of
+// backticks in s.
+func markdownCode(s string) string {
+	s = markdownWhitespace.ReplaceAllString(s, " ")
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// markdownCodeBlock renders content as a fenced code block.
+func markdownCodeBlock(language string, content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+
+	switch language {
+	case "C++":
+		language = "cpp"
+	default:
+		language = strings.ToLower(language)
+	}
+	return fmt.Sprintf("\n\n%s%s\n%s\n%s\n\n", fence, language, strings.Trim(content, "\n"), fence)
+}
+
+// markdownInline joins the blocks of rendered Markdown into a single line, for
+// table cells and list entries.
+func markdownInline(s string) string {
+	return strings.ReplaceAll(strings.TrimSpace(markdownBlankLines.ReplaceAllString(s, "\n\n")), "\n", " ")
+}
+
+// markdownIndent indents every line but the first of s, so it continues a list
+// item.
+func markdownIndent(s string, indent string) string {
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collapseBlankLines removes the runs of blank lines left between blocks,
+// except inside fenced code blocks.
+func collapseBlankLines(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	out := make([]string, 0, len(lines))
+	fenced := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			fenced = !fenced
+		}
+		if !fenced && strings.TrimSpace(line) == "" && len(out) > 0 && out[len(out)-1] == "" {
+			continue
+		}
+		if !fenced && strings.TrimSpace(line) == "" {
+			line = ""
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+func (m *Markdown) RenderRef(refId, content string) string {
+	href, ok := m.LookupHref(refId)
+	if !ok {
+		m.unknownRef(refId)
+		return content
+	}
+	return fmt.Sprintf("[%s](%s)", content, href)
+}
+
+func (m *Markdown) RenderDocstring(docstring goxy.DocString) string {
+	return RenderDocString(m, docstring)
+}
+
+func (m *Markdown) Text(text string) string {
+	return markdownEscape(text)
+}
+
+func (m *Markdown) Paragraph(content string) string {
+	return fmt.Sprintf("\n\n%s\n\n", strings.TrimSpace(content))
+}
+
+func (m *Markdown) Emphasis(content string) string {
+	return fmt.Sprintf("*%s*", strings.TrimSpace(content))
+}
+
+func (m *Markdown) Bold(content string) string {
+	return fmt.Sprintf("**%s**", strings.TrimSpace(content))
+}
+
+func (m *Markdown) Verbatim(content goxy.DocString) string {
+	return markdownCodeBlock("", goxy.PlainText(content))
+}
+
+func (m *Markdown) Preformatted(content goxy.DocString) string {
+	return markdownCodeBlock("", goxy.PlainText(content))
+}
+
+func (m *Markdown) ComputerOutput(content goxy.DocString) string {
+	return markdownCode(goxy.PlainText(content))
+}
+
+func (m *Markdown) Highlight(language string, content goxy.DocString) string {
+	return markdownCodeBlock(language, goxy.CodeText(content))
+}
+
+func (m *Markdown) ItemizedList(items []string) string {
+	buf := bytes.NewBufferString("\n\n")
+	for _, item := range items {
+		_, _ = fmt.Fprintf(buf, "- %s\n", markdownIndent(strings.TrimSpace(item), "  "))
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+	return buf.String()
+}
+
+func (m *Markdown) OrderedList(items []string) string {
+	buf := bytes.NewBufferString("\n\n")
+	for i, item := range items {
+		_, _ = fmt.Fprintf(buf, "%d. %s\n", i+1, markdownIndent(strings.TrimSpace(item), "   "))
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+	return buf.String()
+}
+
+func (m *Markdown) VariableList(items []VariableListItem) string {
+	buf := bytes.NewBufferString("\n\n")
+	for _, item := range items {
+		if item.Term {
+			_, _ = fmt.Fprintf(buf, "- **%s**\n", markdownInline(item.Content))
+		} else {
+			_, _ = fmt.Fprintf(buf, "\n  %s\n\n", markdownIndent(strings.TrimSpace(item.Content), "  "))
+		}
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+	return buf.String()
+}
+
+func (m *Markdown) Term(content string) string {
+	return content
+}
+
+func (m *Markdown) Heading(level int, content string) string {
+	return fmt.Sprintf("\n\n%s %s\n\n", strings.Repeat("#", level), markdownInline(content))
+}
+
+func (m *Markdown) Title(content string) string {
+	return fmt.Sprintf("\n\n## %s\n\n", markdownInline(content))
+}
+
+func (m *Markdown) XRefSect(id string, title string, description string) string {
+	return fmt.Sprintf("\n\n**%s**: %s\n\n", m.RenderRef(id, markdownEscape(title)), markdownInline(description))
+}
+
+func (m *Markdown) Ref(refId string, content string) string {
+	return m.RenderRef(refId, strings.TrimSpace(content))
+}
+
+func (m *Markdown) Ulink(url string, content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return fmt.Sprintf("<%s>", url)
+	}
+	return fmt.Sprintf("[%s](%s)", content, markdownUrlEscaper.Replace(url))
+}
+
+func (m *Markdown) Anchor(id string) string {
+	return fmt.Sprintf("<a id=\"%s\"></a>", id)
+}
+
+func (m *Markdown) SimpleSection(kind string, id string, content string) string {
+	content = strings.TrimSpace(content)
+	switch kind {
+	case "":
+		return fmt.Sprintf("\n\n%s\n\n", content)
+	case "note", "warning", "attention", "important":
+		return fmt.Sprintf("\n\n> **%s:** %s\n\n", strings.Title(kind), strings.ReplaceAll(content, "\n", "\n> "))
+	default:
+		return fmt.Sprintf("\n\n**%s:** %s\n\n", strings.Title(kind), content)
+	}
+}
+
+func (m *Markdown) ParameterList(kind string, items []ParameterListItem) string {
+	buf := bytes.NewBufferString("")
+	_, _ = fmt.Fprintf(buf, "\n\n**%s:**\n\n| Name | Description |\n| --- | --- |\n", parameterListTitle(kind))
+	for _, item := range items {
+		_, _ = fmt.Fprintf(buf, "| %s | %s |\n", markdownCode(item.Name), markdownInline(item.Description))
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+	return buf.String()
+}
+
+func (m *Markdown) Image(image goxy.DocStringImage) string {
+	return fmt.Sprintf("![%s](%s)", markdownEscape(image.Description), image.Name)
+}
+
+func (m *Markdown) LineBreak() string {
+	return "\\\n"
+}
+
+func (m *Markdown) HorizontalRule() string {
+	return "\n\n---\n\n"
+}
+
+func (m *Markdown) BlockQuote(content string) string {
+	return fmt.Sprintf("\n\n> %s\n\n", strings.ReplaceAll(strings.TrimSpace(content), "\n", "\n> "))
+}
+
+func (m *Markdown) Details(summary string, content string) string {
+	if summary = markdownInline(summary); summary == "" {
+		summary = "Details"
+	}
+	return fmt.Sprintf("\n\n<details>\n<summary>%s</summary>\n\n%s\n\n</details>\n\n", summary, strings.TrimSpace(content))
+}
+
+func (m *Markdown) Strike(content string) string {
+	return fmt.Sprintf("~~%s~~", strings.TrimSpace(content))
+}
+
+// Underline falls back to HTML, Markdown has no underline of its own, like
+// Subscript and Superscript.
+func (m *Markdown) Underline(content string) string {
+	return fmt.Sprintf("<ins>%s</ins>", strings.TrimSpace(content))
+}
+
+func (m *Markdown) Subscript(content string) string {
+	return fmt.Sprintf("<sub>%s</sub>", strings.TrimSpace(content))
+}
+
+func (m *Markdown) Superscript(content string) string {
+	return fmt.Sprintf("<sup>%s</sup>", strings.TrimSpace(content))
+}
+
+// Formula renders a formula as GFM math.
+func (m *Markdown) Formula(formula string) string {
+	tex, block := FormulaTeX(formula)
+	if block {
+		return fmt.Sprintf("\n\n$$\n%s\n$$\n\n", tex)
+	}
+	return fmt.Sprintf("$%s$", tex)
+}
+
+func (m *Markdown) Diagram(diagram goxy.DocStringDiagram) string {
+	caption := ""
+	if diagram.Caption != "" {
+		caption = fmt.Sprintf("*%s*\n\n", markdownEscape(diagram.Caption))
+	}
+	if diagram.File != "" {
+		return fmt.Sprintf("\n\n%s%s\n\n", caption, markdownCode(diagram.File))
+	}
+	return markdownCodeBlock(diagram.Language, diagram.Content) + caption
+}
+
+func parameterListTitle(kind string) string {
+	switch kind {
+	case "param":
+		return "Parameters"
+	case "retval":
+		return "Return values"
+	case "exception":
+		return "Exceptions"
+	case "templateparam":
+		return "Template parameters"
+	default:
+		return strings.Title(kind)
+	}
+}
+
+// Table renders a table in the GitHub flavor. Markdown tables need a header
+// row, so the first row is used as one even if doxygen didn't mark it.
+func (m *Markdown) Table(rows [][]TableCell) string {
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	buf := bytes.NewBufferString("\n\n")
+	for i, row := range rows {
+		cells := make([]string, columns)
+		for j, cell := range row {
+			cells[j] = markdownInline(cell.Content)
+		}
+		_, _ = fmt.Fprintf(buf, "| %s |\n", strings.Join(cells, " | "))
+		if i == 0 {
+			_, _ = fmt.Fprintf(buf, "|%s\n", strings.Repeat(" --- |", columns))
+		}
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+	return buf.String()
+}
+
+// renderMember writes the heading, declaration and descriptions shared by every
+// kind of member.
+func (m *Markdown) renderMember(buf *bytes.Buffer, id string, name string, decl string, descriptions goxy.Descriptions) {
+	_, _ = fmt.Fprintf(buf, "<a id=\"%s\"></a>\n### %s\n", id, markdownEscape(name))
+	_, _ = fmt.Fprint(buf, markdownCodeBlock("C++", decl))
+	_, _ = fmt.Fprint(buf, m.RenderDocstring(descriptions.BriefDescription))
+	_, _ = fmt.Fprint(buf, m.RenderDocstring(descriptions.DetailedDescription))
+	_, _ = fmt.Fprint(buf, "\n\n")
+}
+
+func (m *Markdown) RenderSection(section *goxy.SectionDoc) string {
+	buf := bytes.NewBufferString("\n\n")
+
+	if section.Id != "" {
+		_, _ = fmt.Fprintf(buf, "<a id=\"%s\"></a>\n", section.Id)
+	}
+	if section.Header != "" {
+		_, _ = fmt.Fprintf(buf, "## %s\n\n", markdownEscape(section.Header))
+	}
+	_, _ = fmt.Fprint(buf, m.RenderDocstring(section.Description))
+
+	for _, enum := range section.Enums {
+		m.renderMember(buf, enum.Id, EnumName(enum), EnumSignature(enum), enum.Descriptions)
+		_, _ = fmt.Fprint(buf, "| Enumerator | Description |\n| --- | --- |\n")
+		for _, value := range enum.Values {
+			description := markdownInline(m.RenderDocstring(value.BriefDescription) + "\n\n" + m.RenderDocstring(value.DetailedDescription))
+			_, _ = fmt.Fprintf(buf, "| <a id=\"%s\"></a>%s | %s |\n", value.Id, markdownCode(strings.TrimSpace(value.Name+" "+value.Initializer)), description)
+		}
+		_, _ = fmt.Fprint(buf, "\n")
+	}
+
+	for _, function := range section.Functions {
+		m.renderMember(buf, function.Id, function.Name+"()", FunctionSignature(function), function.Descriptions)
+		if function.Reimplements.RefId != "" {
+			_, _ = fmt.Fprintf(buf, "Reimplemented from %s.\n\n", m.renderReimplementation(function.Reimplements))
+		}
+		if len(function.ReimplementedBy) > 0 {
+			links := make([]string, len(function.ReimplementedBy))
+			for i, r := range function.ReimplementedBy {
+				links[i] = m.renderReimplementation(r)
+			}
+			_, _ = fmt.Fprintf(buf, "Reimplemented by %s.\n\n", strings.Join(links, ", "))
+		}
+	}
+
+	for _, attribute := range section.Attributes {
+		m.renderMember(buf, attribute.Id, attribute.Name, AttributeSignature(attribute), attribute.Descriptions)
+	}
+
+	for _, define := range section.Defines {
+		m.renderMember(buf, define.Id, define.Name, DefineSignature(define), define.Descriptions)
+	}
+
+	for _, typedef := range section.Typedefs {
+		m.renderMember(buf, typedef.Id, typedef.Name, TypedefSignature(typedef), typedef.Descriptions)
+	}
+
+	for _, friend := range section.Friends {
+		m.renderMember(buf, friend.Id, friend.Name, FriendSignature(friend), friend.Descriptions)
+	}
+
+	return buf.String()
+}
+
+// renderReimplementation links the class owning a reimplemented function.
+func (m *Markdown) renderReimplementation(r goxy.Reimplements) string {
+	ref, ok := m.LookupRef(r.RefId)
+	if !ok {
+		return "an unknown type"
+	}
+	pRef, ok := m.LookupParent(ref)
+	if !ok {
+		return "an unknown type"
+	}
+	return m.RenderRef(ref.RefId, markdownEscape(pRef.Name))
+}
+
+// renderInnerCompounds lists the compounds nested in a compound under a heading.
+func (m *Markdown) renderInnerCompounds(buf *bytes.Buffer, title string, compounds []goxy.InnerCompoundRef) {
+	if len(compounds) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(buf, "\n\n## %s\n\n", title)
+	for _, inner := range compounds {
+		line := m.RenderRef(inner.RefId, markdownEscape(inner.Value))
+		if brief := markdownInline(m.RenderDocstring(m.CompoundBrief(inner.RefId))); brief != "" {
+			line += " - " + brief
+		}
+		_, _ = fmt.Fprintf(buf, "- %s\n", line)
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+}
+
+func (m *Markdown) renderInheritanceGraph(buf *bytes.Buffer, graph goxy.Graph) {
+	if len(graph.Nodes) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprint(buf, "\n\n```mermaid\nclassDiagram\n")
+	for _, node := range graph.Nodes {
+		_, _ = fmt.Fprintf(buf, "class %s\n", mermaidEscape(node.Label))
+	}
+	for _, edge := range graph.Edges {
+		to, from := graph.ResolveId(edge.ToId), graph.ResolveId(edge.FromId)
+		if to == nil || from == nil {
+			continue
+		}
+		_, _ = fmt.Fprintf(buf, "%s <|-- %s", mermaidEscape(to.Label), mermaidEscape(from.Label))
+		if edge.EdgeLabel != "" {
+			_, _ = fmt.Fprintf(buf, " : %s", mermaidEscape(edge.EdgeLabel))
+		}
+		_, _ = fmt.Fprint(buf, "\n")
+	}
+	_, _ = fmt.Fprint(buf, "```\n\n")
+}
+
+func (m *Markdown) renderIncludes(buf *bytes.Buffer, title string, includes []goxy.IncludeRef, graph goxy.Graph) {
+	if len(includes) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(buf, "\n\n## %s\n\n", title)
+	for _, include := range includes {
+		line := markdownCode(IncludeName(include))
+		// Files outside of the doc set are left unlinked, without the
+		// error of RenderRef.
+		if include.RefId != "" {
+			if href, ok := m.LookupHref(include.RefId); ok {
+				line = fmt.Sprintf("[%s](%s)", line, href)
+			}
+		}
+		_, _ = fmt.Fprintf(buf, "- %s\n", line)
+	}
+	if len(graph.Nodes) > 1 {
+		// The pages of the files aren't linked, viewers of markdown don't
+		// follow links in diagrams.
+		_, _ = fmt.Fprintf(buf, "\n```mermaid\n%s```\n", mermaidIncludeGraph(graph, func(refId string) (string, bool) {
+			return "", false
+		}))
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+}
+
+func (m *Markdown) RenderCompound(compound *goxy.CompoundDoc) ([]byte, error) {
+	m.startCompound(compound)
+	buf := bytes.NewBufferString("")
+
+	_, _ = fmt.Fprintf(buf, "# %s\n\n", markdownEscape(compound.Title))
+	if compound.Location.File != "" {
+		file := markdownCode(compound.Location.File)
+		if compound.Location.FileRefId != "" {
+			file = m.RenderRef(compound.Location.FileRefId, file)
+		}
+		_, _ = fmt.Fprintf(buf, "Defined in %s\n\n", file)
+	}
+
+	m.renderInheritanceGraph(buf, compound.InheritanceGraph)
+	m.renderIncludes(buf, "Includes", compound.Includes, compound.IncludeGraph)
+	m.renderIncludes(buf, "Included by", compound.IncludedBy, compound.IncludedByGraph.Reversed())
+	_, _ = fmt.Fprint(buf, m.RenderDocstring(compound.BriefDescription))
+
+	m.renderInnerCompounds(buf, "Classes", compound.InnerClasses)
+	m.renderInnerCompounds(buf, "Namespaces", compound.InnerNamespaces)
+	m.renderInnerCompounds(buf, "Groups", compound.InnerGroups)
+	m.renderInnerCompounds(buf, "Dirs", compound.InnerDirs)
+	m.renderInnerCompounds(buf, "Files", compound.InnerFiles)
+
+	if len(compound.DetailedDescription.Content) > 0 {
+		_, _ = fmt.Fprint(buf, "\n\n<a id=\"detailed_description\"></a>\n## Detailed Description\n\n")
+		_, _ = fmt.Fprint(buf, m.RenderDocstring(compound.DetailedDescription))
+	}
+
+	for _, section := range compound.Sections {
+		_, _ = fmt.Fprint(buf, m.RenderSection(section))
+	}
+
+	if len(compound.ProgramListing.Content) > 0 {
+		_, _ = fmt.Fprint(buf, "\n\n## Source\n")
+		_, _ = fmt.Fprint(buf, markdownCodeBlock("C++", goxy.CodeText(compound.ProgramListing)))
+	}
+
+	return []byte(collapseBlankLines(buf.String()) + "\n"), nil
+}
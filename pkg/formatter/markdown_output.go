@@ -0,0 +1,84 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/goxy"
+	"bytes"
+	"fmt"
+	"path/filepath"
+)
+
+// MarkdownOutput writes the doc sets as Markdown pages, with an index.md per
+// doc set and one linking the doc sets at the root of the output.
+type MarkdownOutput struct {
+	Options
+	DocSets []*DocSet
+}
+
+func NewMarkdownOutput(opts Options, sets []*DocSet) *MarkdownOutput {
+	return &MarkdownOutput{
+		Options: opts,
+		DocSets: sets,
+	}
+}
+
+func (o *MarkdownOutput) Extension() string {
+	return ".md"
+}
+
+func (o *MarkdownOutput) Renderer(set *DocSet) Renderer {
+	m := NewMarkdownFormatter(set.Section, set.Entities, set.Refs)
+	m.Diagnostics = o.Diagnostics
+	return m
+}
+
+func (o *MarkdownOutput) WriteCompound(set *DocSet, compound *goxy.CompoundDoc, path string) error {
+	return writeCompound(o.Renderer(set), compound, path)
+}
+
+// WriteIndex writes the index of every doc set, listing its compounds by kind,
+// and the index of the output linking to them.
+func (o *MarkdownOutput) WriteIndex() error {
+	root := bytes.NewBufferString("")
+	_, _ = fmt.Fprintf(root, "# %s\n\n", markdownEscape(o.Title))
+
+	for _, set := range o.DocSets {
+		m := NewMarkdownFormatter(set.Section, set.Entities, set.Refs)
+		m.Diagnostics = o.Diagnostics
+		buf := bytes.NewBufferString("")
+		_, _ = fmt.Fprintf(buf, "# %s\n", markdownEscape(set.Title))
+		for _, index := range KindIndexes(set.Compounds) {
+			_, _ = fmt.Fprintf(buf, "\n## %s\n\n", index.Title)
+			for _, compound := range index.Compounds {
+				_, _ = fmt.Fprintf(buf, "- [%s](%s/%s.md)", markdownEscape(compound.Title), compound.Kind, compound.Id)
+				if brief := markdownInline(m.RenderDocstring(compound.BriefDescription)); brief != "" {
+					_, _ = fmt.Fprintf(buf, ": %s", brief)
+				}
+				_, _ = fmt.Fprint(buf, "\n")
+			}
+		}
+
+		_, err := WriteFileIfChanged(filepath.Join(set.Output, "index.md"), buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(o.Dir, set.Output)
+		if err != nil {
+			rel = set.Section
+		}
+		_, _ = fmt.Fprintf(root, "- [%s](%s/index.md)\n", markdownEscape(set.Title), filepath.ToSlash(rel))
+	}
+
+	_, err := WriteFileIfChanged(filepath.Join(o.Dir, "index.md"), root.Bytes())
+	return err
+}
+
+// WriteMenu does nothing, the index pages are the navigation.
+func (o *MarkdownOutput) WriteMenu() error {
+	return nil
+}
+
+// WriteData does nothing, the pages don't load anything at runtime.
+func (o *MarkdownOutput) WriteData() error {
+	return nil
+}
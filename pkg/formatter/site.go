@@ -0,0 +1,499 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/formatter/templates"
+	"ScriptExecServer/pkg/goxy"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/pkg/errors"
+	gohtml "html"
+	"path/filepath"
+	"strings"
+)
+
+// Site writes the doc sets as a standalone HTML site that can be browsed
+// without Hugo, even from the file system. Every doc set is written to a folder
+// named after its section, with the pages of the compounds in <kind>/<id>.html
+// next to an index.html per kind. All links are relative.
+type Site struct {
+	Options
+	DocSets []*DocSet
+
+	// kinds are the kind indexes of the doc sets, by name.
+	kinds map[string][]KindIndex
+}
+
+type SitePageModel struct {
+	SiteTitle string
+	Title     string
+	// Root is the relative path from the page to the root of the site.
+	Root    string
+	Section string
+	Kind    goxy.Kind
+	DocSets []*DocSet
+	DocSet  *DocSet
+	// Kinds are the kind indexes of DocSet, listed in the sidebar.
+	Kinds []KindIndex
+	Body  string
+}
+
+type siteDocSetModel struct {
+	Set   *DocSet
+	Kinds []KindIndex
+}
+
+// SiteSearchEntry is an entry of the search index, short keys keep the index
+// small.
+type SiteSearchEntry struct {
+	Name   string `json:"n"`
+	Kind   string `json:"k"`
+	Url    string `json:"u"`
+	Parent string `json:"p,omitempty"`
+}
+
+func NewSite(opts Options, sets []*DocSet) *Site {
+	kinds := make(map[string][]KindIndex, len(sets))
+	for _, set := range sets {
+		kinds[set.Name] = KindIndexes(set.Compounds)
+	}
+
+	return &Site{
+		Options: opts,
+		DocSets: sets,
+		kinds:   kinds,
+	}
+}
+
+func (s *Site) Extension() string {
+	return ".html"
+}
+
+// renderPage wraps the body of a page in the layout with the navigation.
+func (s *Site) renderPage(model SitePageModel) ([]byte, error) {
+	model.SiteTitle = s.Title
+	model.DocSets = s.DocSets
+	if model.DocSet != nil {
+		model.Kinds = s.kinds[model.DocSet.Name]
+	}
+
+	content, err := executeTemplate("page", templates.SitePage, model)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.ReplaceAll(content, "££@$$", "{{")), nil
+}
+
+// Renderer returns the renderer of the compound pages of a doc set.
+func (s *Site) Renderer(set *DocSet) Renderer {
+	return s.Pages(set)
+}
+
+// Pages returns the renderer of the compound pages of a doc set.
+func (s *Site) Pages(set *DocSet) *SitePages {
+	h := NewHugoFormatter(set.Section, set.Entities, set.Refs)
+	h.Diagnostics = s.Diagnostics
+	h.pageHref = func(kind string, refId string) string {
+		return fmt.Sprintf("../%s/%s.html", kind, refId)
+	}
+
+	return &SitePages{
+		Site: s,
+		Set:  set,
+		H:    h,
+	}
+}
+
+func (s *Site) WriteCompound(set *DocSet, compound *goxy.CompoundDoc, path string) error {
+	return writeCompound(s.Pages(set), compound, path)
+}
+
+// WriteIndex writes the index pages of the kinds and the doc sets, and the
+// index page of the site.
+func (s *Site) WriteIndex() error {
+	models := make([]siteDocSetModel, len(s.DocSets))
+	for i, set := range s.DocSets {
+		models[i] = siteDocSetModel{
+			Set:   set,
+			Kinds: s.kinds[set.Name],
+		}
+
+		pages := s.Pages(set)
+		for _, kind := range models[i].Kinds {
+			content, err := pages.RenderKindIndex(kind)
+			if err != nil {
+				return err
+			}
+			_, err = WriteFileIfChanged(filepath.Join(set.Output, string(kind.Kind), "index.html"), content)
+			if err != nil {
+				return err
+			}
+		}
+
+		body, err := executeTemplate("docset", templates.SiteDocSetIndex, models[i])
+		if err != nil {
+			return err
+		}
+		content, err := s.renderPage(SitePageModel{
+			Title:   set.Title,
+			Root:    "../",
+			Section: set.Section,
+			DocSet:  set,
+			Body:    body,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = WriteFileIfChanged(filepath.Join(set.Output, "index.html"), content)
+		if err != nil {
+			return err
+		}
+	}
+
+	body, err := executeTemplate("index", templates.SiteIndex, map[string]interface{}{
+		"Title":   s.Title,
+		"DocSets": models,
+	})
+	if err != nil {
+		return err
+	}
+	content, err := s.renderPage(SitePageModel{
+		Body: body,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = WriteFileIfChanged(filepath.Join(s.Dir, "index.html"), content)
+	return err
+}
+
+// WriteMenu does nothing, the pages carry the navigation themselves.
+func (s *Site) WriteMenu() error {
+	return nil
+}
+
+// WriteData writes the search index and the assets of the site.
+func (s *Site) WriteData() error {
+	search := make([]SiteSearchEntry, 0)
+	for _, set := range s.DocSets {
+		for _, compound := range set.Compounds {
+			search = append(search, siteSearchEntries(set, compound)...)
+		}
+	}
+
+	data, err := json.Marshal(search)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// The index is a script rather than JSON, browsers don't let pages opened
+	// from the file system fetch other files.
+	_, err = WriteFileIfChanged(filepath.Join(s.Dir, "search-index.js"), []byte(fmt.Sprintf("window.goxygenSearchIndex = %s;\n", data)))
+	if err != nil {
+		return err
+	}
+
+	return s.writeAssets(s.Dir)
+}
+
+func (s *Site) writeAssets(dir string) error {
+	_, err := WriteFileIfChanged(filepath.Join(dir, "assets", "site.css"), []byte(templates.SiteCSS))
+	if err != nil {
+		return err
+	}
+	_, err = WriteFileIfChanged(filepath.Join(dir, "assets", "search.js"), []byte(templates.SiteSearch))
+	if err != nil {
+		return err
+	}
+
+	style := styles.Get("swapoff")
+	if style == nil {
+		style = styles.Fallback
+	}
+	buf := bytes.NewBufferString("")
+	err = html.New(html.WithClasses(true)).WriteCSS(buf, style)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = WriteFileIfChanged(filepath.Join(dir, "assets", "highlight.css"), buf.Bytes())
+	return err
+}
+
+// siteSearchEntries indexes a compound and its members.
+func siteSearchEntries(set *DocSet, compound *goxy.CompoundDoc) []SiteSearchEntry {
+	url := fmt.Sprintf("%s/%s/%s.html", set.Section, compound.Kind, compound.Id)
+	entries := []SiteSearchEntry{
+		{
+			Name: compound.Title,
+			Kind: string(compound.Kind),
+			Url:  url,
+		},
+	}
+
+	member := func(name string, kind string, id string) {
+		entries = append(entries, SiteSearchEntry{
+			Name:   name,
+			Kind:   kind,
+			Url:    url + "#" + id,
+			Parent: compound.Title,
+		})
+	}
+	for _, section := range compound.Sections {
+		for _, e := range section.Enums {
+			if !strings.HasPrefix(e.Name, "@") {
+				member(e.Name, "enum", e.Id)
+			}
+		}
+		for _, f := range section.Functions {
+			member(f.Name, "function", f.Id)
+		}
+		for _, a := range section.Attributes {
+			member(a.Name, "variable", a.Id)
+		}
+		for _, d := range section.Defines {
+			member(d.Name, "define", d.Id)
+		}
+		for _, t := range section.Typedefs {
+			member(t.Name, "typedef", t.Id)
+		}
+	}
+	return entries
+}
+
+// SitePages renders the compound pages of one doc set of a Site, using the Hugo
+// formatter for their content.
+type SitePages struct {
+	Site *Site
+	Set  *DocSet
+	H    *Hugo
+}
+
+type SiteCompoundModel struct {
+	H        *Hugo
+	Compound *goxy.CompoundDoc
+	FileHref string
+	Diagram  string
+	DirTree  string
+
+	IncludeDiagram    string
+	IncludedByDiagram string
+}
+
+func (p *SitePages) StartTracking() {
+	p.H.StartTracking()
+}
+
+func (p *SitePages) StopTracking() []string {
+	return p.H.StopTracking()
+}
+
+func (p *SitePages) RenderCompound(compound *goxy.CompoundDoc) ([]byte, error) {
+	p.H.startCompound(compound)
+	model := SiteCompoundModel{
+		H:        p.H,
+		Compound: compound,
+		Diagram:  p.RenderInheritanceDiagram(compound),
+	}
+	if compound.Location.FileRefId != "" {
+		model.FileHref = p.H.PageHref(string(goxy.File), compound.Location.FileRefId)
+	}
+	if compound.Kind == goxy.Dir {
+		model.DirTree = p.RenderDirTree(compound)
+	}
+	model.IncludeDiagram, model.IncludedByDiagram = p.RenderIncludeDiagrams(compound)
+
+	body, err := executeTemplate("compound", templates.SiteCompound, model)
+	if err != nil {
+		return nil, err
+	}
+	return p.Site.renderPage(SitePageModel{
+		Title:   compound.Title,
+		Root:    "../../",
+		Section: p.Set.Section,
+		Kind:    compound.Kind,
+		DocSet:  p.Set,
+		Body:    body,
+	})
+}
+
+type siteIndexEntry struct {
+	Name  string
+	Href  string
+	Brief string
+}
+
+func (p *SitePages) RenderKindIndex(kind KindIndex) ([]byte, error) {
+	entries := make([]siteIndexEntry, len(kind.Compounds))
+	for i, compound := range kind.Compounds {
+		entries[i] = siteIndexEntry{
+			Name:  compound.Title,
+			Href:  compound.Id + ".html",
+			Brief: p.H.RenderDocstring(compound.BriefDescription),
+		}
+	}
+
+	body, err := executeTemplate("kind", templates.SiteKindIndex, map[string]interface{}{
+		"Title":   kind.Title,
+		"Entries": entries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.Site.renderPage(SitePageModel{
+		Title:   kind.Title,
+		Root:    "../../",
+		Section: p.Set.Section,
+		Kind:    kind.Kind,
+		DocSet:  p.Set,
+		Body:    body,
+	})
+}
+
+// RenderDirTree lists the dirs and files below a dir as nested lists.
+func (p *SitePages) RenderDirTree(compound *goxy.CompoundDoc) string {
+	buf := bytes.NewBufferString("<ul class=\"site-tree\">")
+	for _, dir := range compound.InnerDirs {
+		_, _ = fmt.Fprintf(buf, "<li><a href=\"%s\">%s/</a>", p.H.PageHref(string(goxy.Dir), dir.RefId), gohtml.EscapeString(p.H.CompoundTitle(dir.RefId)))
+		if c, ok := p.Set.Entities[dir.RefId]; ok {
+			_, _ = fmt.Fprint(buf, p.RenderDirTree(c))
+		}
+		_, _ = fmt.Fprint(buf, "</li>")
+	}
+	for _, file := range compound.InnerFiles {
+		_, _ = fmt.Fprintf(buf, "<li><a href=\"%s\">%s</a></li>", p.H.PageHref(string(goxy.File), file.RefId), gohtml.EscapeString(filepath.Base(p.H.CompoundTitle(file.RefId))))
+	}
+	_, _ = fmt.Fprint(buf, "</ul>")
+	return buf.String()
+}
+
+// RenderInheritanceDiagram draws the inheritance graph of a compound as an SVG,
+// with the base classes on top, so the site doesn't need a diagram library.
+func (p *SitePages) RenderInheritanceDiagram(compound *goxy.CompoundDoc) string {
+	if len(compound.InheritanceGraph.Nodes) == 0 {
+		return ""
+	}
+	return p.renderGraphDiagram(compound.InheritanceGraph, compound.Id)
+}
+
+// RenderIncludeDiagrams draws the graph of the files a file includes and the
+// one of the files including it, with the including files on top. Graphs of a
+// single file are left out.
+func (p *SitePages) RenderIncludeDiagrams(compound *goxy.CompoundDoc) (string, string) {
+	includes, includedBy := "", ""
+	if len(compound.IncludeGraph.Nodes) > 1 {
+		includes = p.renderGraphDiagram(compound.IncludeGraph.Reversed(), compound.Id)
+	}
+	if len(compound.IncludedByGraph.Nodes) > 1 {
+		includedBy = p.renderGraphDiagram(compound.IncludedByGraph, compound.Id)
+	}
+	return includes, includedBy
+}
+
+// renderGraphDiagram draws a graph as an SVG, with the nodes the edges point
+// to above the ones they point from. The node of currentId is highlighted and
+// the other nodes link to their pages.
+func (p *SitePages) renderGraphDiagram(g goxy.Graph, currentId string) string {
+	const (
+		boxHeight = 28
+		hGap      = 20
+		vGap      = 40
+		margin    = 10
+	)
+
+	// The row of a node is the length of the longest path to a base class,
+	// edges point from a class to its base.
+	bases := make(map[int][]int)
+	for _, edge := range g.Edges {
+		bases[edge.FromId] = append(bases[edge.FromId], edge.ToId)
+	}
+	rows := make(map[int]int)
+	var rowOf func(id int, visiting map[int]bool) int
+	rowOf = func(id int, visiting map[int]bool) int {
+		if row, ok := rows[id]; ok {
+			return row
+		}
+		if visiting[id] {
+			return 0
+		}
+		visiting[id] = true
+		row := 0
+		for _, base := range bases[id] {
+			if r := rowOf(base, visiting) + 1; r > row {
+				row = r
+			}
+		}
+		rows[id] = row
+		return row
+	}
+
+	type box struct {
+		node        goxy.GraphNode
+		x, y, width int
+	}
+	layout := make([][]*box, 0)
+	for _, node := range g.Nodes {
+		row := rowOf(node.Id, make(map[int]bool))
+		for len(layout) <= row {
+			layout = append(layout, make([]*box, 0))
+		}
+		width := len(node.Label)*7 + 20
+		if width < 80 {
+			width = 80
+		}
+		layout[row] = append(layout[row], &box{node: node, width: width})
+	}
+
+	totalWidth := 0
+	for _, row := range layout {
+		rowWidth := -hGap
+		for _, b := range row {
+			rowWidth += b.width + hGap
+		}
+		if rowWidth > totalWidth {
+			totalWidth = rowWidth
+		}
+	}
+
+	boxes := make(map[int]*box)
+	for i, row := range layout {
+		rowWidth := -hGap
+		for _, b := range row {
+			rowWidth += b.width + hGap
+		}
+		x := margin + (totalWidth-rowWidth)/2
+		for _, b := range row {
+			b.x = x
+			b.y = margin + i*(boxHeight+vGap)
+			x += b.width + hGap
+			boxes[b.node.Id] = b
+		}
+	}
+
+	width := totalWidth + 2*margin
+	height := len(layout)*(boxHeight+vGap) - vGap + 2*margin
+	buf := bytes.NewBufferString("")
+	_, _ = fmt.Fprintf(buf, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">", width, height, width, height)
+	for _, edge := range g.Edges {
+		from, to := boxes[edge.FromId], boxes[edge.ToId]
+		if from == nil || to == nil {
+			continue
+		}
+		_, _ = fmt.Fprintf(buf, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" />", from.x+from.width/2, from.y, to.x+to.width/2, to.y+boxHeight)
+	}
+	for _, node := range g.Nodes {
+		b := boxes[node.Id]
+		label := gohtml.EscapeString(node.Label)
+		shape := fmt.Sprintf("<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"3\" /><text x=\"%d\" y=\"%d\" text-anchor=\"middle\">%s</text>", b.x, b.y, b.width, boxHeight, b.x+b.width/2, b.y+boxHeight/2+4, label)
+		switch {
+		case node.RefId == currentId:
+			_, _ = fmt.Fprintf(buf, "<g class=\"current\">%s</g>", shape)
+		case node.RefId != "":
+			_, _ = fmt.Fprintf(buf, "<a href=\"%s\"><title>See documentation for %s</title>%s</a>", p.H.HrefForRefId(node.RefId), label, shape)
+		default:
+			_, _ = fmt.Fprintf(buf, "<g>%s</g>", shape)
+		}
+	}
+	_, _ = fmt.Fprint(buf, "</svg>")
+	return buf.String()
+}
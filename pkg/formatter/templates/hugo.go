@@ -59,16 +59,16 @@ goxygen:
 
 GeekdocSearchKeywords:
   {{- range .Compound.InnerClasses }}
-  - "{{ (index $.H.CompoundIdMap .RefId).Title }}"
+  - "{{ $.H.CompoundTitle .RefId }}"
   {{- end }}
   {{- range .Compound.InnerGroups }}
-  - "{{ (index $.H.CompoundIdMap .RefId).Title }}"
+  - "{{ $.H.CompoundTitle .RefId }}"
   {{- end }}
   {{- range .Compound.InnerFiles }}
-  - "{{ (index $.H.CompoundIdMap .RefId).Title }}"
+  - "{{ $.H.CompoundTitle .RefId }}"
   {{- end }}
   {{- range .Compound.InnerDirs }}
-  - "{{ (index $.H.CompoundIdMap .RefId).Title }}"
+  - "{{ $.H.CompoundTitle .RefId }}"
   {{- end }}
   {{- range .Compound.Sections }}
   - "{{ .Header }}"
@@ -140,6 +140,8 @@ link {{ $.H.MermaidEscape .Label }} "{{ $.H.HrefForRefId .RefId }}" "See documen
 </script>
 {{ end }}
 
+{{ $.H.RenderIncludes .Compound }}
+
 {{ if .Compound.BriefDescription }}
 {{ $.H.RenderDocstring .Compound.BriefDescription }}
 {{ end }}
@@ -211,19 +213,56 @@ link {{ $.H.MermaidEscape .Label }} "{{ $.H.HrefForRefId .RefId }}" "See documen
 {{ end }}
 
 {{ if .Compound.ProgramListing.Content }}
-{{ $.H.RenderHighlightWithLineNos "C++" ($.H.RenderDocstring .Compound.ProgramListing) }}
+{{ $.H.Highlight "C++" .Compound.ProgramListing }}
 {{ end }}`
 
+const Includes = `{{ if .Includes }}
+<h2>{{ .Title }}:</h2>
+<ul class="compound-includes">
+{{- range .Includes }}
+	<li><code>{{ $.H.RenderInclude . }}</code></li>
+{{- end }}
+</ul>
+{{ end }}
+
+{{ with .Graph }}
+<div class="gdoc-expand">
+  <label class="gdoc-expand__head flex justify-between" for="compound-{{ $.Id }}-graph">
+    <span>{{ $.GraphTitle }}</span>
+    <span>↕</span>
+  </label>
+  <input id="compound-{{ $.Id }}-graph" type="checkbox" class="gdoc-expand__control hidden" />
+  <div class="gdoc-markdown--nested gdoc-expand__content">
+  	<div id="compound-{{ $.Id }}-graph-container"></div>
+  </div>
+</div>
+
+<script type="application/javascript">
+ document.addEventListener('DOMContentLoaded', function() {
+   const graphDefinition = ` + "`" + `{{ . }}` + "`" + `;
+
+  mermaid.mermaidAPI.initialize({
+    startOnLoad:false
+  });
+  var graph = mermaid.mermaidAPI.render('{{ $.Id }}GraphPrerenderDiv', graphDefinition, function (svgCode) {
+    const element = document.querySelector("#compound-{{ $.Id }}-graph-container");
+    element.innerHTML = svgCode;
+  });
+ });
+</script>
+{{ end }}
+`
+
 const InnerCompound = `<div class="inner-compound-briefs__item">
 	<div class="inner-compound-briefs__item__kind">
-		{{ (index $.H.CompoundRefs .RefId).Kind }}
+		{{ $.H.CompoundKind .RefId }}
 	</div>
 	<div class="inner-compound-briefs__item__description">
 		<div class="inner-compound-briefs__item__description__name">
 			{{ $.H.RenderRef .RefId .Value}}
 		</div>
 		<div class="inner-compound-briefs__item__description__brief">
-			{{ $.H.RenderDocstring (index $.H.CompoundIdMap .RefId).BriefDescription }}
+			{{ $.H.RenderDocstring ($.H.CompoundBrief .RefId) }}
 		</div>
 	</div>
 </div>
@@ -247,11 +286,7 @@ const SectionBrief = `<div class="compound-section">
         </div>
         <div class="section-briefs__item__description">
             <div class="section-briefs__item__description__name">
-                {{ $name := .Name}}
-                {{ if HasPrefix $name "@" }}
-                {{ $name = "_Anonymous_" }}
-                {{ end }}
-				{{ $.H.RenderHighlight "C++" (printf "<a href=\"#%s\">%s</a> %s" .Id $name ($.H.RenderEnumBody .Values)) }}
+				{{ $.H.RenderBriefEnumDecl . }}
             </div>
             <div class="section-briefs__item__description__brief">
 				{{ $.H.RenderDocstring .BriefDescription }}
@@ -267,11 +302,11 @@ const SectionBrief = `<div class="compound-section">
 {{ range . }}
     <div class="section-briefs__item">
         <div class="section-briefs__item__kind">
-            {{ $.H.RenderHighlight "C++" ($.H.RenderDocstring .Type) }}
+            {{ $.H.Highlight "C++" .Type }}
         </div>
         <div class="section-briefs__item__description">
             <div class="section-briefs__item__description__name">
-            	{{ $.H.RenderHighlight "C++" ($.H.RenderBriefFunctionDecl .) }}
+            	{{ $.H.RenderBriefFunctionDecl . }}
             </div>
             <div class="section-briefs__item__description__brief">
 				{{ $.H.RenderDocstring .BriefDescription }}
@@ -287,11 +322,11 @@ const SectionBrief = `<div class="compound-section">
 {{ range . }}
     <div class="section-briefs__item">
         <div class="section-briefs__item__kind">
-            {{ $.H.RenderHighlight "C++" ($.H.RenderDocstring .Type) }}
+            {{ $.H.Highlight "C++" .Type }}
         </div>
         <div class="section-briefs__item__description">
             <div class="section-briefs__item__description__name">
-            	{{ $.H.RenderHighlight "C++" (printf "<a href=\"#%s\">%s</a> %s" .Id .Name ($.H.RenderDocstring .ArgsString)) }}
+            	{{ $.H.RenderBriefAttributeDecl . }}
             </div>
             <div class="section-briefs__item__description__brief">
 				{{ $.H.RenderDocstring .BriefDescription }}
@@ -311,7 +346,7 @@ const SectionBrief = `<div class="compound-section">
         </div>
         <div class="section-briefs__item__description">
             <div class="section-briefs__item__description__name">
-            	{{ $.H.RenderHighlight "C++" ($.H.RenderBriefDefineDecl .) }}
+            	{{ $.H.RenderBriefDefineDecl . }}
             </div>
             <div class="section-briefs__item__description__brief">
 				{{ $.H.RenderDocstring .BriefDescription }}
@@ -327,11 +362,11 @@ const SectionBrief = `<div class="compound-section">
 {{ range . }}
     <div class="section-briefs__item">
         <div class="section-briefs__item__kind">
-            {{ $.H.RenderHighlight "C++" ($.H.RenderDocstring .Type) }}
+            {{ $.H.Highlight "C++" .Type }}
         </div>
         <div class="section-briefs__item__description">
             <div class="section-briefs__item__description__name">
-            	{{ $.H.RenderHighlight "C++" (printf "%s %s" .Name ($.H.RenderDocstring .ArgsString)) }}
+            	{{ $.H.RenderBriefTypedefDecl . }}
             </div>
             <div class="section-briefs__item__description__brief">
 				{{ $.H.RenderDocstring .BriefDescription }}
@@ -347,11 +382,11 @@ const SectionBrief = `<div class="compound-section">
 {{ range . }}
     <div class="section-briefs__item">
         <div class="section-briefs__item__kind">
-            {{ $.H.RenderHighlight "C++" ($.H.RenderDocstring .Type) }}
+            {{ $.H.Highlight "C++" .Type }}
         </div>
         <div class="section-briefs__item__description">
             <div class="section-briefs__item__description__name">
-			{{ $.H.RenderHighlight "C++" (printf "<a href=\"#%s\">%s</a>" .Id .Name) }}
+			{{ $.H.RenderBriefFriendDecl . }}
             </div>
             <div class="section-briefs__item__description__brief">
 				{{ $.H.RenderDocstring .BriefDescription }}
@@ -399,7 +434,7 @@ const Section = `<div class="compound-section">
 {{ with .Section.Functions }}
 {{ range . }}
 	<a class="anchor" id="{{ .Id }}"></a>
-	{{ $.H.RenderHighlight "C++" ($.H.RenderBriefFunctionDecl .) }}
+	{{ $.H.RenderBriefFunctionDecl . }}
 	
 	<p>
 	{{ if .Reimplements.RefId }}
@@ -421,7 +456,7 @@ const Section = `<div class="compound-section">
 {{ with .Section.Attributes }}
 {{ range . }}
 	<a class="anchor" id="{{ .Id }}"></a>
-	{{ $.H.RenderHighlight "C++" (printf "%s %s %s" ($.H.RenderDocstring .Type) .Name ($.H.RenderDocstring .ArgsString)) }}
+	{{ $.H.RenderAttributeDecl . }}
 
 	{{ $.H.RenderDocstring .BriefDescription }}
 	{{ $.H.RenderDocstring .DetailedDescription }}
@@ -431,7 +466,7 @@ const Section = `<div class="compound-section">
 {{ with .Section.Defines }}
 {{ range . }}
 	<a class="anchor" id="{{ .Id }}"></a>
-	{{ $.H.RenderHighlight "C++" ($.H.RenderBriefDefineDecl .) }}
+	{{ $.H.RenderBriefDefineDecl . }}
 
 	{{ $.H.RenderDocstring .BriefDescription }}
 	{{ $.H.RenderDocstring .DetailedDescription }}
@@ -441,7 +476,7 @@ const Section = `<div class="compound-section">
 {{ with .Section.Typedefs }}
 {{ range . }}
 	<a class="anchor" id="{{ .Id }}"></a>
-	{{ $.H.RenderHighlight "C++" (printf "typedef %s %s %s" ($.H.RenderDocstring .Type) .Name ($.H.RenderDocstring .ArgsString)) }}
+	{{ $.H.RenderTypedefDecl . }}
 
 	{{ $.H.RenderDocstring .BriefDescription }}
 	{{ $.H.RenderDocstring .DetailedDescription }}
@@ -458,10 +493,10 @@ const DocstringSection = `<div class="docstring-section">
 	{{ .Kind }}:
 </div>
 <div class="docstring-section__content">
-	{{ $.H.RenderDocstring .Content}}
+	{{ .Content }}
 </div>
 {{ else }}
-{{ $.H.RenderDocstring .Content}}
+{{ .Content }}
 {{ end }}
 </div>`
 
@@ -471,7 +506,7 @@ const DocstringParameterList = `<b>Parameters:</b>
 	{{ range .Items }}
 		<tr>
 			<td>{{ .Name }}</td>
-			<td>{{ $.H.RenderDocstring .Description }}</td>
+			<td>{{ .Description }}</td>
 		</tr>
 	{{ end }}
 	</tbody>
@@ -487,7 +522,7 @@ const DocstringTable = `<table>
 			{{ else }}
 			<td> 
 			{{ end }}
-			<td>{{ $.H.RenderDocstring .Content }}</td>
+			<td>{{ .Content }}</td>
 			{{ if .Head }}
 			</th>
 			{{ else }}
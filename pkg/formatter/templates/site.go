@@ -0,0 +1,440 @@
+package templates
+
+const SitePage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>{{ with .Title }}{{ html . }} - {{ end }}{{ html .SiteTitle }}</title>
+	<link rel="stylesheet" href="{{ .Root }}assets/site.css">
+	<link rel="stylesheet" href="{{ .Root }}assets/highlight.css">
+</head>
+<body>
+<header class="site-header">
+	<a class="site-header__title" href="{{ .Root }}index.html">{{ html .SiteTitle }}</a>
+	<nav class="site-header__docsets">
+	{{- range .DocSets }}
+		<a href="{{ $.Root }}{{ .Section }}/index.html"{{ if eq .Section $.Section }} class="active"{{ end }}>{{ html .Title }}</a>
+	{{- end }}
+	</nav>
+	<div class="site-search">
+		<input id="site-search" type="search" placeholder="Search" autocomplete="off">
+		<ul id="site-search-results"></ul>
+	</div>
+</header>
+<div class="site-layout">
+{{- with .DocSet }}
+	<nav class="site-sidebar">
+		<h2><a href="{{ $.Root }}{{ .Section }}/index.html">{{ html .Title }}</a></h2>
+		<ul>
+		{{- range $.Kinds }}
+			<li><a href="{{ $.Root }}{{ $.Section }}/{{ .Kind }}/index.html"{{ if eq .Kind $.Kind }} class="active"{{ end }}>{{ .Title }}</a></li>
+		{{- end }}
+		</ul>
+	</nav>
+{{- end }}
+	<main class="site-content">
+{{ .Body }}
+	</main>
+</div>
+<script src="{{ .Root }}search-index.js"></script>
+<script src="{{ .Root }}assets/search.js" data-root="{{ .Root }}"></script>
+</body>
+</html>
+`
+
+const SiteCompound = `<h1>{{ html .Compound.Title }}</h1>
+
+{{ if .Compound.Location.File }}
+<p>
+	{{ if .FileHref }}<a href="{{ .FileHref }}">{{ html .Compound.Location.File }}</a>{{ else }}{{ html .Compound.Location.File }}{{ end }}
+</p>
+{{ end }}
+
+{{ with .Diagram }}
+<details class="site-diagram" open>
+	<summary>Inheritance diagram for {{ html $.Compound.Title }}</summary>
+	{{ . }}
+</details>
+{{ end }}
+
+{{ if .Compound.Includes }}
+<h2>Includes:</h2>
+<ul class="compound-includes">
+{{- range .Compound.Includes }}
+	<li><code>{{ $.H.RenderInclude . }}</code></li>
+{{- end }}
+</ul>
+{{ end }}
+
+{{ with .IncludeDiagram }}
+<details class="site-diagram">
+	<summary>Include dependency graph for {{ html $.Compound.Title }}</summary>
+	{{ . }}
+</details>
+{{ end }}
+
+{{ if .Compound.IncludedBy }}
+<h2>Included by:</h2>
+<ul class="compound-includes">
+{{- range .Compound.IncludedBy }}
+	<li><code>{{ $.H.RenderInclude . }}</code></li>
+{{- end }}
+</ul>
+{{ end }}
+
+{{ with .IncludedByDiagram }}
+<details class="site-diagram">
+	<summary>Files including {{ html $.Compound.Title }}</summary>
+	{{ . }}
+</details>
+{{ end }}
+
+{{ if .Compound.BriefDescription }}
+{{ $.H.RenderDocstring .Compound.BriefDescription }}
+{{ end }}
+
+<p>
+	<a href="#detailed_description">More...</a>
+</p>
+
+{{ with .DirTree }}
+<h2>Contents:</h2>
+{{ . }}
+{{ end }}
+
+{{ if (len .Compound.InnerClasses) }}
+<h2>Classes:</h2>
+<div class="inner-compound-briefs">
+{{ range $compound := .Compound.InnerClasses }}
+{{ $.H.RenderInnerCompound $compound }}
+{{ end }}
+</div>
+{{ end }}
+
+{{ if (len .Compound.InnerNamespaces) }}
+<h2>Namespaces:</h2>
+<div class="inner-compound-briefs">
+{{ range $compound := .Compound.InnerNamespaces }}
+{{ $.H.RenderInnerCompound $compound }}
+{{ end }}
+</div>
+{{ end }}
+
+{{ if (len .Compound.InnerGroups) }}
+<h2>Groups:</h2>
+<div class="inner-compound-briefs">
+{{ range $compound := .Compound.InnerGroups }}
+{{ $.H.RenderInnerCompound $compound }}
+{{ end }}
+</div>
+{{ end }}
+
+{{ if and (len .Compound.InnerFiles) (not .DirTree) }}
+<h2>Files:</h2>
+<div class="inner-compound-briefs">
+{{ range $compound := .Compound.InnerFiles }}
+{{ $.H.RenderInnerCompound $compound }}
+{{ end }}
+</div>
+{{ end }}
+
+{{ range .Compound.Sections }}
+{{ $.H.RenderSectionBrief . }}
+{{ end }}
+
+<a id="detailed_description"></a>
+<h2>Detailed Description</h2>
+{{ if .Compound.BriefDescription }}
+{{ $.H.RenderDocstring .Compound.BriefDescription }}
+{{ end }}
+{{ if .Compound.DetailedDescription }}
+{{ $.H.RenderDocstring .Compound.DetailedDescription }}
+{{ end }}
+
+{{ range .Compound.Sections }}
+{{ $.H.RenderSection . }}
+{{ end }}
+
+{{ if .Compound.ProgramListing.Content }}
+{{ $.H.Highlight "C++" .Compound.ProgramListing }}
+{{ end }}`
+
+const SiteKindIndex = `<h1>{{ html .Title }}</h1>
+
+<table class="site-index">
+	<tbody>
+	{{- range .Entries }}
+		<tr>
+			<td><a href="{{ .Href }}">{{ html .Name }}</a></td>
+			<td>{{ .Brief }}</td>
+		</tr>
+	{{- end }}
+	</tbody>
+</table>`
+
+const SiteDocSetIndex = `<h1>{{ html .Set.Title }}</h1>
+
+<ul class="site-kinds">
+{{- range .Kinds }}
+	<li><a href="{{ .Kind }}/index.html">{{ .Title }}</a> ({{ len .Compounds }})</li>
+{{- end }}
+</ul>`
+
+const SiteIndex = `<h1>{{ html .Title }}</h1>
+
+{{ range .DocSets }}
+{{- $set := .Set }}
+<h2><a href="{{ $set.Section }}/index.html">{{ html $set.Title }}</a></h2>
+<ul class="site-kinds">
+{{- range .Kinds }}
+	<li><a href="{{ $set.Section }}/{{ .Kind }}/index.html">{{ .Title }}</a> ({{ len .Compounds }})</li>
+{{- end }}
+</ul>
+{{ end }}`
+
+const SiteCSS = `:root {
+	--accent: #2f6fab;
+	--border: #d8dee4;
+	--muted: #57606a;
+}
+
+* {
+	box-sizing: border-box;
+}
+
+body {
+	margin: 0;
+	font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif;
+	line-height: 1.5;
+	color: #1f2328;
+}
+
+a {
+	color: var(--accent);
+	text-decoration: none;
+}
+
+a:hover {
+	text-decoration: underline;
+}
+
+.site-header {
+	display: flex;
+	align-items: center;
+	gap: 1.5rem;
+	padding: 0.75rem 1.5rem;
+	background: #24292f;
+}
+
+.site-header a {
+	color: #fff;
+}
+
+.site-header__title {
+	font-weight: 600;
+	font-size: 1.2rem;
+}
+
+.site-header__docsets {
+	display: flex;
+	gap: 1rem;
+	flex: 1;
+}
+
+.site-header__docsets a.active {
+	text-decoration: underline;
+}
+
+.site-search {
+	position: relative;
+}
+
+#site-search {
+	width: 18rem;
+	padding: 0.3rem 0.5rem;
+	border: 1px solid var(--border);
+	border-radius: 4px;
+}
+
+#site-search-results {
+	position: absolute;
+	right: 0;
+	z-index: 10;
+	width: 28rem;
+	max-height: 24rem;
+	margin: 0.25rem 0 0;
+	padding: 0;
+	overflow-y: auto;
+	list-style: none;
+	background: #fff;
+	border: 1px solid var(--border);
+	border-radius: 4px;
+}
+
+#site-search-results:empty {
+	display: none;
+}
+
+#site-search-results li a {
+	display: block;
+	padding: 0.3rem 0.6rem;
+	color: #1f2328;
+}
+
+#site-search-results li small {
+	color: var(--muted);
+}
+
+.site-layout {
+	display: flex;
+}
+
+.site-sidebar {
+	flex: 0 0 14rem;
+	padding: 1rem 1.5rem;
+	border-right: 1px solid var(--border);
+}
+
+.site-sidebar h2 {
+	font-size: 1rem;
+}
+
+.site-sidebar ul {
+	padding: 0;
+	list-style: none;
+}
+
+.site-sidebar a.active {
+	font-weight: 600;
+}
+
+.site-content {
+	flex: 1;
+	min-width: 0;
+	padding: 1rem 2rem 3rem;
+}
+
+.site-content pre,
+.site-content .chroma {
+	overflow-x: auto;
+}
+
+.site-index td {
+	padding: 0.25rem 1rem 0.25rem 0;
+	vertical-align: top;
+}
+
+.compound-includes {
+	padding: 0;
+	list-style: none;
+}
+
+.site-diagram svg {
+	max-width: 100%;
+	height: auto;
+}
+
+.site-diagram rect {
+	fill: #f6f8fa;
+	stroke: var(--accent);
+}
+
+.site-diagram .current rect {
+	fill: #ddf4ff;
+}
+
+.site-diagram line {
+	stroke: var(--muted);
+}
+
+.site-diagram text {
+	font-size: 12px;
+	fill: #1f2328;
+}
+
+.inner-compound-briefs__item,
+.section-briefs__item {
+	display: flex;
+	gap: 1rem;
+	padding: 0.25rem 0;
+	border-bottom: 1px solid var(--border);
+}
+
+.inner-compound-briefs__item__kind,
+.section-briefs__item__kind {
+	flex: 0 0 10rem;
+	color: var(--muted);
+	overflow-x: auto;
+}
+
+.gdoc-hint {
+	margin: 1rem 0;
+	padding: 0.5rem 1rem;
+	border-left: 4px solid #d4a72c;
+	background: #fff8c5;
+}
+
+.docstring-section__title {
+	font-weight: 600;
+	text-transform: capitalize;
+}
+
+table.goxy-parameterlist td {
+	padding: 0.2rem 1rem 0.2rem 0;
+	vertical-align: top;
+}
+`
+
+const SiteSearch = `(function () {
+	var script = document.currentScript;
+	var root = script.getAttribute("data-root");
+	var input = document.getElementById("site-search");
+	var results = document.getElementById("site-search-results");
+	var index = window.goxygenSearchIndex || [];
+
+	function render(query) {
+		results.innerHTML = "";
+		query = query.trim().toLowerCase();
+		if (query.length < 2) {
+			return;
+		}
+
+		var prefix = [];
+		var other = [];
+		for (var i = 0; i < index.length && prefix.length < 50; i++) {
+			var name = index[i].n.toLowerCase();
+			var pos = name.indexOf(query);
+			if (pos === 0) {
+				prefix.push(index[i]);
+			} else if (pos > 0 && other.length < 50) {
+				other.push(index[i]);
+			}
+		}
+
+		prefix.concat(other).slice(0, 50).forEach(function (entry) {
+			var li = document.createElement("li");
+			var a = document.createElement("a");
+			a.href = root + entry.u;
+			a.textContent = entry.n + " ";
+			var small = document.createElement("small");
+			small.textContent = entry.k + (entry.p ? " in " + entry.p : "");
+			a.appendChild(small);
+			li.appendChild(a);
+			results.appendChild(li);
+		});
+	}
+
+	input.addEventListener("input", function () {
+		render(input.value);
+	});
+	input.addEventListener("keydown", function (e) {
+		if (e.key === "Enter" && results.firstChild) {
+			window.location.href = results.firstChild.firstChild.href;
+		} else if (e.key === "Escape") {
+			input.value = "";
+			render("");
+		}
+	});
+})();
+`
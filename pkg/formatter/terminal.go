@@ -0,0 +1,431 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/goxy"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Terminal renders compounds and their members as plain text for reading in a
+// terminal, wrapped to Width columns and styled with ANSI escape codes if Color
+// is set.
+type Terminal struct {
+	*Core
+
+	Width int
+	Color bool
+}
+
+func NewTerminalFormatter(section string, idMap map[string]*goxy.CompoundDoc, refs map[string]goxy.CompoundRef, width int, color bool) *Terminal {
+	return &Terminal{
+		Core: NewCore(section, idMap, refs, func(kind string, refId string) string {
+			return fmt.Sprintf("../%s/%s.txt", kind, refId)
+		}),
+		Width: width,
+		Color: color,
+	}
+}
+
+// terminalLiteral starts the lines the final fill must keep as they are, like
+// code.
+const terminalLiteral = "\x00"
+
+var (
+	terminalEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+	terminalMarker = regexp.MustCompile(`^(\x{2022} |\d+\. )`)
+)
+
+func (t *Terminal) style(s string, on string, off string) string {
+	if !t.Color || s == "" {
+		return s
+	}
+	return "\x1b[" + on + "m" + s + "\x1b[" + off + "m"
+}
+
+func (t *Terminal) bold(s string) string {
+	return t.style(s, "1", "22")
+}
+
+func (t *Terminal) underline(s string) string {
+	return t.style(s, "4", "24")
+}
+
+func (t *Terminal) code(s string) string {
+	return t.style(s, "36", "39")
+}
+
+// literal renders text as literal lines indented by four spaces.
+func (t *Terminal) literal(s string) string {
+	lines := strings.Split(strings.Trim(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = terminalLiteral + "    " + t.code(line)
+	}
+	return "\n\n" + strings.Join(lines, "\n") + "\n\n"
+}
+
+// terminalInline joins rendered text into a single line.
+func terminalInline(s string) string {
+	s = strings.ReplaceAll(s, terminalLiteral, "")
+	return strings.TrimSpace(markdownWhitespace.ReplaceAllString(s, " "))
+}
+
+// terminalIndent indents every line but the first of s, literal lines stay
+// literal.
+func terminalIndent(s string, indent string) string {
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		switch {
+		case strings.HasPrefix(lines[i], terminalLiteral):
+			lines[i] = terminalLiteral + indent + strings.TrimPrefix(lines[i], terminalLiteral)
+		case lines[i] != "":
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// visibleLength counts the columns s takes up in a terminal.
+func visibleLength(s string) int {
+	return utf8.RuneCountInString(terminalEscape.ReplaceAllString(s, ""))
+}
+
+// fill wraps the lines of s to the width, continuing list items below their
+// text, and drops the markers of the literal lines.
+func (t *Terminal) fill(s string) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.HasPrefix(line, terminalLiteral) {
+			out = append(out, strings.TrimPrefix(line, terminalLiteral))
+			continue
+		}
+		if t.Width <= 0 || visibleLength(line) <= t.Width {
+			out = append(out, line)
+			continue
+		}
+
+		text := strings.TrimLeft(line, " ")
+		indent := line[:len(line)-len(text)]
+		hanging := indent
+		if marker := terminalMarker.FindString(text); marker != "" {
+			hanging += strings.Repeat(" ", utf8.RuneCountInString(marker))
+		}
+
+		current := indent
+		empty := true
+		for _, word := range strings.Fields(text) {
+			if !empty && visibleLength(current)+1+visibleLength(word) > t.Width {
+				out = append(out, current)
+				current = hanging
+				empty = true
+			}
+			if !empty {
+				current += " "
+			}
+			current += word
+			empty = false
+		}
+		out = append(out, current)
+	}
+	return strings.Join(out, "\n")
+}
+
+func (t *Terminal) RenderDocstring(docstring goxy.DocString) string {
+	return RenderDocString(t, docstring)
+}
+
+func (t *Terminal) Text(text string) string {
+	return markdownWhitespace.ReplaceAllString(text, " ")
+}
+
+func (t *Terminal) Paragraph(content string) string {
+	return fmt.Sprintf("\n\n%s\n\n", strings.TrimSpace(content))
+}
+
+func (t *Terminal) Emphasis(content string) string {
+	return t.underline(strings.TrimSpace(content))
+}
+
+func (t *Terminal) Bold(content string) string {
+	return t.bold(strings.TrimSpace(content))
+}
+
+func (t *Terminal) Verbatim(content goxy.DocString) string {
+	return t.literal(goxy.PlainText(content))
+}
+
+func (t *Terminal) Preformatted(content goxy.DocString) string {
+	return t.literal(goxy.PlainText(content))
+}
+
+func (t *Terminal) ComputerOutput(content goxy.DocString) string {
+	return t.code(terminalInline(goxy.PlainText(content)))
+}
+
+func (t *Terminal) Highlight(language string, content goxy.DocString) string {
+	return t.literal(goxy.CodeText(content))
+}
+
+func (t *Terminal) ItemizedList(items []string) string {
+	buf := bytes.NewBufferString("\n\n")
+	for _, item := range items {
+		_, _ = fmt.Fprintf(buf, "  • %s\n", terminalIndent(strings.TrimSpace(item), "    "))
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+	return buf.String()
+}
+
+func (t *Terminal) OrderedList(items []string) string {
+	buf := bytes.NewBufferString("\n\n")
+	for i, item := range items {
+		_, _ = fmt.Fprintf(buf, "  %d. %s\n", i+1, terminalIndent(strings.TrimSpace(item), "     "))
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+	return buf.String()
+}
+
+func (t *Terminal) VariableList(items []VariableListItem) string {
+	buf := bytes.NewBufferString("\n\n")
+	for _, item := range items {
+		if item.Term {
+			_, _ = fmt.Fprintf(buf, "  %s\n", t.bold(terminalInline(item.Content)))
+		} else {
+			_, _ = fmt.Fprintf(buf, "      %s\n", terminalIndent(strings.TrimSpace(item.Content), "      "))
+		}
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+	return buf.String()
+}
+
+func (t *Terminal) Term(content string) string {
+	return content
+}
+
+func (t *Terminal) Heading(level int, content string) string {
+	return fmt.Sprintf("\n\n%s\n\n", t.bold(terminalInline(content)))
+}
+
+func (t *Terminal) Title(content string) string {
+	return fmt.Sprintf("\n\n%s\n\n", t.bold(terminalInline(content)))
+}
+
+func (t *Terminal) XRefSect(id string, title string, description string) string {
+	return fmt.Sprintf("\n\n%s %s\n\n", t.bold(title+":"), terminalInline(description))
+}
+
+func (t *Terminal) Ref(refId string, content string) string {
+	t.track(refId)
+	return t.underline(content)
+}
+
+func (t *Terminal) Ulink(url string, content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" || content == t.Text(url) {
+		return t.underline(url)
+	}
+	return fmt.Sprintf("%s <%s>", t.underline(content), url)
+}
+
+func (t *Terminal) Anchor(id string) string {
+	return ""
+}
+
+func (t *Terminal) SimpleSection(kind string, id string, content string) string {
+	if kind == "" {
+		return content
+	}
+	return fmt.Sprintf("\n\n%s %s\n\n", t.bold(strings.Title(kind)+":"), strings.TrimSpace(content))
+}
+
+func (t *Terminal) ParameterList(kind string, items []ParameterListItem) string {
+	buf := bytes.NewBufferString("")
+	_, _ = fmt.Fprintf(buf, "\n\n%s\n", t.bold(parameterListTitle(kind)+":"))
+	for _, item := range items {
+		_, _ = fmt.Fprintf(buf, "  %s  %s\n", t.code(item.Name), terminalInline(item.Description))
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+	return buf.String()
+}
+
+func (t *Terminal) Table(rows [][]TableCell) string {
+	buf := bytes.NewBufferString("\n\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = terminalInline(cell.Content)
+			if cell.Head {
+				cells[i] = t.bold(cells[i])
+			}
+		}
+		_, _ = fmt.Fprintf(buf, "  %s\n", strings.Join(cells, " | "))
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+	return buf.String()
+}
+
+func (t *Terminal) Image(image goxy.DocStringImage) string {
+	if image.Description != "" {
+		return fmt.Sprintf("[image: %s]", image.Description)
+	}
+	return fmt.Sprintf("[image: %s]", image.Name)
+}
+
+func (t *Terminal) LineBreak() string {
+	return "\n"
+}
+
+func (t *Terminal) HorizontalRule() string {
+	width := t.Width
+	if width <= 0 {
+		width = 40
+	}
+	return "\n\n" + terminalLiteral + strings.Repeat("─", width) + "\n\n"
+}
+
+func (t *Terminal) BlockQuote(content string) string {
+	return fmt.Sprintf("\n\n    %s\n\n", terminalIndent(strings.TrimSpace(content), "    "))
+}
+
+func (t *Terminal) Details(summary string, content string) string {
+	return fmt.Sprintf("\n\n%s\n    %s\n\n", t.bold(terminalInline(summary)), terminalIndent(strings.TrimSpace(content), "    "))
+}
+
+func (t *Terminal) Strike(content string) string {
+	return t.style(strings.TrimSpace(content), "9", "29")
+}
+
+func (t *Terminal) Underline(content string) string {
+	return t.underline(strings.TrimSpace(content))
+}
+
+func (t *Terminal) Subscript(content string) string {
+	return "_" + content
+}
+
+func (t *Terminal) Superscript(content string) string {
+	return "^" + content
+}
+
+func (t *Terminal) Formula(formula string) string {
+	tex, block := FormulaTeX(formula)
+	if block {
+		return t.literal(tex)
+	}
+	return t.code(terminalInline(tex))
+}
+
+func (t *Terminal) Diagram(diagram goxy.DocStringDiagram) string {
+	if diagram.File != "" {
+		return t.Image(goxy.DocStringImage{Name: diagram.File, Description: diagram.Caption})
+	}
+	caption := ""
+	if diagram.Caption != "" {
+		caption = fmt.Sprintf("%s\n\n", terminalInline(diagram.Caption))
+	}
+	return t.literal(diagram.Content) + caption
+}
+
+func (t *Terminal) renderDescriptions(buf *bytes.Buffer, descriptions goxy.Descriptions, indent string) {
+	text := t.RenderDocstring(descriptions.BriefDescription) + "\n\n" + t.RenderDocstring(descriptions.DetailedDescription)
+	if text = strings.TrimSpace(text); text != "" {
+		_, _ = fmt.Fprintf(buf, "\n\n%s%s\n\n", indent, terminalIndent(text, indent))
+	}
+}
+
+func (t *Terminal) renderValues(buf *bytes.Buffer, values []goxy.EnumValue, indent string) {
+	if len(values) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(buf, "\n\n%s%s\n", indent, t.bold("Values:"))
+	for _, value := range values {
+		description := terminalInline(t.RenderDocstring(value.BriefDescription) + " " + t.RenderDocstring(value.DetailedDescription))
+		_, _ = fmt.Fprintf(buf, "%s  %s  %s\n", indent, t.code(strings.TrimSpace(value.Name+" "+value.Initializer)), description)
+	}
+	_, _ = fmt.Fprint(buf, "\n")
+}
+
+// finish collapses the blank lines between the blocks and wraps the text.
+func (t *Terminal) finish(buf *bytes.Buffer) []byte {
+	return []byte(t.fill(collapseBlankLines(buf.String())) + "\n")
+}
+
+// RenderMember renders the documentation of a single member of a compound.
+func (t *Terminal) RenderMember(compound *goxy.CompoundDoc, member MemberDoc) []byte {
+	buf := bytes.NewBufferString("")
+	_, _ = fmt.Fprintf(buf, "%s\n", t.bold(compound.Title+"::"+member.Name))
+	_, _ = fmt.Fprintf(buf, "%s of %s\n", strings.Title(member.Kind), compound.Title)
+	_, _ = fmt.Fprint(buf, t.literal(member.Signature))
+	t.renderDescriptions(buf, member.Descriptions, "")
+	t.renderValues(buf, member.Values, "")
+	return t.finish(buf)
+}
+
+func (t *Terminal) RenderCompound(compound *goxy.CompoundDoc) ([]byte, error) {
+	t.startCompound(compound)
+	buf := bytes.NewBufferString("")
+	_, _ = fmt.Fprintf(buf, "%s (%s)\n", t.bold(compound.Title), compound.Kind)
+	if compound.Location.File != "" {
+		_, _ = fmt.Fprintf(buf, "Defined in %s\n", compound.Location.File)
+	}
+	t.renderDescriptions(buf, compound.Descriptions, "")
+
+	for _, refs := range []struct {
+		title string
+		refs  []goxy.InnerCompoundRef
+	}{
+		{"Classes", compound.InnerClasses},
+		{"Namespaces", compound.InnerNamespaces},
+		{"Groups", compound.InnerGroups},
+		{"Dirs", compound.InnerDirs},
+		{"Files", compound.InnerFiles},
+	} {
+		if len(refs.refs) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(buf, "\n\n%s\n", t.bold(refs.title))
+		for _, ref := range refs.refs {
+			line := "  " + t.CompoundTitle(ref.RefId)
+			if brief := terminalInline(t.RenderDocstring(t.CompoundBrief(ref.RefId))); brief != "" {
+				line += " - " + brief
+			}
+			_, _ = fmt.Fprintf(buf, "%s\n", line)
+		}
+	}
+
+	for _, includes := range []struct {
+		title    string
+		includes []goxy.IncludeRef
+	}{
+		{"Includes", compound.Includes},
+		{"Included by", compound.IncludedBy},
+	} {
+		if len(includes.includes) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(buf, "\n\n%s\n", t.bold(includes.title))
+		for _, include := range includes.includes {
+			_, _ = fmt.Fprintf(buf, "  %s\n", IncludeName(include))
+		}
+	}
+
+	for _, section := range compound.Sections {
+		members := Members(section)
+		if len(members) == 0 {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(buf, "\n\n%s\n", t.bold(strings.ToUpper(section.Header)))
+		_, _ = fmt.Fprint(buf, t.RenderDocstring(section.Description))
+		for _, member := range members {
+			_, _ = fmt.Fprint(buf, t.literal(member.Signature))
+			t.renderDescriptions(buf, member.Descriptions, "    ")
+			t.renderValues(buf, member.Values, "    ")
+		}
+	}
+
+	return t.finish(buf), nil
+}
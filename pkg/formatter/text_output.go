@@ -0,0 +1,51 @@
+package formatter
+
+import (
+	"ScriptExecServer/pkg/goxy"
+)
+
+// TextOutput writes the doc sets as plain text pages without escape codes, for
+// grepping and reading in pagers.
+type TextOutput struct {
+	Options
+	DocSets []*DocSet
+}
+
+// TextWidth is the column the text pages are wrapped at.
+const TextWidth = 80
+
+func NewTextOutput(opts Options, sets []*DocSet) *TextOutput {
+	return &TextOutput{
+		Options: opts,
+		DocSets: sets,
+	}
+}
+
+func (o *TextOutput) Extension() string {
+	return ".txt"
+}
+
+func (o *TextOutput) Renderer(set *DocSet) Renderer {
+	t := NewTerminalFormatter(set.Section, set.Entities, set.Refs, TextWidth, false)
+	t.Diagnostics = o.Diagnostics
+	return t
+}
+
+func (o *TextOutput) WriteCompound(set *DocSet, compound *goxy.CompoundDoc, path string) error {
+	return writeCompound(o.Renderer(set), compound, path)
+}
+
+// WriteIndex does nothing, the pages are found by file name.
+func (o *TextOutput) WriteIndex() error {
+	return nil
+}
+
+// WriteMenu does nothing, text pages can't link.
+func (o *TextOutput) WriteMenu() error {
+	return nil
+}
+
+// WriteData does nothing, text pages are self-contained.
+func (o *TextOutput) WriteData() error {
+	return nil
+}
@@ -119,6 +119,17 @@ func CompoundFromDoxygen(d *doxygen.Doxygen) (*CompoundDoc, error) {
 	compound.InheritanceGraph = GraphFromDoxygen(d.CompoundDef.InheritanceGraph)
 	compound.InheritanceGraph = PruneSubClassesFromGraph(compound.InheritanceGraph, compound.Id)
 
+	compound.Includes = make([]IncludeRef, 0)
+	for _, include := range d.CompoundDef.Includes {
+		compound.Includes = append(compound.Includes, IncludeRefFromDoxygen(include))
+	}
+	compound.IncludedBy = make([]IncludeRef, 0)
+	for _, include := range d.CompoundDef.IncludedBy {
+		compound.IncludedBy = append(compound.IncludedBy, IncludeRefFromDoxygen(include))
+	}
+	compound.IncludeGraph = GraphFromDoxygen(d.CompoundDef.IncDepGraph)
+	compound.IncludedByGraph = GraphFromDoxygen(d.CompoundDef.InvIncDepGraph)
+
 	return compound, nil
 }
 
@@ -181,6 +192,14 @@ func InnerCompoundRefFromDoxygen(ic doxygen.InnerCompound) (InnerCompoundRef, er
 	return res, err
 }
 
+func IncludeRefFromDoxygen(i doxygen.Include) IncludeRef {
+	return IncludeRef{
+		RefId: strings.ToLower(i.RefId),
+		Name:  strings.TrimSpace(i.Value),
+		Local: i.Local == "yes",
+	}
+}
+
 func KindFromDoxygen(kind string) (Kind, error) {
 	switch kind {
 	case "class":
@@ -554,6 +573,19 @@ func DocStringFromDoxygen(t doxygen.DocString) (DocString, error) {
 				Type:  Ref,
 				Value: e,
 			}
+		case doxygen.Ulink:
+			e := DocStringUlink{
+				Url: cc.Url,
+			}
+			e.Content, err = DocStringFromDoxygen(cc.Content)
+			if err != nil {
+				return DocString{}, err
+			}
+
+			parts[i] = DocStringElement{
+				Type:  Ulink,
+				Value: e,
+			}
 		case doxygen.Anchor:
 			parts[i] = DocStringElement{
 				Type:  Anchor,
@@ -575,13 +607,29 @@ func DocStringFromDoxygen(t doxygen.DocString) (DocString, error) {
 			}
 		case doxygen.Section:
 			s := DocStringSection{
-				Id:   strings.ToLower(cc.Id),
-				Kind: cc.Kind,
+				Id:    strings.ToLower(cc.Id),
+				Kind:  cc.Kind,
+				Level: cc.Level,
 			}
 			s.Content, err = DocStringFromDoxygen(cc.Content)
 			if err != nil {
 				return DocString{}, err
 			}
+			// The title of a section is a heading one level below the one of
+			// the section it is nested in.
+			if s.Level > 0 && len(s.Content.Content) > 0 && s.Content.Content[0].Type == Title {
+				level := s.Level + 1
+				if level > 6 {
+					level = 6
+				}
+				s.Content.Content[0] = DocStringElement{
+					Type: Heading,
+					Value: DocStringHeading{
+						Content: s.Content.Content[0].Value.(DocStringTitle).Content,
+						Level:   level,
+					},
+				}
+			}
 			parts[i] = DocStringElement{
 				Type:  Section,
 				Value: s,
@@ -787,11 +835,166 @@ func DocStringFromDoxygen(t doxygen.DocString) (DocString, error) {
 				Type:  Highlight,
 				Value: h,
 			}
+		case doxygen.CodeLine:
+			l := DocStringCodeLine{
+				Number: cc.LineNumber,
+				RefId:  strings.ToLower(cc.RefId),
+			}
+			l.Content, err = DocStringFromDoxygen(cc.Content)
+			if err != nil {
+				return DocString{}, err
+			}
+			parts[i] = DocStringElement{
+				Type:  CodeLine,
+				Value: l,
+			}
+		case doxygen.Highlight:
+			h := DocStringCodeHighlight{
+				Class: cc.Class,
+			}
+			h.Content, err = DocStringFromDoxygen(cc.Content)
+			if err != nil {
+				return DocString{}, err
+			}
+			parts[i] = DocStringElement{
+				Type:  CodeHighlight,
+				Value: h,
+			}
 		case doxygen.Linebreak:
 			parts[i] = DocStringElement{
 				Type:  LineBreak,
 				Value: DocStringLinebreak{},
 			}
+		case doxygen.HorizontalRuler:
+			parts[i] = DocStringElement{
+				Type:  HorizontalRule,
+				Value: DocStringHorizontalRule{},
+			}
+		case doxygen.BlockQuote:
+			b := DocStringBlockQuote{}
+			b.Content, err = DocStringFromDoxygen(cc.Content)
+			if err != nil {
+				return DocString{}, err
+			}
+			parts[i] = DocStringElement{
+				Type:  BlockQuote,
+				Value: b,
+			}
+		case doxygen.Details:
+			// The summary is taken out of the content.
+			var summary, content doxygen.DocString
+			for _, c := range cc.Content.Content {
+				if s, ok := c.(doxygen.Summary); ok {
+					summary.Content = append(summary.Content, s.Content.Content...)
+				} else {
+					content.Content = append(content.Content, c)
+				}
+			}
+
+			d := DocStringDetails{}
+			d.Summary, err = DocStringFromDoxygen(summary)
+			if err != nil {
+				return DocString{}, err
+			}
+			d.Content, err = DocStringFromDoxygen(content)
+			if err != nil {
+				return DocString{}, err
+			}
+			parts[i] = DocStringElement{
+				Type:  Details,
+				Value: d,
+			}
+		case doxygen.Summary:
+			// A summary outside of details is shown as a paragraph.
+			p := DocStringParagraph{}
+			p.Content, err = DocStringFromDoxygen(cc.Content)
+			if err != nil {
+				return DocString{}, err
+			}
+			parts[i] = DocStringElement{
+				Type:  Paragraph,
+				Value: p,
+			}
+		case doxygen.Strike:
+			e := DocStringStrike{}
+			e.Content, err = DocStringFromDoxygen(cc.Content)
+			if err != nil {
+				return DocString{}, err
+			}
+			parts[i] = DocStringElement{
+				Type:  Strike,
+				Value: e,
+			}
+		case doxygen.Underline:
+			e := DocStringUnderline{}
+			e.Content, err = DocStringFromDoxygen(cc.Content)
+			if err != nil {
+				return DocString{}, err
+			}
+			parts[i] = DocStringElement{
+				Type:  Underline,
+				Value: e,
+			}
+		case doxygen.Subscript:
+			e := DocStringSubscript{}
+			e.Content, err = DocStringFromDoxygen(cc.Content)
+			if err != nil {
+				return DocString{}, err
+			}
+			parts[i] = DocStringElement{
+				Type:  Subscript,
+				Value: e,
+			}
+		case doxygen.Superscript:
+			e := DocStringSuperscript{}
+			e.Content, err = DocStringFromDoxygen(cc.Content)
+			if err != nil {
+				return DocString{}, err
+			}
+			parts[i] = DocStringElement{
+				Type:  Superscript,
+				Value: e,
+			}
+		case doxygen.Formula:
+			parts[i] = DocStringElement{
+				Type:  Formula,
+				Value: DocStringFormula{cc.Content},
+			}
+		case doxygen.Diagram:
+			d := DocStringDiagram{
+				Language: strings.TrimSuffix(cc.Kind, "file"),
+				Caption:  cc.Caption,
+				Content:  strings.Trim(cc.Content, "\n"),
+			}
+			if strings.HasSuffix(cc.Kind, "file") {
+				d.File = cc.Name
+			}
+			parts[i] = DocStringElement{
+				Type:  Diagram,
+				Value: d,
+			}
+		case doxygen.TocList:
+			// The table of contents is a list of refs to the sections.
+			l := DocStringItemizedList{
+				Items: make([]DocString, len(cc.Items)),
+			}
+			for j, item := range cc.Items {
+				r := DocStringRef{
+					RefId:   strings.ToLower(item.Id),
+					KindRef: "member",
+				}
+				r.Content, err = DocStringFromDoxygen(item.Content)
+				if err != nil {
+					return DocString{}, err
+				}
+				l.Items[j] = DocString{
+					Content: []DocStringElement{{Type: Ref, Value: r}},
+				}
+			}
+			parts[i] = DocStringElement{
+				Type:  ItemizedList,
+				Value: l,
+			}
 		default:
 			return DocString{}, errors.New(fmt.Sprintf("unable to convert doc string, unknown type: %T", cc))
 		}
@@ -0,0 +1,196 @@
+package goxy
+
+import (
+	"ScriptExecServer/pkg/doxygen"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func loadDoxygen(t *testing.T, path string) map[string]*CompoundDoc {
+	t.Helper()
+	files, err := doxygen.StreamDoxygenFolder(path, doxygen.ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byId := make(map[string]*CompoundDoc)
+	for f := range files {
+		if f.Err != nil {
+			t.Fatalf("%s: %v", f.Path, f.Err)
+		}
+		c, err := CompoundFromDoxygen(f.Doc)
+		if err != nil {
+			t.Fatalf("%s: %v", f.Path, err)
+		}
+		byId[c.Id] = c
+	}
+	return byId
+}
+
+// docElements returns the content of a doc string without the line breaks
+// between its elements.
+func docElements(d DocString) []DocStringElement {
+	content := make([]DocStringElement, 0)
+	for _, e := range d.Content {
+		if text, ok := e.Value.(DocStringText); ok && strings.Contains(text.Content, "\n") && strings.TrimSpace(text.Content) == "" {
+			continue
+		}
+		content = append(content, e)
+	}
+	return content
+}
+
+func docText(content ...string) DocString {
+	d := DocString{}
+	for _, c := range content {
+		d.Content = append(d.Content, DocStringElement{Type: Text, Value: DocStringText{c}})
+	}
+	return d
+}
+
+func docParagraph(content ...DocStringElement) DocStringElement {
+	return DocStringElement{Type: Paragraph, Value: DocStringParagraph{DocString{content}}}
+}
+
+func TestDocStringFromDoxygen(t *testing.T) {
+	vocab := loadDoxygen(t, "../doxygen/testdata")["vocab"]
+	brief := docElements(vocab.BriefDescription)
+	detailed := docElements(vocab.DetailedDescription)
+	first := docElements(detailed[1].Value.(DocStringSection).Content)
+	second := docElements(first[4].Value.(DocStringSection).Content)
+
+	tests := []struct {
+		name string
+		got  DocStringElement
+		want DocStringElement
+	}{
+		{
+			name: "symbols",
+			got:  brief[0],
+			want: DocStringElement{Type: Paragraph, Value: DocStringParagraph{
+				docText("Symbols ", "©", " 2024 ", "—", " ", "Ä", "\u00a0", "™", " and ", "‘", "q", "’", "."),
+			}},
+		},
+		{
+			name: "toclist",
+			got:  detailed[0],
+			want: docParagraph(DocStringElement{Type: ItemizedList, Value: DocStringItemizedList{Items: []DocString{
+				{Content: []DocStringElement{{Type: Ref, Value: DocStringRef{RefId: "vocab_1first", KindRef: "member", Content: docText("First")}}}},
+				{Content: []DocStringElement{{Type: Ref, Value: DocStringRef{RefId: "vocab_1deep", KindRef: "member", Content: docText("Deep")}}}},
+			}}}),
+		},
+		{
+			name: "strike",
+			got:  first[1],
+			want: docParagraph(
+				DocStringElement{Type: Text, Value: DocStringText{"Text with "}},
+				DocStringElement{Type: Strike, Value: DocStringStrike{docText("struck")}},
+				DocStringElement{Type: Text, Value: DocStringText{", "}},
+				DocStringElement{Type: Strike, Value: DocStringStrike{docText("s")}},
+				DocStringElement{Type: Text, Value: DocStringText{" and "}},
+				DocStringElement{Type: Strike, Value: DocStringStrike{docText("del")}},
+				DocStringElement{Type: Text, Value: DocStringText{"."}},
+			),
+		},
+		{
+			name: "formula",
+			got:  first[2],
+			want: docParagraph(
+				DocStringElement{Type: Text, Value: DocStringText{"Inline "}},
+				DocStringElement{Type: Formula, Value: DocStringFormula{"$x^2 + y^2$"}},
+				DocStringElement{Type: Text, Value: DocStringText{" and block "}},
+				DocStringElement{Type: Formula, Value: DocStringFormula{`\[ \sum_{i=0}^n i \]`}},
+			),
+		},
+		{
+			name: "details",
+			got:  first[3],
+			want: DocStringElement{Type: Details, Value: DocStringDetails{
+				Summary: DocString{Content: []DocStringElement{
+					{Type: Text, Value: DocStringText{"More "}},
+					{Type: Emphasis, Value: DocStringEmphasis{docText("info")}},
+				}},
+				Content: DocString{Content: []DocStringElement{
+					docParagraph(DocStringElement{Type: Text, Value: DocStringText{"Hidden body."}}),
+				}},
+			}},
+		},
+		{
+			name: "diagram",
+			got:  second[1],
+			want: docParagraph(
+				DocStringElement{Type: Diagram, Value: DocStringDiagram{Language: "dot", Caption: "Graph", Content: "digraph G { a -> b; }"}},
+				DocStringElement{Type: Diagram, Value: DocStringDiagram{Language: "dot", File: "graph.dot", Caption: "File caption"}},
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSectionHeadingsFromDoxygen(t *testing.T) {
+	vocab := loadDoxygen(t, "../doxygen/testdata")["vocab"]
+
+	type section struct {
+		Id      string
+		Level   int
+		Heading DocStringElement
+	}
+	got := make([]section, 0)
+	content := docElements(vocab.DetailedDescription)
+	for len(content) > 0 {
+		var next []DocStringElement
+		for _, e := range content {
+			s, ok := e.Value.(DocStringSection)
+			if !ok {
+				continue
+			}
+			inner := docElements(s.Content)
+			got = append(got, section{s.Id, s.Level, inner[0]})
+			next = inner
+		}
+		content = next
+	}
+
+	heading := func(title string, level int) DocStringElement {
+		return DocStringElement{Type: Heading, Value: DocStringHeading{Content: docText(title), Level: level}}
+	}
+	want := []section{
+		{"vocab_1first", 1, heading("First", 2)},
+		{"vocab_1second", 2, heading("Second", 3)},
+		{"vocab_1deep", 3, heading("Deep", 4)},
+		{"vocab_1deeper", 4, heading("Deeper", 5)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %+v, want %+v", got, want)
+	}
+}
+
+func TestDocStringUlinkFromDoxygen(t *testing.T) {
+	linker := loadDoxygen(t, "../doxygen/testdata")["classlinker"]
+
+	got := docElements(linker.BriefDescription)
+	want := []DocStringElement{
+		docParagraph(
+			DocStringElement{Type: Text, Value: DocStringText{"Links to "}},
+			DocStringElement{Type: Ulink, Value: DocStringUlink{
+				Url: "https://docs.example.org/linker",
+				Content: DocString{[]DocStringElement{
+					{Type: Bold, Value: DocStringBold{docText("the")}},
+					{Type: Text, Value: DocStringText{" docs"}},
+				}},
+			}},
+			DocStringElement{Type: Text, Value: DocStringText{"."}},
+		),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("brief = %+v, want %+v", got, want)
+	}
+}
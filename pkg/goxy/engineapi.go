@@ -0,0 +1,539 @@
+package goxy
+
+import (
+	"ScriptExecServer/pkg/engineapi"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EngineApiId is the id of the compound holding the global scope of an engine
+// API export.
+const EngineApiId = "engineapi"
+
+// CompoundsFromEngineApi converts the engine's API export into compounds. The
+// global scope becomes a namespace, nested scopes become namespaces inside it,
+// and every exported struct and class becomes a compound of its own.
+func CompoundsFromEngineApi(scope *engineapi.EngineExportScope) ([]*CompoundDoc, error) {
+	c := &engineApiConverter{
+		classIds: make(map[string]string),
+		classes:  make(map[string]engineapi.EngineClassType),
+	}
+	c.collectClasses(EngineApiId, scope.Exports)
+
+	root := &CompoundDoc{
+		Descriptions: DescriptionsFromEngineDocs(scope.Docs),
+		Id:           EngineApiId,
+		Kind:         Namespace,
+		Name:         "Engine API",
+		Title:        "Engine API",
+	}
+	c.compounds = append(c.compounds, root)
+	err := c.exports(root, scope.Exports)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, compound := range c.compounds {
+		if compound.Kind == Class {
+			compound.InheritanceGraph = c.inheritanceGraph(compound.Name)
+		}
+	}
+
+	return c.compounds, nil
+}
+
+type engineApiConverter struct {
+	compounds []*CompoundDoc
+	// classIds maps class names to compound ids, classes refer to their
+	// superclass by name.
+	classIds map[string]string
+	classes  map[string]engineapi.EngineClassType
+}
+
+func (c *engineApiConverter) collectClasses(scopeId string, exports engineapi.Exports) {
+	for _, class := range exports.Classes {
+		id := engineApiCompoundId(scopeId, "class", class.Name)
+		c.classIds[class.Name] = id
+		c.classes[class.Name] = class
+		c.collectClasses(id, class.Exports)
+	}
+	for _, scope := range exports.Scopes {
+		c.collectClasses(engineApiCompoundId(scopeId, "scope", scope.Name), scope.Exports)
+	}
+}
+
+// exports adds the sections for the exports of a scope or class to compound,
+// and converts the nested scopes, structs and classes.
+func (c *engineApiConverter) exports(compound *CompoundDoc, exports engineapi.Exports) error {
+	methods := &SectionDoc{
+		Id:     compound.Id + "_functions",
+		Kind:   Functions,
+		Header: "Functions",
+	}
+	if compound.Kind == Class {
+		methods.Header = "Methods"
+	}
+	callbacks := &SectionDoc{
+		Id:     compound.Id + "_callbacks",
+		Kind:   UserDefined,
+		Header: "Callbacks",
+	}
+	for _, f := range exports.Functions {
+		function, err := FunctionFromEngineApi(compound.Id, f)
+		if err != nil {
+			return err
+		}
+		if engineapi.IsSet(f.IsCallback) {
+			callbacks.Functions = append(callbacks.Functions, function)
+		} else {
+			methods.Functions = append(methods.Functions, function)
+		}
+	}
+
+	enums := &SectionDoc{
+		Id:     compound.Id + "_enums",
+		Kind:   Enums,
+		Header: "Enumerations",
+	}
+	for _, e := range exports.Enums {
+		enums.Enums = append(enums.Enums, EnumFromEngineApi(compound.Id, e))
+	}
+	bitfields := &SectionDoc{
+		Id:     compound.Id + "_bitfields",
+		Kind:   Enums,
+		Header: "Bitfields",
+	}
+	for _, b := range exports.Bitfields {
+		bitfields.Enums = append(bitfields.Enums, EnumFromEngineApi(compound.Id, engineapi.EngineEnumType(b)))
+	}
+
+	for _, section := range []*SectionDoc{methods, callbacks, enums, bitfields} {
+		if len(section.Functions) > 0 || len(section.Enums) > 0 {
+			compound.Sections = append(compound.Sections, section)
+		}
+	}
+
+	for _, s := range exports.Structs {
+		inner := StructFromEngineApi(compound.Id, s)
+		compound.InnerClasses = append(compound.InnerClasses, InnerCompoundRef{
+			RefId:      inner.Id,
+			Protection: Public,
+			Value:      inner.Name,
+		})
+		c.compounds = append(c.compounds, inner)
+	}
+
+	for _, class := range exports.Classes {
+		inner := &CompoundDoc{
+			Descriptions: DescriptionsFromEngineDocs(class.Docs),
+			Id:           c.classIds[class.Name],
+			Kind:         Class,
+			Name:         class.Name,
+			Title:        class.Name,
+		}
+		inner.Sections = append(inner.Sections, engineApiProperties(inner.Id, class.Properties)...)
+		compound.InnerClasses = append(compound.InnerClasses, InnerCompoundRef{
+			RefId:      inner.Id,
+			Protection: Public,
+			Value:      inner.Name,
+		})
+		c.compounds = append(c.compounds, inner)
+
+		err := c.exports(inner, class.Exports)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, scope := range exports.Scopes {
+		if scope.Name == "" {
+			return errors.New(fmt.Sprintf("engine export scope without a name in %s", compound.Name))
+		}
+		name := scope.Name
+		if compound.Id != EngineApiId {
+			name = compound.Name + "::" + scope.Name
+		}
+		inner := &CompoundDoc{
+			Descriptions: DescriptionsFromEngineDocs(scope.Docs),
+			Id:           engineApiCompoundId(compound.Id, "scope", scope.Name),
+			Kind:         Namespace,
+			Name:         name,
+			Title:        name,
+		}
+		if compound.Kind == Namespace {
+			compound.InnerNamespaces = append(compound.InnerNamespaces, InnerCompoundRef{
+				RefId:      inner.Id,
+				Protection: Public,
+				Value:      inner.Name,
+			})
+		}
+		c.compounds = append(c.compounds, inner)
+
+		err := c.exports(inner, scope.Exports)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// inheritanceGraph builds the graph of a class and its superclasses, classes
+// that aren't part of the export end the chain.
+func (c *engineApiConverter) inheritanceGraph(name string) Graph {
+	g := Graph{}
+	seen := make(map[string]bool)
+	for name != "" && !seen[name] {
+		seen[name] = true
+		id := len(g.Nodes) + 1
+		g.Nodes = append(g.Nodes, GraphNode{
+			Id:    id,
+			Label: name,
+			RefId: c.classIds[name],
+		})
+		if id > 1 {
+			g.Edges = append(g.Edges, GraphEdge{
+				FromId:   id - 1,
+				ToId:     id,
+				Relation: "public-inheritance",
+			})
+		}
+
+		class, ok := c.classes[name]
+		if !ok {
+			break
+		}
+		name = class.SuperType
+	}
+
+	if len(g.Nodes) < 2 {
+		return Graph{}
+	}
+	return g
+}
+
+func engineApiProperties(compoundId string, properties []engineapi.EngineProperty) []*SectionDoc {
+	if len(properties) == 0 {
+		return nil
+	}
+
+	section := &SectionDoc{
+		Id:     compoundId + "_properties",
+		Kind:   Attributes,
+		Header: "Properties",
+	}
+	for _, p := range properties {
+		section.Attributes = append(section.Attributes, &ClassAttributeDoc{
+			Descriptions: DescriptionsFromEngineDocs(p.Docs),
+			Id:           engineApiMemberId(compoundId, p.Name),
+			Name:         p.Name,
+			Protection:   Public,
+			Type:         engineApiText(engineApiPropertyType(p)),
+			Definition:   strings.TrimSpace(fmt.Sprintf("%s %s", p.Type, p.Name)),
+			ArgsString:   engineApiText(engineApiIndexedSize(p.IndexedSize)),
+		})
+	}
+	return []*SectionDoc{section}
+}
+
+func engineApiPropertyType(p engineapi.EngineProperty) string {
+	t := p.Type
+	if engineapi.IsSet(p.IsConstant) {
+		t = "const " + t
+	}
+	return t
+}
+
+// engineApiIndexedSize returns the array suffix of fields and properties with
+// more than one element.
+func engineApiIndexedSize(size string) string {
+	size = strings.TrimSpace(size)
+	if size == "" || size == "0" || size == "1" {
+		return ""
+	}
+	return "[" + size + "]"
+}
+
+// StructFromEngineApi converts an exported struct of the scope with the given
+// compound id, its fields become attributes.
+func StructFromEngineApi(scopeId string, s engineapi.EngineStructType) *CompoundDoc {
+	compound := &CompoundDoc{
+		Descriptions: DescriptionsFromEngineDocs(s.Docs),
+		Id:           engineApiCompoundId(scopeId, "struct", s.Name),
+		Kind:         Struct,
+		Name:         s.Name,
+		Title:        s.Name,
+	}
+	if len(s.Fields) == 0 {
+		return compound
+	}
+
+	section := &SectionDoc{
+		Id:     compound.Id + "_fields",
+		Kind:   Attributes,
+		Header: "Fields",
+	}
+	for _, f := range s.Fields {
+		section.Attributes = append(section.Attributes, &ClassAttributeDoc{
+			Descriptions: DescriptionsFromEngineDocs(f.Docs),
+			Id:           engineApiMemberId(compound.Id, f.Name),
+			Name:         f.Name,
+			Protection:   Public,
+			Type:         engineApiText(f.Type),
+			Definition:   strings.TrimSpace(fmt.Sprintf("%s %s", f.Type, f.Name)),
+			ArgsString:   engineApiText(engineApiIndexedSize(f.IndexedSize)),
+		})
+	}
+	compound.Sections = append(compound.Sections, section)
+	return compound
+}
+
+// EnumFromEngineApi converts an exported enum or bitfield of the scope with the
+// given compound id.
+func EnumFromEngineApi(scopeId string, e engineapi.EngineEnumType) *EnumDoc {
+	enum := &EnumDoc{
+		Descriptions: DescriptionsFromEngineDocs(e.Docs),
+		Id:           engineApiMemberId(scopeId, e.Name),
+		Name:         e.Name,
+		Protection:   Public,
+	}
+	for _, v := range e.Enums {
+		value := EnumValue{
+			Descriptions: DescriptionsFromEngineDocs(v.Docs),
+			Id:           engineApiMemberId(scopeId, e.Name+"_"+v.Name),
+			Name:         v.Name,
+			Protection:   Public,
+		}
+		if v.Value != "" {
+			value.Initializer = "= " + v.Value
+		}
+		enum.Values = append(enum.Values, value)
+	}
+	return enum
+}
+
+// FunctionFromEngineApi converts an exported function of the scope with the
+// given compound id.
+func FunctionFromEngineApi(scopeId string, f engineapi.EngineFunction) (*FunctionDoc, error) {
+	if f.Name == "" {
+		return nil, errors.New(fmt.Sprintf("engine function without a name in scope %s", scopeId))
+	}
+
+	function := &FunctionDoc{
+		Descriptions: DescriptionsFromEngineDocs(f.Docs),
+		Id:           engineApiMemberId(scopeId, f.Name),
+		Name:         f.Name,
+		Protection:   Public,
+		Type:         engineApiText(f.ReturnType),
+		Definition:   strings.TrimSpace(fmt.Sprintf("%s %s", f.ReturnType, f.Name)),
+	}
+
+	args := make([]string, 0, len(f.Arguments)+1)
+	for _, arg := range f.Arguments {
+		function.Params = append(function.Params, FunctionParam{
+			Type:         engineApiText(arg.Type),
+			DeclName:     arg.Name,
+			DefaultValue: arg.DefaultValue,
+		})
+		decl := strings.TrimSpace(arg.Type + " " + arg.Name)
+		if arg.DefaultValue != "" {
+			decl += "=" + arg.DefaultValue
+		}
+		args = append(args, decl)
+	}
+	if engineapi.IsSet(f.IsVariadic) {
+		function.Params = append(function.Params, FunctionParam{
+			DeclName: "...",
+		})
+		args = append(args, "...")
+	}
+	function.ArgsString = "(" + strings.Join(args, ", ") + ")"
+
+	return function, nil
+}
+
+func engineApiMemberId(scopeId string, name string) string {
+	return scopeId + "_1" + strings.ToLower(name)
+}
+
+func engineApiCompoundId(scopeId string, kind string, name string) string {
+	return scopeId + "_" + kind + "_" + strings.ToLower(name)
+}
+
+func engineApiText(text string) DocString {
+	if text == "" {
+		return DocString{}
+	}
+	return DocString{
+		Content: []DocStringElement{
+			{
+				Type:  Text,
+				Value: DocStringText{text},
+			},
+		},
+	}
+}
+
+// DescriptionsFromEngineDocs converts the doc strings of the engine export,
+// which use a subset of the doxygen commands, into descriptions. The brief
+// description is either given by @brief or is the first paragraph.
+func DescriptionsFromEngineDocs(docs string) Descriptions {
+	p := &engineDocsParser{}
+	lines := strings.Split(strings.ReplaceAll(docs, "\r\n", "\n"), "\n")
+	for _, line := range lines {
+		p.line(line)
+	}
+	p.flush()
+	p.flushParams()
+
+	if len(p.brief.Content) == 0 && len(p.detailed.Content) > 0 && p.detailed.Content[0].Type == Paragraph {
+		p.brief.Content = p.detailed.Content[:1]
+		p.detailed.Content = p.detailed.Content[1:]
+	}
+
+	return Descriptions{
+		BriefDescription:    p.brief,
+		DetailedDescription: p.detailed,
+	}
+}
+
+type engineDocsParser struct {
+	brief    DocString
+	detailed DocString
+	params   []DocStringParameterItem
+
+	// command is the command the text collected in text belongs to, or empty
+	// for a plain paragraph.
+	command string
+	// name is the parameter name of a @param command.
+	name string
+	text []string
+	// example is true between @tsexample and @endtsexample.
+	example bool
+}
+
+func (p *engineDocsParser) line(line string) {
+	trimmed := strings.TrimSpace(line)
+
+	if p.example {
+		if trimmed == "@endtsexample" || trimmed == "\\endtsexample" {
+			p.detailed.Content = append(p.detailed.Content, DocStringElement{
+				Type: Highlight,
+				Value: DocStringHighlight{
+					Content:  engineApiText(strings.Join(p.text, "\n")),
+					Language: "TorqueScript",
+				},
+			})
+			p.text = nil
+			p.example = false
+			return
+		}
+		p.text = append(p.text, line)
+		return
+	}
+
+	if trimmed == "" {
+		p.flush()
+		return
+	}
+
+	if !strings.HasPrefix(trimmed, "@") && !strings.HasPrefix(trimmed, "\\") {
+		p.text = append(p.text, trimmed)
+		return
+	}
+
+	command := trimmed[1:]
+	rest := ""
+	if i := strings.IndexAny(command, " \t"); i >= 0 {
+		command, rest = command[:i], strings.TrimSpace(command[i:])
+	}
+
+	switch command {
+	case "brief", "param", "return", "returns", "note", "see", "deprecated":
+		p.flush()
+		p.command = command
+		if command == "param" {
+			p.name = rest
+			if i := strings.IndexAny(rest, " \t"); i >= 0 {
+				p.name, rest = rest[:i], strings.TrimSpace(rest[i:])
+			} else {
+				rest = ""
+			}
+		}
+		if rest != "" {
+			p.text = append(p.text, rest)
+		}
+	case "tsexample":
+		p.flush()
+		p.example = true
+	case "ingroup", "internal", "hide":
+		// Grouping is handled by the doxygen pass over the scripting docs.
+	default:
+		p.text = append(p.text, trimmed)
+	}
+}
+
+// flush ends the paragraph or command being collected.
+func (p *engineDocsParser) flush() {
+	text := strings.Join(p.text, " ")
+	command := p.command
+	p.text = nil
+	p.command = ""
+
+	if command != "param" {
+		p.flushParams()
+	}
+
+	switch command {
+	case "param":
+		p.params = append(p.params, DocStringParameterItem{
+			Name:        p.name,
+			Description: engineApiText(text),
+		})
+		return
+	case "":
+		if text == "" {
+			return
+		}
+	}
+
+	paragraph := DocStringElement{
+		Type:  Paragraph,
+		Value: DocStringParagraph{engineApiText(text)},
+	}
+	switch command {
+	case "brief":
+		p.brief.Content = append(p.brief.Content, paragraph)
+	case "":
+		p.detailed.Content = append(p.detailed.Content, paragraph)
+	default:
+		kind := command
+		if kind == "returns" {
+			kind = "return"
+		}
+		p.detailed.Content = append(p.detailed.Content, DocStringElement{
+			Type: Section,
+			Value: DocStringSection{
+				Kind:    kind,
+				Content: DocString{[]DocStringElement{paragraph}},
+			},
+		})
+	}
+}
+
+// flushParams ends the parameter list being collected, consecutive @param
+// commands form a single list.
+func (p *engineDocsParser) flushParams() {
+	if len(p.params) == 0 {
+		return
+	}
+	p.detailed.Content = append(p.detailed.Content, DocStringElement{
+		Type: ParameterList,
+		Value: DocStringParameterList{
+			Kind:  "param",
+			Items: p.params,
+		},
+	})
+	p.params = nil
+}
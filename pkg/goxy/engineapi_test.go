@@ -0,0 +1,213 @@
+package goxy
+
+import (
+	"ScriptExecServer/pkg/engineapi"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func loadEngineApi(t *testing.T, path string) map[string]*CompoundDoc {
+	t.Helper()
+	scope, err := engineapi.ReadEngineApiExportXml(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compounds, err := CompoundsFromEngineApi(scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byId := make(map[string]*CompoundDoc)
+	for _, c := range compounds {
+		if _, ok := byId[c.Id]; ok {
+			t.Fatalf("compound id %s is used twice", c.Id)
+		}
+		byId[c.Id] = c
+	}
+	return byId
+}
+
+func sectionHeaders(c *CompoundDoc) []string {
+	headers := make([]string, 0)
+	for _, s := range c.Sections {
+		headers = append(headers, s.Header)
+	}
+	return headers
+}
+
+func TestCompoundsFromEngineApi(t *testing.T) {
+	type compound struct {
+		Kind     Kind
+		Name     string
+		Sections []string
+	}
+	tests := []struct {
+		name string
+		path string
+		want map[string]compound
+	}{
+		{
+			name: "old",
+			path: "../engineapi/testdata/export_old.xml",
+			want: map[string]compound{
+				"engineapi":            {Namespace, "Engine API", []string{"Functions", "Enumerations"}},
+				"engineapi_scope_math": {Namespace, "Math", []string{"Functions"}},
+			},
+		},
+		{
+			name: "new",
+			path: "../engineapi/testdata/export_new.xml",
+			want: map[string]compound{
+				"engineapi":                         {Namespace, "Engine API", []string{"Functions", "Callbacks", "Enumerations", "Bitfields"}},
+				"engineapi_struct_point3f":          {Struct, "Point3F", []string{"Fields"}},
+				"engineapi_class_simobject":         {Class, "SimObject", []string{"Properties", "Methods", "Callbacks"}},
+				"engineapi_class_sceneobject":       {Class, "SceneObject", []string{}},
+				"engineapi_scope_math":              {Namespace, "Math", []string{"Functions"}},
+				"engineapi_scope_math_scope_vector": {Namespace, "Math::Vector", []string{"Functions"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compounds := loadEngineApi(t, tt.path)
+
+			got := make(map[string]compound)
+			for id, c := range compounds {
+				got[id] = compound{c.Kind, c.Name, sectionHeaders(c)}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compounds = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionsFromEngineApi(t *testing.T) {
+	compounds := loadEngineApi(t, "../engineapi/testdata/export_new.xml")
+	root := compounds[EngineApiId]
+
+	tests := []struct {
+		name       string
+		section    int
+		index      int
+		id         string
+		definition string
+		argsString string
+		brief      string
+	}{
+		{
+			name:       "arguments with defaults",
+			section:    0,
+			index:      0,
+			id:         "engineapi_1getrealtime",
+			definition: "int getRealTime",
+			argsString: "(int scale=1, int offset)",
+			brief:      "Return the current real time in milliseconds.",
+		},
+		{
+			name:       "variadic",
+			section:    0,
+			index:      1,
+			id:         "engineapi_1echo",
+			definition: "void echo",
+			argsString: "(string text, ...)",
+			brief:      "Print a message to the console.",
+		},
+		{
+			name:       "callback",
+			section:    1,
+			index:      0,
+			id:         "engineapi_1onstart",
+			definition: "void onStart",
+			argsString: "()",
+			brief:      "Called when the engine started.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := root.Sections[tt.section].Functions[tt.index]
+			if f.Id != tt.id || f.Definition != tt.definition || f.ArgsString != tt.argsString {
+				t.Errorf("got %s %q %q, want %s %q %q", f.Id, f.Definition, f.ArgsString, tt.id, tt.definition, tt.argsString)
+			}
+			if brief := strings.TrimSpace(PlainText(f.BriefDescription)); brief != tt.brief {
+				t.Errorf("brief = %q, want %q", brief, tt.brief)
+			}
+		})
+	}
+
+	getRealTime := root.Sections[0].Functions[0]
+	wantParams := []FunctionParam{
+		{Type: engineApiText("int"), DeclName: "scale", DefaultValue: "1"},
+		{Type: engineApiText("int"), DeclName: "offset"},
+	}
+	if !reflect.DeepEqual(getRealTime.Params, wantParams) {
+		t.Errorf("params = %+v, want %+v", getRealTime.Params, wantParams)
+	}
+}
+
+func TestEnumsFromEngineApi(t *testing.T) {
+	compounds := loadEngineApi(t, "../engineapi/testdata/export_new.xml")
+	root := compounds[EngineApiId]
+
+	enum := root.Sections[2].Enums[0]
+	values := make([]string, 0)
+	for _, v := range enum.Values {
+		values = append(values, v.Id+" "+v.Name+" "+v.Initializer)
+	}
+	want := []string{
+		"engineapi_1gfxformat_gfxformatr8g8b8 GFXFormatR8G8B8 = 0",
+		"engineapi_1gfxformat_gfxformata8 GFXFormatA8 = 1",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+
+	bitfield := root.Sections[3].Enums[0]
+	if bitfield.Name != "TypeMasks" || len(bitfield.Values) != 2 || bitfield.Values[1].Initializer != "= 4" {
+		t.Errorf("bitfield = %+v, want TypeMasks with 2 flags", bitfield)
+	}
+}
+
+func TestClassesFromEngineApi(t *testing.T) {
+	compounds := loadEngineApi(t, "../engineapi/testdata/export_new.xml")
+
+	point := compounds["engineapi_struct_point3f"]
+	fields := point.Sections[0].Attributes
+	if len(fields) != 2 || fields[0].Definition != "float x" || PlainText(fields[1].ArgsString) != "[2]" {
+		t.Errorf("fields = %+v, want x and yz[2]", fields)
+	}
+
+	simObject := compounds["engineapi_class_simobject"]
+	property := simObject.Sections[0].Attributes[0]
+	if PlainText(property.Type) != "const string" {
+		t.Errorf("property type = %q, want const string", PlainText(property.Type))
+	}
+
+	sceneObject := compounds["engineapi_class_sceneobject"]
+	wantGraph := Graph{
+		Nodes: []GraphNode{
+			{Id: 1, Label: "SceneObject", RefId: "engineapi_class_sceneobject"},
+			{Id: 2, Label: "SimObject", RefId: "engineapi_class_simobject"},
+		},
+		Edges: []GraphEdge{{FromId: 1, ToId: 2, Relation: "public-inheritance"}},
+	}
+	if !reflect.DeepEqual(sceneObject.InheritanceGraph, wantGraph) {
+		t.Errorf("inheritance graph = %+v, want %+v", sceneObject.InheritanceGraph, wantGraph)
+	}
+
+	root := compounds[EngineApiId]
+	namespaces := make([]string, 0)
+	for _, inner := range root.InnerNamespaces {
+		namespaces = append(namespaces, inner.RefId)
+	}
+	if !reflect.DeepEqual(namespaces, []string{"engineapi_scope_math"}) {
+		t.Errorf("inner namespaces = %v, want only the Math scope", namespaces)
+	}
+	math := compounds["engineapi_scope_math"]
+	if len(math.InnerNamespaces) != 1 || math.InnerNamespaces[0].Value != "Math::Vector" {
+		t.Errorf("Math inner namespaces = %+v, want Math::Vector", math.InnerNamespaces)
+	}
+}
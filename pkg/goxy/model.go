@@ -60,6 +60,18 @@ const (
 	ComputerOutput DocStringType = "computeroutput"
 	LineBreak      DocStringType = "linebreak"
 	Highlight      DocStringType = "highlight"
+	Ulink          DocStringType = "ulink"
+	BlockQuote     DocStringType = "blockquote"
+	Details        DocStringType = "details"
+	Strike         DocStringType = "strike"
+	Underline      DocStringType = "underline"
+	Subscript      DocStringType = "subscript"
+	Superscript    DocStringType = "superscript"
+	Formula        DocStringType = "formula"
+	Diagram        DocStringType = "diagram"
+	HorizontalRule DocStringType = "horizontalrule"
+	CodeLine       DocStringType = "codeline"
+	CodeHighlight  DocStringType = "codehighlight"
 )
 
 type Kind string
@@ -83,6 +95,9 @@ type DocStringSection struct {
 	Id      string
 	Kind    string
 	Content DocString
+	// Level is the nesting of a section with a title, from 1, and 0 for
+	// simple sections like notes.
+	Level int `json:",omitempty"`
 }
 
 type DocStringParagraph struct {
@@ -165,17 +180,85 @@ type DocStringComputerOutput struct {
 
 type DocStringLinebreak struct{}
 
+type DocStringBlockQuote struct {
+	Content DocString
+}
+
+// DocStringDetails is a block collapsed to its summary.
+type DocStringDetails struct {
+	Summary DocString
+	Content DocString
+}
+
+type DocStringStrike struct {
+	Content DocString
+}
+
+type DocStringUnderline struct {
+	Content DocString
+}
+
+type DocStringSubscript struct {
+	Content DocString
+}
+
+type DocStringSuperscript struct {
+	Content DocString
+}
+
+// DocStringFormula is a LaTeX formula with its delimiters, $ for inline
+// formulas and \[ or an environment for blocks.
+type DocStringFormula struct {
+	Content string
+}
+
+// DocStringDiagram is a dot, msc or PlantUML diagram. Inline diagrams have
+// their source as content, the others name the file of the diagram.
+type DocStringDiagram struct {
+	Language string
+	File     string
+	Caption  string
+	Content  string
+}
+
+type DocStringHorizontalRule struct{}
+
 type DocStringHighlight struct {
 	Content  DocString
 	Language string
 }
 
+// DocStringCodeLine is a line of a program listing. Listings from doxygen
+// consist of code lines, the text of the other ones is split into lines when
+// rendered.
+type DocStringCodeLine struct {
+	// Number is the line in the source file, or 0 for listings in
+	// documentation.
+	Number int `json:",omitempty"`
+	// RefId is the member or compound declared on the line, if any.
+	RefId   string `json:",omitempty"`
+	Content DocString
+}
+
+// DocStringCodeHighlight is a run of a code line in one of the highlight
+// classes of doxygen, like keyword, comment or stringliteral.
+type DocStringCodeHighlight struct {
+	Class   string
+	Content DocString
+}
+
 type DocStringRef struct {
 	RefId   string
 	KindRef string
 	Content DocString
 }
 
+// DocStringUlink is a link to an external URL.
+type DocStringUlink struct {
+	Url     string
+	Content DocString
+}
+
 type DocStringAnchor struct {
 	Id string
 }
@@ -229,6 +312,16 @@ type InnerCompoundRef struct {
 	Value      string
 }
 
+// IncludeRef is an #include of a file.
+type IncludeRef struct {
+	// RefId is the file compound of the included file, empty for files
+	// outside of the doc set, like system headers.
+	RefId string
+	Name  string
+	// Local is true for includes in quotes rather than angle brackets.
+	Local bool
+}
+
 type EnumDoc struct {
 	Descriptions
 
@@ -253,8 +346,9 @@ type FriendDoc struct {
 }
 
 type FunctionParam struct {
-	Type     DocString
-	DeclName string
+	Type         DocString
+	DeclName     string
+	DefaultValue string
 }
 
 type Reimplements struct {
@@ -319,6 +413,10 @@ type TypedefDoc struct {
 	ArgsString DocString
 }
 
+// NoParentRef is the ParentRef of the refs to compounds, which aren't members
+// of another compound.
+const NoParentRef = "N/D"
+
 type CompoundRef struct {
 	Kind      string
 	Name      string
@@ -344,6 +442,19 @@ type Graph struct {
 	Edges []GraphEdge
 }
 
+// Reversed returns the graph with its edges pointing the other way.
+func (g Graph) Reversed() Graph {
+	r := Graph{
+		Nodes: g.Nodes,
+		Edges: make([]GraphEdge, len(g.Edges)),
+	}
+	for i, edge := range g.Edges {
+		edge.FromId, edge.ToId = edge.ToId, edge.FromId
+		r.Edges[i] = edge
+	}
+	return r
+}
+
 func (g Graph) ResolveId(id int) *GraphNode {
 	for _, node := range g.Nodes {
 		if node.Id == id {
@@ -376,4 +487,14 @@ type CompoundDoc struct {
 	ProgramListing DocString
 
 	InheritanceGraph Graph
+
+	// Includes are the files a file includes, IncludedBy the files including
+	// it.
+	Includes   []IncludeRef
+	IncludedBy []IncludeRef
+	// IncludeGraph has edges from the files to the files they include, down
+	// from this one. IncludedByGraph has edges from the files to the files
+	// including them, up from this one.
+	IncludeGraph    Graph
+	IncludedByGraph Graph
 }
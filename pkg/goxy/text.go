@@ -0,0 +1,165 @@
+package goxy
+
+import (
+	"strings"
+)
+
+// PlainText renders a doc string without markup, for consumers that can't
+// display HTML. Paragraphs and blocks are separated by blank lines and list
+// items are put on lines starting with "- ".
+func PlainText(d DocString) string {
+	buf := &strings.Builder{}
+	writePlainText(buf, d)
+	lines := strings.Split(buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	text := strings.Join(lines, "\n")
+	for strings.Contains(text, "\n\n\n") {
+		text = strings.ReplaceAll(text, "\n\n\n", "\n\n")
+	}
+	return strings.TrimSpace(text)
+}
+
+// CodeText renders the text of a program listing, one line per code line.
+// Unlike PlainText it keeps blank lines and the indentation of the first line.
+func CodeText(d DocString) string {
+	buf := &strings.Builder{}
+	writePlainText(buf, d)
+	lines := strings.Split(buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+func writePlainText(buf *strings.Builder, d DocString) {
+	for _, element := range d.Content {
+		switch e := element.Value.(type) {
+		case DocStringText:
+			buf.WriteString(e.Content)
+		case DocStringParagraph:
+			buf.WriteString("\n\n")
+			writePlainText(buf, e.Content)
+			buf.WriteString("\n\n")
+		case DocStringSection:
+			buf.WriteString("\n\n")
+			if e.Kind != "" {
+				buf.WriteString(strings.Title(e.Kind) + ": ")
+			}
+			writePlainText(buf, e.Content)
+			buf.WriteString("\n\n")
+		case DocStringTitle:
+			writePlainText(buf, e.Content)
+			buf.WriteString("\n")
+		case DocStringHeading:
+			buf.WriteString("\n\n")
+			writePlainText(buf, e.Content)
+			buf.WriteString("\n\n")
+		case DocStringBold:
+			writePlainText(buf, e.Content)
+		case DocStringEmphasis:
+			writePlainText(buf, e.Content)
+		case DocStringComputerOutput:
+			writePlainText(buf, e.Content)
+		case DocStringTerm:
+			writePlainText(buf, e.Content)
+		case DocStringRef:
+			writePlainText(buf, e.Content)
+		case DocStringUlink:
+			// The URL is kept, unless the text already is the URL.
+			text := PlainText(e.Content)
+			buf.WriteString(text)
+			if text != e.Url {
+				if text != "" {
+					buf.WriteString(" ")
+				}
+				buf.WriteString("<" + e.Url + ">")
+			}
+		case DocStringVerbatim:
+			buf.WriteString("\n\n")
+			writePlainText(buf, e.Content)
+			buf.WriteString("\n\n")
+		case DocStringPreformatted:
+			buf.WriteString("\n\n")
+			writePlainText(buf, e.Content)
+			buf.WriteString("\n\n")
+		case DocStringHighlight:
+			buf.WriteString("\n\n")
+			writePlainText(buf, e.Content)
+			buf.WriteString("\n\n")
+		case DocStringCodeLine:
+			writePlainText(buf, e.Content)
+			buf.WriteString("\n")
+		case DocStringCodeHighlight:
+			writePlainText(buf, e.Content)
+		case DocStringXRefSect:
+			buf.WriteString("\n\n" + e.Title + ": ")
+			writePlainText(buf, e.Description)
+			buf.WriteString("\n\n")
+		case DocStringItemizedList:
+			writePlainTextItems(buf, e.Items)
+		case DocStringOrderedList:
+			writePlainTextItems(buf, e.Items)
+		case DocStringVariableList:
+			writePlainTextItems(buf, e.Items)
+		case DocStringParameterList:
+			buf.WriteString("\n\n")
+			for _, item := range e.Items {
+				buf.WriteString("- " + item.Name + ": " + strings.TrimSpace(PlainText(item.Description)) + "\n")
+			}
+			buf.WriteString("\n")
+		case DocStringTable:
+			buf.WriteString("\n\n")
+			for _, row := range e.Rows {
+				cells := make([]string, len(row))
+				for i, entry := range row {
+					cells[i] = PlainText(entry.Content)
+				}
+				buf.WriteString(strings.Join(cells, " | ") + "\n")
+			}
+			buf.WriteString("\n")
+		case DocStringLinebreak:
+			buf.WriteString("\n")
+		case DocStringHorizontalRule:
+			buf.WriteString("\n\n")
+		case DocStringBlockQuote:
+			buf.WriteString("\n\n")
+			writePlainText(buf, e.Content)
+			buf.WriteString("\n\n")
+		case DocStringDetails:
+			buf.WriteString("\n\n")
+			writePlainText(buf, e.Summary)
+			buf.WriteString("\n\n")
+			writePlainText(buf, e.Content)
+			buf.WriteString("\n\n")
+		case DocStringStrike:
+			writePlainText(buf, e.Content)
+		case DocStringUnderline:
+			writePlainText(buf, e.Content)
+		case DocStringSubscript:
+			buf.WriteString("_")
+			writePlainText(buf, e.Content)
+		case DocStringSuperscript:
+			buf.WriteString("^")
+			writePlainText(buf, e.Content)
+		case DocStringFormula:
+			buf.WriteString(e.Content)
+		case DocStringDiagram:
+			buf.WriteString("\n\n")
+			if e.Caption != "" {
+				buf.WriteString(e.Caption + "\n")
+			}
+			buf.WriteString(e.Content)
+			buf.WriteString("\n\n")
+		}
+	}
+}
+
+func writePlainTextItems(buf *strings.Builder, items []DocString) {
+	buf.WriteString("\n\n")
+	for _, item := range items {
+		buf.WriteString("- " + strings.ReplaceAll(PlainText(item), "\n", "\n  ") + "\n")
+	}
+	buf.WriteString("\n")
+}
@@ -0,0 +1,84 @@
+package goxy
+
+// Children returns the doc strings nested in an element, in document order.
+func (e DocStringElement) Children() []DocString {
+	switch v := e.Value.(type) {
+	case DocStringParagraph:
+		return []DocString{v.Content}
+	case DocStringSection:
+		return []DocString{v.Content}
+	case DocStringTitle:
+		return []DocString{v.Content}
+	case DocStringHeading:
+		return []DocString{v.Content}
+	case DocStringBold:
+		return []DocString{v.Content}
+	case DocStringEmphasis:
+		return []DocString{v.Content}
+	case DocStringVerbatim:
+		return []DocString{v.Content}
+	case DocStringPreformatted:
+		return []DocString{v.Content}
+	case DocStringComputerOutput:
+		return []DocString{v.Content}
+	case DocStringTerm:
+		return []DocString{v.Content}
+	case DocStringHighlight:
+		return []DocString{v.Content}
+	case DocStringCodeLine:
+		return []DocString{v.Content}
+	case DocStringCodeHighlight:
+		return []DocString{v.Content}
+	case DocStringRef:
+		return []DocString{v.Content}
+	case DocStringUlink:
+		return []DocString{v.Content}
+	case DocStringXRefSect:
+		return []DocString{v.Description}
+	case DocStringBlockQuote:
+		return []DocString{v.Content}
+	case DocStringDetails:
+		return []DocString{v.Summary, v.Content}
+	case DocStringStrike:
+		return []DocString{v.Content}
+	case DocStringUnderline:
+		return []DocString{v.Content}
+	case DocStringSubscript:
+		return []DocString{v.Content}
+	case DocStringSuperscript:
+		return []DocString{v.Content}
+	case DocStringItemizedList:
+		return v.Items
+	case DocStringOrderedList:
+		return v.Items
+	case DocStringVariableList:
+		return v.Items
+	case DocStringParameterList:
+		children := make([]DocString, len(v.Items))
+		for i, item := range v.Items {
+			children[i] = item.Description
+		}
+		return children
+	case DocStringTable:
+		children := make([]DocString, 0)
+		for _, row := range v.Rows {
+			for _, entry := range row {
+				children = append(children, entry.Content)
+			}
+		}
+		return children
+	default:
+		return nil
+	}
+}
+
+// Walk calls fn for every element of a doc string, and the elements nested in
+// it, parents before their children.
+func Walk(d DocString, fn func(element DocStringElement)) {
+	for _, element := range d.Content {
+		fn(element)
+		for _, child := range element.Children() {
+			Walk(child, fn)
+		}
+	}
+}
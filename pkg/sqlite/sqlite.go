@@ -0,0 +1,548 @@
+// Package sqlite writes SQLite database files without cgo, for outputs that
+// ship a database, like the search index of a Dash docset. Databases are built
+// in memory and written in one go, only tables and indexes of rows small
+// enough to not need overflow pages are supported, RowFits and IndexEntryFits
+// tell the others apart.
+package sqlite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// PageSize is the size of the pages of the written databases.
+const PageSize = 4096
+
+const (
+	tableInterior = 0x05
+	tableLeaf     = 0x0d
+	indexInterior = 0x02
+	indexLeaf     = 0x0a
+
+	// maxTableLocal and maxIndexLocal are the largest records stored without
+	// overflow pages in table and index b-trees.
+	maxTableLocal = PageSize - 35
+	maxIndexLocal = (PageSize-12)*64/255 - 23
+)
+
+// Table is a table and its rows. The rowid of a row is its position, counting
+// from 1. Values are nil, int64 or string, the column of an INTEGER PRIMARY KEY
+// is nil, SQLite reads the rowid for it.
+type Table struct {
+	Name string
+	// SQL is the CREATE TABLE statement of the table.
+	SQL  string
+	Rows [][]interface{}
+}
+
+// Index is an index on the columns of a table.
+type Index struct {
+	Name  string
+	Table string
+	// SQL is the CREATE INDEX statement of the index.
+	SQL string
+	// Columns are the positions of the indexed columns in the rows of the table.
+	Columns []int
+}
+
+// RowFits reports whether a row is small enough to be stored in a table
+// without overflow pages, which aren't supported.
+func RowFits(row []interface{}) bool {
+	return len(encodeRecord(row)) <= maxTableLocal
+}
+
+// IndexEntryFits reports whether the entry of a row in an index on values is
+// small enough to be stored without overflow pages. The size of the rowid the
+// entry ends with is taken as the largest possible.
+func IndexEntryFits(values []interface{}) bool {
+	entry := make([]interface{}, 0, len(values)+1)
+	entry = append(entry, values...)
+	entry = append(entry, int64(math.MaxInt64))
+	return len(encodeRecord(entry)) <= maxIndexLocal
+}
+
+// Database is a database file with its tables and indexes.
+type Database struct {
+	Tables  []*Table
+	Indexes []*Index
+
+	pages [][]byte
+}
+
+// WriteFile writes the database to path, replacing the file if it exists.
+func (d *Database) WriteFile(path string) error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Bytes encodes the database file.
+func (d *Database) Bytes() ([]byte, error) {
+	// Page 1 is the schema table, it is filled in once the root pages of the
+	// tables and indexes are known.
+	d.pages = [][]byte{nil}
+
+	schema := make([][]interface{}, 0, len(d.Tables)+len(d.Indexes))
+	tables := make(map[string]*Table, len(d.Tables))
+	for _, table := range d.Tables {
+		root, err := d.writeTable(table.Rows)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to write table %s: %v", table.Name, err))
+		}
+		tables[table.Name] = table
+		schema = append(schema, []interface{}{"table", table.Name, table.Name, int64(root), table.SQL})
+	}
+	for _, index := range d.Indexes {
+		table, ok := tables[index.Table]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("index %s is on unknown table %s", index.Name, index.Table))
+		}
+		root, err := d.writeIndex(table.Rows, index.Columns)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to write index %s: %v", index.Name, err))
+		}
+		schema = append(schema, []interface{}{"index", index.Name, index.Table, int64(root), index.SQL})
+	}
+
+	cells := make([]leafCell, len(schema))
+	for i, row := range schema {
+		cells[i] = leafCell{rowid: int64(i + 1), record: encodeRecord(row)}
+	}
+	page := newPage(100, tableLeaf)
+	for _, cell := range cells {
+		if !page.add(cell.bytes()) {
+			return nil, errors.New("the schema doesn't fit on the first page")
+		}
+	}
+	d.pages[0] = page.bytes()
+	d.writeHeader()
+
+	return bytes.Join(d.pages, nil), nil
+}
+
+func (d *Database) writeHeader() {
+	h := d.pages[0][:100]
+	copy(h, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(h[16:], PageSize)
+	h[18], h[19] = 1, 1 // legacy journal mode
+	h[20] = 0           // reserved bytes per page
+	h[21], h[22], h[23] = 64, 32, 32
+	binary.BigEndian.PutUint32(h[24:], 1) // file change counter
+	binary.BigEndian.PutUint32(h[28:], uint32(len(d.pages)))
+	binary.BigEndian.PutUint32(h[40:], 1) // schema cookie
+	binary.BigEndian.PutUint32(h[44:], 4) // schema format
+	binary.BigEndian.PutUint32(h[56:], 1) // UTF-8
+	binary.BigEndian.PutUint32(h[92:], 1) // version valid for
+	binary.BigEndian.PutUint32(h[96:], 3031001)
+}
+
+// allocate reserves the next page and returns its number.
+func (d *Database) allocate() int {
+	d.pages = append(d.pages, nil)
+	return len(d.pages)
+}
+
+// child is a page of a b-tree level, with the key its parent refers to it by.
+type child struct {
+	page int
+	// rowid is the largest rowid in the page of a table b-tree.
+	rowid int64
+}
+
+type leafCell struct {
+	rowid  int64
+	record []byte
+}
+
+func (c leafCell) bytes() []byte {
+	buf := putVarint(nil, int64(len(c.record)))
+	buf = putVarint(buf, c.rowid)
+	return append(buf, c.record...)
+}
+
+// writeTable writes the b-tree of a table and returns its root page.
+func (d *Database) writeTable(rows [][]interface{}) (int, error) {
+	children := make([]child, 0)
+	page := newPage(0, tableLeaf)
+	var last int64
+	flush := func() {
+		n := d.allocate()
+		d.pages[n-1] = page.bytes()
+		children = append(children, child{page: n, rowid: last})
+		page = newPage(0, tableLeaf)
+	}
+
+	for i, row := range rows {
+		cell := leafCell{rowid: int64(i + 1), record: encodeRecord(row)}
+		if len(cell.record) > maxTableLocal {
+			return 0, errors.New(fmt.Sprintf("row %d is too large", i+1))
+		}
+		if !page.add(cell.bytes()) {
+			flush()
+			page.add(cell.bytes())
+		}
+		last = cell.rowid
+	}
+	if page.count() > 0 || len(children) == 0 {
+		flush()
+	}
+
+	for len(children) > 1 {
+		children = d.writeTableLevel(children)
+	}
+	return children[0].page, nil
+}
+
+// writeTableLevel writes the interior pages above a level of a table b-tree and
+// returns them as the next level.
+func (d *Database) writeTableLevel(children []child) []child {
+	parents := make([]child, 0)
+	for start := 0; start < len(children); {
+		// The children but the right-most one get a cell keyed by their largest
+		// rowid.
+		page := newPage(0, tableInterior)
+		end := start
+		for end+1 < len(children) {
+			cell := putUint32(nil, uint32(children[end].page))
+			cell = putVarint(cell, children[end].rowid)
+			if !page.add(cell) {
+				break
+			}
+			end++
+		}
+		if len(children)-end-1 == 1 && page.count() > 1 {
+			// An interior page needs a cell, leave two children to the next one.
+			page.pop()
+			end--
+		}
+		page.right = children[end].page
+
+		n := d.allocate()
+		d.pages[n-1] = page.bytes()
+		parents = append(parents, child{page: n, rowid: children[end].rowid})
+		start = end + 1
+	}
+	return parents
+}
+
+// indexEntry is an entry of an index b-tree, the indexed values of a row
+// followed by its rowid.
+type indexEntry struct {
+	values []interface{}
+	record []byte
+}
+
+// writeIndex writes the b-tree of an index and returns its root page.
+func (d *Database) writeIndex(rows [][]interface{}, columns []int) (int, error) {
+	entries := make([]indexEntry, len(rows))
+	for i, row := range rows {
+		values := make([]interface{}, 0, len(columns)+1)
+		for _, column := range columns {
+			values = append(values, row[column])
+		}
+		values = append(values, int64(i+1))
+		entries[i] = indexEntry{values: values, record: encodeRecord(values)}
+		if len(entries[i].record) > maxIndexLocal {
+			return 0, errors.New(fmt.Sprintf("the entry of row %d is too large", i+1))
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return compareValues(entries[i].values, entries[j].values) < 0
+	})
+
+	// The entries between the pages of a level move up to the level above, to
+	// separate them.
+	children := make([]int, 0)
+	separators := make([]indexEntry, 0)
+	page := newPage(0, indexLeaf)
+	flush := func() {
+		n := d.allocate()
+		d.pages[n-1] = page.bytes()
+		children = append(children, n)
+		page = newPage(0, indexLeaf)
+	}
+	for i := 0; i < len(entries); i++ {
+		cell := putVarint(nil, int64(len(entries[i].record)))
+		cell = append(cell, entries[i].record...)
+		if page.add(cell) {
+			continue
+		}
+		if i == len(entries)-1 {
+			// A separator needs a page after it, give it the last entry of the
+			// page instead.
+			page.pop()
+			i--
+		}
+		flush()
+		separators = append(separators, entries[i])
+	}
+	flush()
+
+	for len(children) > 1 {
+		children, separators = d.writeIndexLevel(children, separators)
+	}
+	return children[0], nil
+}
+
+// writeIndexLevel writes the interior pages above a level of an index b-tree,
+// separated by separators, and returns them and their separators as the next
+// level.
+func (d *Database) writeIndexLevel(children []int, separators []indexEntry) ([]int, []indexEntry) {
+	parents := make([]int, 0)
+	parentSeparators := make([]indexEntry, 0)
+	for start := 0; start < len(children); {
+		page := newPage(0, indexInterior)
+		end := start
+		for end+1 < len(children) {
+			cell := putUint32(nil, uint32(children[end]))
+			cell = putVarint(cell, int64(len(separators[end].record)))
+			cell = append(cell, separators[end].record...)
+			if !page.add(cell) {
+				break
+			}
+			end++
+		}
+		if len(children)-end-1 == 1 && page.count() > 1 {
+			page.pop()
+			end--
+		}
+		page.right = children[end]
+
+		n := d.allocate()
+		d.pages[n-1] = page.bytes()
+		parents = append(parents, n)
+		if end+1 < len(children) {
+			parentSeparators = append(parentSeparators, separators[end])
+		}
+		start = end + 1
+	}
+	return parents, parentSeparators
+}
+
+// compareValues orders rows of values like SQLite with the BINARY collation:
+// NULL before integers before text.
+func compareValues(a []interface{}, b []interface{}) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareValue(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+func compareValue(a interface{}, b interface{}) int {
+	rank := func(v interface{}) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case int64:
+			return 1
+		default:
+			return 2
+		}
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case string:
+		return bytes.Compare([]byte(a), []byte(b.(string)))
+	}
+	return 0
+}
+
+// encodeRecord encodes values in the record format.
+func encodeRecord(values []interface{}) []byte {
+	types := make([]byte, 0, len(values))
+	body := make([]byte, 0)
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			types = putVarint(types, 0)
+		case int64:
+			serial, size := integerType(v)
+			types = putVarint(types, serial)
+			for i := size - 1; i >= 0; i-- {
+				body = append(body, byte(v>>(8*uint(i))))
+			}
+		case string:
+			types = putVarint(types, int64(13+2*len(v)))
+			body = append(body, v...)
+		default:
+			panic(fmt.Sprintf("unsupported value type %T", value))
+		}
+	}
+
+	// The size of the header includes the varint of the size itself.
+	size := int64(len(types) + 1)
+	for int64(len(putVarint(nil, size))+len(types)) != size {
+		size = int64(len(putVarint(nil, size)) + len(types))
+	}
+	record := putVarint(nil, size)
+	record = append(record, types...)
+	return append(record, body...)
+}
+
+// integerType returns the serial type of an integer and its size in bytes.
+func integerType(v int64) (int64, int) {
+	switch {
+	case v == 0:
+		return 8, 0
+	case v == 1:
+		return 9, 0
+	case v >= -1<<7 && v < 1<<7:
+		return 1, 1
+	case v >= -1<<15 && v < 1<<15:
+		return 2, 2
+	case v >= -1<<23 && v < 1<<23:
+		return 3, 3
+	case v >= -1<<31 && v < 1<<31:
+		return 4, 4
+	case v >= -1<<47 && v < 1<<47:
+		return 5, 6
+	default:
+		return 6, 8
+	}
+}
+
+// putVarint appends the SQLite varint of v to buf: big-endian groups of seven
+// bits, with a ninth byte of eight bits.
+func putVarint(buf []byte, v int64) []byte {
+	u := uint64(v)
+	if u > 0x00ffffffffffffff {
+		out := make([]byte, 9)
+		out[8] = byte(u)
+		u >>= 8
+		for i := 7; i >= 0; i-- {
+			out[i] = byte(u&0x7f) | 0x80
+			u >>= 7
+		}
+		return append(buf, out...)
+	}
+
+	out := make([]byte, 0, 9)
+	for {
+		out = append(out, byte(u&0x7f))
+		u >>= 7
+		if u == 0 {
+			break
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	for i := 0; i < len(out)-1; i++ {
+		out[i] |= 0x80
+	}
+	return append(buf, out...)
+}
+
+func putUint32(buf []byte, v uint32) []byte {
+	out := make([]byte, 4)
+	binary.BigEndian.PutUint32(out, v)
+	return append(buf, out...)
+}
+
+// page builds a b-tree page. Offset is where the page header starts, 100 on the
+// first page, after the database header.
+type page struct {
+	offset int
+	kind   byte
+	cells  [][]byte
+	size   int
+	// right is the right-most child of an interior page.
+	right int
+}
+
+func newPage(offset int, kind byte) *page {
+	p := &page{
+		offset: offset,
+		kind:   kind,
+	}
+	p.size = offset + p.headerSize()
+	return p
+}
+
+func (p *page) headerSize() int {
+	if p.kind == tableInterior || p.kind == indexInterior {
+		return 12
+	}
+	return 8
+}
+
+// cellSize is the space a cell takes, including its pointer. SQLite expects
+// cells of at least four bytes.
+func cellSize(cell []byte) int {
+	if len(cell) < 4 {
+		return 4 + 2
+	}
+	return len(cell) + 2
+}
+
+// add adds a cell if it fits on the page.
+func (p *page) add(cell []byte) bool {
+	if p.size+cellSize(cell) > PageSize {
+		return false
+	}
+	p.cells = append(p.cells, cell)
+	p.size += cellSize(cell)
+	return true
+}
+
+// pop removes the last cell.
+func (p *page) pop() {
+	last := p.cells[len(p.cells)-1]
+	p.cells = p.cells[:len(p.cells)-1]
+	p.size -= cellSize(last)
+}
+
+func (p *page) count() int {
+	return len(p.cells)
+}
+
+func (p *page) bytes() []byte {
+	buf := make([]byte, PageSize)
+	h := buf[p.offset:]
+	h[0] = p.kind
+	binary.BigEndian.PutUint16(h[3:], uint16(len(p.cells)))
+	if p.headerSize() == 12 {
+		binary.BigEndian.PutUint32(h[8:], uint32(p.right))
+	}
+
+	content := PageSize
+	pointers := p.offset + p.headerSize()
+	for i, cell := range p.cells {
+		size := cellSize(cell) - 2
+		content -= size
+		copy(buf[content:], cell)
+		binary.BigEndian.PutUint16(buf[pointers+2*i:], uint16(content))
+	}
+	// A content area starting at the end of the page is written as 0, for
+	// pages of 65536 bytes.
+	binary.BigEndian.PutUint16(h[5:], uint16(content))
+	return buf
+}
@@ -0,0 +1,368 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// reader reads the b-trees of a written database back, checking their
+// structure along the way like the integrity check of SQLite does.
+type reader struct {
+	t    *testing.T
+	data []byte
+	used map[int]bool
+}
+
+func newReader(t *testing.T, data []byte) *reader {
+	t.Helper()
+	if len(data)%PageSize != 0 {
+		t.Fatalf("file of %d bytes isn't made of pages", len(data))
+	}
+	if string(data[:16]) != "SQLite format 3\x00" {
+		t.Fatalf("header starts with %q", data[:16])
+	}
+	if size := binary.BigEndian.Uint16(data[16:]); size != PageSize {
+		t.Errorf("page size = %d, want %d", size, PageSize)
+	}
+	if count := int(binary.BigEndian.Uint32(data[28:])); count != len(data)/PageSize {
+		t.Errorf("header counts %d pages, the file has %d", count, len(data)/PageSize)
+	}
+	return &reader{t: t, data: data, used: make(map[int]bool)}
+}
+
+// btreePage is a decoded b-tree page.
+type btreePage struct {
+	kind  byte
+	cells [][]byte
+	right int
+}
+
+// page decodes page n, each page may only be part of one b-tree once.
+func (r *reader) page(n int) btreePage {
+	r.t.Helper()
+	if n < 1 || n > len(r.data)/PageSize {
+		r.t.Fatalf("page %d is out of the file", n)
+	}
+	if r.used[n] {
+		r.t.Fatalf("page %d is referenced twice", n)
+	}
+	r.used[n] = true
+
+	data := r.data[(n-1)*PageSize : n*PageSize]
+	offset := 0
+	if n == 1 {
+		offset = 100
+	}
+	h := data[offset:]
+	p := btreePage{kind: h[0]}
+	headerSize := 8
+	switch p.kind {
+	case tableInterior, indexInterior:
+		headerSize = 12
+		p.right = int(binary.BigEndian.Uint32(h[8:]))
+	case tableLeaf, indexLeaf:
+	default:
+		r.t.Fatalf("page %d has the unknown kind %#x", n, p.kind)
+	}
+
+	count := int(binary.BigEndian.Uint16(h[3:]))
+	content := int(binary.BigEndian.Uint16(h[5:]))
+	pointers := offset + headerSize
+	if pointers+2*count > content {
+		r.t.Fatalf("the cell pointers of page %d run into its content at %d", n, content)
+	}
+	end := PageSize
+	for i := 0; i < count; i++ {
+		start := int(binary.BigEndian.Uint16(data[pointers+2*i:]))
+		size := cellLength(p.kind, data[start:])
+		// The cells are written from the end of the page backwards.
+		if start < content || start+size > end {
+			r.t.Fatalf("cell %d of page %d at %d-%d overlaps another one or the header", i, n, start, start+size)
+		}
+		p.cells = append(p.cells, data[start:start+size])
+		end = start
+	}
+	return p
+}
+
+// cellLength returns the length of the cell of a page kind at the start of b.
+func cellLength(kind byte, b []byte) int {
+	switch kind {
+	case tableInterior:
+		_, n := readVarint(b[4:])
+		return 4 + n
+	case tableLeaf:
+		size, n := readVarint(b)
+		_, m := readVarint(b[n:])
+		return n + m + int(size)
+	case indexInterior:
+		size, n := readVarint(b[4:])
+		return 4 + n + int(size)
+	default:
+		size, n := readVarint(b)
+		return n + int(size)
+	}
+}
+
+// table returns the rows of the table b-tree at root by rowid and its depth.
+func (r *reader) table(root int) (map[int64][]interface{}, int) {
+	rows := make(map[int64][]interface{})
+	var last int64
+	depth := r.tableNode(root, rows, &last, 1<<62)
+	return rows, depth
+}
+
+// tableNode walks the subtree at n, whose rowids follow last and are at most
+// max, and returns its depth.
+func (r *reader) tableNode(n int, rows map[int64][]interface{}, last *int64, max int64) int {
+	r.t.Helper()
+	p := r.page(n)
+	if p.kind == tableLeaf {
+		for _, cell := range p.cells {
+			size, i := readVarint(cell)
+			rowid, j := readVarint(cell[i:])
+			if rowid <= *last || rowid > max {
+				r.t.Fatalf("rowid %d on page %d is out of order after %d, at most %d", rowid, n, *last, max)
+			}
+			*last = rowid
+			rows[rowid] = decodeRecord(r.t, cell[i+j:i+j+int(size)])
+		}
+		return 1
+	}
+	if p.kind != tableInterior || len(p.cells) == 0 {
+		r.t.Fatalf("page %d of a table b-tree has kind %#x and %d cells", n, p.kind, len(p.cells))
+	}
+
+	depth := 0
+	for _, cell := range p.cells {
+		key, _ := readVarint(cell[4:])
+		if key > max {
+			r.t.Fatalf("key %d on page %d is above %d", key, n, max)
+		}
+		d := r.tableNode(int(binary.BigEndian.Uint32(cell)), rows, last, key)
+		if depth != 0 && d != depth {
+			r.t.Fatalf("the children of page %d have different depths", n)
+		}
+		depth = d
+	}
+	if d := r.tableNode(p.right, rows, last, max); d != depth {
+		r.t.Fatalf("the right child of page %d has another depth", n)
+	}
+	return depth + 1
+}
+
+// index returns the entries of the index b-tree at root in order and its
+// depth.
+func (r *reader) index(root int) ([][]interface{}, int) {
+	entries := make([][]interface{}, 0)
+	depth := r.indexNode(root, &entries)
+	return entries, depth
+}
+
+func (r *reader) indexNode(n int, entries *[][]interface{}) int {
+	r.t.Helper()
+	p := r.page(n)
+	if p.kind == indexLeaf {
+		for _, cell := range p.cells {
+			size, i := readVarint(cell)
+			*entries = append(*entries, decodeRecord(r.t, cell[i:i+int(size)]))
+		}
+		return 1
+	}
+	if p.kind != indexInterior || len(p.cells) == 0 {
+		r.t.Fatalf("page %d of an index b-tree has kind %#x and %d cells", n, p.kind, len(p.cells))
+	}
+
+	depth := 0
+	for _, cell := range p.cells {
+		d := r.indexNode(int(binary.BigEndian.Uint32(cell)), entries)
+		if depth != 0 && d != depth {
+			r.t.Fatalf("the children of page %d have different depths", n)
+		}
+		depth = d
+		size, i := readVarint(cell[4:])
+		*entries = append(*entries, decodeRecord(r.t, cell[4+i:4+i+int(size)]))
+	}
+	if d := r.indexNode(p.right, entries); d != depth {
+		r.t.Fatalf("the right child of page %d has another depth", n)
+	}
+	return depth + 1
+}
+
+func readVarint(b []byte) (int64, int) {
+	var v uint64
+	for i := 0; i < 8; i++ {
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return int64(v), i + 1
+		}
+	}
+	return int64(v<<8 | uint64(b[8])), 9
+}
+
+func decodeRecord(t *testing.T, b []byte) []interface{} {
+	t.Helper()
+	headerSize, n := readVarint(b)
+	types := make([]int64, 0)
+	for i := n; i < int(headerSize); {
+		serial, m := readVarint(b[i:])
+		types = append(types, serial)
+		i += m
+	}
+
+	values := make([]interface{}, 0, len(types))
+	body := b[headerSize:]
+	for _, serial := range types {
+		switch {
+		case serial == 0:
+			values = append(values, nil)
+		case serial == 8:
+			values = append(values, int64(0))
+		case serial == 9:
+			values = append(values, int64(1))
+		case serial >= 1 && serial <= 6:
+			size := []int{0, 1, 2, 3, 4, 6, 8}[serial]
+			v := int64(int8(body[0]))
+			for _, c := range body[1:size] {
+				v = v<<8 | int64(c)
+			}
+			values = append(values, v)
+			body = body[size:]
+		case serial >= 13 && serial%2 == 1:
+			size := int((serial - 13) / 2)
+			values = append(values, string(body[:size]))
+			body = body[size:]
+		default:
+			t.Fatalf("unexpected serial type %d", serial)
+		}
+	}
+	if len(body) != 0 {
+		t.Fatalf("%d bytes left after the record", len(body))
+	}
+	return values
+}
+
+func testRows(count int, length int) [][]interface{} {
+	rows := make([][]interface{}, count)
+	for i := range rows {
+		// The names are out of order, so the index sorts them.
+		name := fmt.Sprintf("%05d", (i*7919)%count) + strings.Repeat("x", length)
+		rows[i] = []interface{}{nil, name, int64(i - count/2)}
+	}
+	return rows
+}
+
+func TestDatabaseBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		rows  [][]interface{}
+		depth int
+		// indexDepth is the depth of the index b-tree, its entries are
+		// larger and move up, so it grows faster.
+		indexDepth int
+	}{
+		{name: "empty", rows: [][]interface{}{}, depth: 1, indexDepth: 1},
+		{name: "one page", rows: testRows(10, 10), depth: 1, indexDepth: 1},
+		{name: "two levels", rows: testRows(2000, 10), depth: 2, indexDepth: 2},
+		{name: "three levels", rows: testRows(5000, 200), depth: 2, indexDepth: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &Database{
+				Tables: []*Table{
+					{Name: "t", SQL: "CREATE TABLE t(id INTEGER PRIMARY KEY, name TEXT, n INTEGER)", Rows: tt.rows},
+				},
+				Indexes: []*Index{
+					{Name: "i", Table: "t", SQL: "CREATE INDEX i ON t (name, n)", Columns: []int{1, 2}},
+				},
+			}
+			data, err := db.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := newReader(t, data)
+
+			schema, depth := r.table(1)
+			if depth != 1 || len(schema) != 2 {
+				t.Fatalf("schema = %v of depth %d, want 2 rows on the first page", schema, depth)
+			}
+			tableRoot, indexRoot := schema[1][3].(int64), schema[2][3].(int64)
+			wantSchema := [][]interface{}{
+				{"table", "t", "t", tableRoot, db.Tables[0].SQL},
+				{"index", "i", "t", indexRoot, db.Indexes[0].SQL},
+			}
+			if got := [][]interface{}{schema[1], schema[2]}; !reflect.DeepEqual(got, wantSchema) {
+				t.Errorf("schema = %v, want %v", got, wantSchema)
+			}
+
+			rows, depth := r.table(int(tableRoot))
+			if depth != tt.depth {
+				t.Errorf("table depth = %d, want %d", depth, tt.depth)
+			}
+			if len(rows) != len(tt.rows) {
+				t.Fatalf("table has %d rows, want %d", len(rows), len(tt.rows))
+			}
+			for i, row := range tt.rows {
+				if got := rows[int64(i+1)]; !reflect.DeepEqual(got, row) {
+					t.Fatalf("row %d = %v, want %v", i+1, got, row)
+				}
+			}
+
+			entries, depth := r.index(int(indexRoot))
+			if depth != tt.indexDepth {
+				t.Errorf("index depth = %d, want %d", depth, tt.indexDepth)
+			}
+			if len(entries) != len(tt.rows) {
+				t.Fatalf("index has %d entries, want %d", len(entries), len(tt.rows))
+			}
+			for i, entry := range entries {
+				if i > 0 && compareValues(entries[i-1], entry) >= 0 {
+					t.Fatalf("index entry %d %v isn't after %v", i, entry, entries[i-1])
+				}
+				row := tt.rows[entry[2].(int64)-1]
+				if !reflect.DeepEqual(entry[:2], []interface{}{row[1], row[2]}) {
+					t.Fatalf("index entry %v doesn't match its row %v", entry, row)
+				}
+			}
+
+			if len(r.used) != len(data)/PageSize {
+				t.Errorf("%d of %d pages are in a b-tree", len(r.used), len(data)/PageSize)
+			}
+		})
+	}
+}
+
+func TestDatabaseBytesTooLarge(t *testing.T) {
+	tests := []struct {
+		name string
+		row  []interface{}
+	}{
+		{name: "row", row: []interface{}{nil, strings.Repeat("x", PageSize)}},
+		{name: "index entry", row: []interface{}{nil, strings.Repeat("x", maxIndexLocal)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if RowFits(tt.row) && IndexEntryFits(tt.row[1:]) {
+				t.Errorf("the row fits")
+			}
+			db := &Database{
+				Tables:  []*Table{{Name: "t", Rows: [][]interface{}{tt.row}}},
+				Indexes: []*Index{{Name: "i", Table: "t", Columns: []int{1}}},
+			}
+			_, err := db.Bytes()
+			if err == nil {
+				t.Errorf("Bytes() of a row too large succeeded")
+			}
+		})
+	}
+
+	fits := []interface{}{nil, strings.Repeat("x", maxIndexLocal-20)}
+	if !RowFits(fits) || !IndexEntryFits(fits[1:]) {
+		t.Errorf("a row just below the limit doesn't fit")
+	}
+}
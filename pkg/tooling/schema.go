@@ -0,0 +1,109 @@
+package tooling
+
+// SchemaFile is the name the schema is written under, next to the database.
+const SchemaFile = "torquescript.schema.json"
+
+// Schema is the JSON schema of Database, so editor integrations can validate
+// the database they load.
+const Schema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://torque3d.org/schemas/torquescript.schema.json",
+  "title": "TorqueScript completion database",
+  "type": "object",
+  "required": ["version", "functions", "classes", "enums"],
+  "properties": {
+    "$schema": { "type": "string" },
+    "version": { "type": "integer", "const": 1 },
+    "functions": {
+      "type": "array",
+      "items": { "$ref": "#/definitions/function" }
+    },
+    "classes": {
+      "type": "array",
+      "items": { "$ref": "#/definitions/class" }
+    },
+    "enums": {
+      "type": "array",
+      "items": { "$ref": "#/definitions/enum" }
+    }
+  },
+  "definitions": {
+    "parameter": {
+      "type": "object",
+      "required": ["name"],
+      "properties": {
+        "name": { "type": "string" },
+        "type": { "type": "string" },
+        "default": { "type": "string" }
+      }
+    },
+    "function": {
+      "type": "object",
+      "required": ["name", "kind", "parameters", "signature"],
+      "properties": {
+        "name": { "type": "string" },
+        "scope": { "type": "string" },
+        "kind": { "enum": ["function", "method", "callback"] },
+        "returnType": { "type": "string" },
+        "parameters": {
+          "type": "array",
+          "items": { "$ref": "#/definitions/parameter" }
+        },
+        "variadic": { "type": "boolean" },
+        "signature": { "type": "string" },
+        "summary": { "type": "string" },
+        "documentation": { "type": "string" }
+      }
+    },
+    "field": {
+      "type": "object",
+      "required": ["name"],
+      "properties": {
+        "name": { "type": "string" },
+        "type": { "type": "string" },
+        "summary": { "type": "string" },
+        "documentation": { "type": "string" }
+      }
+    },
+    "class": {
+      "type": "object",
+      "required": ["name", "fields", "methods"],
+      "properties": {
+        "name": { "type": "string" },
+        "superclass": { "type": "string" },
+        "summary": { "type": "string" },
+        "documentation": { "type": "string" },
+        "fields": {
+          "type": "array",
+          "items": { "$ref": "#/definitions/field" }
+        },
+        "methods": {
+          "type": "array",
+          "items": { "type": "string" }
+        }
+      }
+    },
+    "enum": {
+      "type": "object",
+      "required": ["name", "values"],
+      "properties": {
+        "name": { "type": "string" },
+        "summary": { "type": "string" },
+        "documentation": { "type": "string" },
+        "values": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "required": ["name"],
+            "properties": {
+              "name": { "type": "string" },
+              "value": { "type": "string" },
+              "documentation": { "type": "string" }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
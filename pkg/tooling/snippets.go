@@ -0,0 +1,64 @@
+package tooling
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Snippet is an entry of a VS Code .code-snippets file.
+type Snippet struct {
+	Scope       string   `json:"scope,omitempty"`
+	Prefix      string   `json:"prefix"`
+	Body        []string `json:"body"`
+	Description string   `json:"description,omitempty"`
+}
+
+// SnippetScope is the VS Code language id the snippets are offered in.
+const SnippetScope = "torquescript"
+
+// BuildSnippets creates a snippet for every global function and method in the
+// database, with a tab stop for each parameter. Snippets are keyed by their
+// qualified name, which VS Code shows when the prefix is ambiguous.
+func BuildSnippets(db *Database) map[string]Snippet {
+	snippets := make(map[string]Snippet, len(db.Functions))
+	for _, f := range db.Functions {
+		if f.Kind == "callback" {
+			continue
+		}
+
+		stops := make([]string, 0, len(f.Parameters))
+		for i, p := range f.Parameters {
+			stops = append(stops, fmt.Sprintf("${%d:%s}", i+1, escapeSnippet(p.Name)))
+		}
+
+		// Methods are completed after "%obj.", namespace functions are called
+		// by their qualified name.
+		prefix := f.Name
+		key := f.Name
+		if f.Scope != "" {
+			key = f.Scope + "::" + f.Name
+			if f.Kind != "method" {
+				prefix = key
+			}
+		}
+
+		description := f.Signature
+		if f.Summary != "" {
+			description += "\n" + f.Summary
+		}
+		snippets[key] = Snippet{
+			Scope:       SnippetScope,
+			Prefix:      prefix,
+			Body:        []string{escapeSnippet(prefix) + "(" + strings.Join(stops, ", ") + ")$0"},
+			Description: description,
+		}
+	}
+	return snippets
+}
+
+// escapeSnippet escapes the characters that have a meaning in snippet bodies.
+func escapeSnippet(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "$", `\$`)
+	return strings.ReplaceAll(s, "}", `\}`)
+}
@@ -0,0 +1,245 @@
+// Package tooling derives editor tooling data for TorqueScript, a completion
+// and signature database and VS Code snippets, from the scripting docs.
+package tooling
+
+import (
+	"ScriptExecServer/pkg/goxy"
+	"sort"
+	"strings"
+)
+
+// DatabaseVersion is bumped whenever the database format changes in a way
+// that isn't backwards compatible.
+const DatabaseVersion = 1
+
+type Parameter struct {
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"`
+	Default string `json:"default,omitempty"`
+}
+
+type Function struct {
+	Name string `json:"name"`
+	// Scope is the class or namespace the function is called on, empty for
+	// global functions.
+	Scope         string      `json:"scope,omitempty"`
+	Kind          string      `json:"kind"`
+	ReturnType    string      `json:"returnType,omitempty"`
+	Parameters    []Parameter `json:"parameters"`
+	Variadic      bool        `json:"variadic,omitempty"`
+	Signature     string      `json:"signature"`
+	Summary       string      `json:"summary,omitempty"`
+	Documentation string      `json:"documentation,omitempty"`
+}
+
+type Field struct {
+	Name          string `json:"name"`
+	Type          string `json:"type,omitempty"`
+	Summary       string `json:"summary,omitempty"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+type Class struct {
+	Name          string   `json:"name"`
+	Superclass    string   `json:"superclass,omitempty"`
+	Summary       string   `json:"summary,omitempty"`
+	Documentation string   `json:"documentation,omitempty"`
+	Fields        []Field  `json:"fields"`
+	Methods       []string `json:"methods"`
+}
+
+type EnumValue struct {
+	Name          string `json:"name"`
+	Value         string `json:"value,omitempty"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+type Enum struct {
+	Name          string      `json:"name"`
+	Summary       string      `json:"summary,omitempty"`
+	Documentation string      `json:"documentation,omitempty"`
+	Values        []EnumValue `json:"values"`
+}
+
+// Database is the completion and signature database, described by Schema.
+type Database struct {
+	Schema    string     `json:"$schema,omitempty"`
+	Version   int        `json:"version"`
+	Functions []Function `json:"functions"`
+	Classes   []Class    `json:"classes"`
+	Enums     []Enum     `json:"enums"`
+}
+
+// BuildDatabase collects the functions, classes and enums of the compounds of
+// a scripting doc set. Functions documented by several compounds, such as a
+// file and a group, are only listed once.
+func BuildDatabase(compounds []*goxy.CompoundDoc) *Database {
+	db := &Database{
+		Version:   DatabaseVersion,
+		Functions: make([]Function, 0),
+		Classes:   make([]Class, 0),
+		Enums:     make([]Enum, 0),
+	}
+
+	functions := make(map[string]bool)
+	classes := make(map[string]bool)
+	enums := make(map[string]bool)
+	for _, compound := range compounds {
+		scope := ""
+		isClass := compound.Kind == goxy.Class || compound.Kind == goxy.Struct
+		switch {
+		case isClass:
+			scope = compound.Name
+		case compound.Kind == goxy.Namespace && compound.Id != goxy.EngineApiId:
+			scope = compound.Name
+		}
+
+		var class *Class
+		if isClass && !classes[compound.Name] {
+			classes[compound.Name] = true
+			db.Classes = append(db.Classes, Class{
+				Name:          compound.Name,
+				Superclass:    superclass(compound),
+				Summary:       goxy.PlainText(compound.BriefDescription),
+				Documentation: goxy.PlainText(compound.DetailedDescription),
+				Fields:        make([]Field, 0),
+				Methods:       make([]string, 0),
+			})
+			class = &db.Classes[len(db.Classes)-1]
+		}
+
+		for _, section := range compound.Sections {
+			for _, f := range section.Functions {
+				key := scope + "::" + strings.ToLower(f.Name)
+				if functions[key] {
+					continue
+				}
+				functions[key] = true
+
+				function := functionFromDoc(f, scope)
+				switch {
+				case section.Kind == goxy.UserDefined && section.Header == "Callbacks":
+					function.Kind = "callback"
+				case isClass:
+					function.Kind = "method"
+				default:
+					function.Kind = "function"
+				}
+				db.Functions = append(db.Functions, function)
+				if class != nil {
+					class.Methods = append(class.Methods, f.Name)
+				}
+			}
+
+			if class != nil {
+				for _, attr := range section.Attributes {
+					class.Fields = append(class.Fields, Field{
+						Name:          attr.Name,
+						Type:          strings.TrimSpace(goxy.PlainText(attr.Type) + goxy.PlainText(attr.ArgsString)),
+						Summary:       goxy.PlainText(attr.BriefDescription),
+						Documentation: goxy.PlainText(attr.DetailedDescription),
+					})
+				}
+			}
+
+			for _, e := range section.Enums {
+				if strings.HasPrefix(e.Name, "@") || enums[e.Name] {
+					continue
+				}
+				enums[e.Name] = true
+				db.Enums = append(db.Enums, enumFromDoc(e))
+			}
+		}
+	}
+
+	sort.SliceStable(db.Functions, func(i, j int) bool {
+		a, b := db.Functions[i], db.Functions[j]
+		if a.Scope != b.Scope {
+			return a.Scope < b.Scope
+		}
+		return a.Name < b.Name
+	})
+	sort.SliceStable(db.Classes, func(i, j int) bool {
+		return db.Classes[i].Name < db.Classes[j].Name
+	})
+	sort.SliceStable(db.Enums, func(i, j int) bool {
+		return db.Enums[i].Name < db.Enums[j].Name
+	})
+	return db
+}
+
+func functionFromDoc(f *goxy.FunctionDoc, scope string) Function {
+	function := Function{
+		Name:          f.Name,
+		Scope:         scope,
+		ReturnType:    goxy.PlainText(f.Type),
+		Parameters:    make([]Parameter, 0, len(f.Params)),
+		Summary:       goxy.PlainText(f.BriefDescription),
+		Documentation: goxy.PlainText(f.DetailedDescription),
+	}
+
+	params := make([]string, 0, len(f.Params))
+	for _, p := range f.Params {
+		if p.DeclName == "..." {
+			function.Variadic = true
+			params = append(params, "...")
+			continue
+		}
+		param := Parameter{
+			Name:    p.DeclName,
+			Type:    goxy.PlainText(p.Type),
+			Default: p.DefaultValue,
+		}
+		function.Parameters = append(function.Parameters, param)
+
+		decl := strings.TrimSpace(param.Type + " " + param.Name)
+		if param.Default != "" {
+			decl += " = " + param.Default
+		}
+		params = append(params, decl)
+	}
+
+	name := f.Name
+	if scope != "" {
+		name = scope + "::" + name
+	}
+	function.Signature = strings.TrimSpace(function.ReturnType + " " + name + "(" + strings.Join(params, ", ") + ")")
+	return function
+}
+
+func enumFromDoc(e *goxy.EnumDoc) Enum {
+	enum := Enum{
+		Name:          e.Name,
+		Summary:       goxy.PlainText(e.BriefDescription),
+		Documentation: goxy.PlainText(e.DetailedDescription),
+		Values:        make([]EnumValue, 0, len(e.Values)),
+	}
+	for _, v := range e.Values {
+		enum.Values = append(enum.Values, EnumValue{
+			Name:          v.Name,
+			Value:         strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(v.Initializer), "=")),
+			Documentation: strings.TrimSpace(goxy.PlainText(v.BriefDescription) + "\n\n" + goxy.PlainText(v.DetailedDescription)),
+		})
+	}
+	return enum
+}
+
+// superclass finds the direct base class of a compound in its inheritance
+// graph, edges point from a class to its base.
+func superclass(compound *goxy.CompoundDoc) string {
+	g := compound.InheritanceGraph
+	for _, node := range g.Nodes {
+		if node.RefId != compound.Id {
+			continue
+		}
+		for _, edge := range g.Edges {
+			if edge.FromId != node.Id {
+				continue
+			}
+			if base := g.ResolveId(edge.ToId); base != nil {
+				return base.Label
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/executor"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxScriptSize limits the size of the script bodies accepted by /eval.
+const maxScriptSize = 64 * 1024
+
+// EvalResponse is the body of /eval responses. ErrorKind is set when the script
+// exited with an error or hit one of the limits.
+type EvalResponse struct {
+	Stdout     string             `json:"stdout"`
+	Stderr     string             `json:"stderr"`
+	ExitCode   int64              `json:"exitCode"`
+	DurationMs int64              `json:"durationMs"`
+	Error      string             `json:"error,omitempty"`
+	ErrorKind  executor.ErrorKind `json:"errorKind,omitempty"`
+}
+
+type Server struct {
+	Pool *WorkerPool
+	// AllowOrigin is sent as Access-Control-Allow-Origin, so the docs site can
+	// call the server from another origin.
+	AllowOrigin string
+}
+
+func NewServer(pool *WorkerPool, allowOrigin string) *Server {
+	return &Server{
+		Pool:        pool,
+		AllowOrigin: allowOrigin,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eval", s.HandleEval)
+	mux.HandleFunc("/metrics", s.HandleMetrics)
+	return mux
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+func (s *Server) HandleEval(w http.ResponseWriter, r *http.Request) {
+	if s.AllowOrigin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", s.AllowOrigin)
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	}
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPost:
+	default:
+		w.Header().Set("Allow", "POST, OPTIONS")
+		s.writeJSON(w, http.StatusMethodNotAllowed, EvalResponse{Error: "only POST is supported"})
+		return
+	}
+
+	// One byte more than allowed is read, to tell scripts that are too large
+	// apart from bodies that couldn't be read.
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxScriptSize+1))
+	if err != nil {
+		s.writeJSON(w, http.StatusBadRequest, EvalResponse{
+			Error: fmt.Sprintf("unable to read the script: %v", err),
+		})
+		return
+	}
+	if len(body) > maxScriptSize {
+		s.writeJSON(w, http.StatusRequestEntityTooLarge, EvalResponse{
+			Error: fmt.Sprintf("script is larger than %d bytes", maxScriptSize),
+		})
+		return
+	}
+	script := string(body)
+	if strings.TrimSpace(script) == "" {
+		s.writeJSON(w, http.StatusBadRequest, EvalResponse{Error: "empty script"})
+		return
+	}
+
+	start := time.Now()
+	j := NewJob(script, r.Context())
+	err = s.Pool.Push(j)
+	if err == ErrBusy || err == ErrStopped {
+		w.Header().Set("Retry-After", "1")
+		s.writeJSON(w, http.StatusServiceUnavailable, EvalResponse{Error: err.Error()})
+		return
+	}
+	result, err := j.GetOutput()
+	var evalErr *executor.Error
+	if errors.As(err, &evalErr) {
+		s.writeJSON(w, http.StatusOK, EvalResponse{
+			Stdout:     result.Stdout,
+			Stderr:     result.Stderr,
+			ExitCode:   result.ExitCode,
+			DurationMs: result.Duration.Milliseconds(),
+			Error:      evalErr.Error(),
+			ErrorKind:  evalErr.Kind,
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to evaluate script: %+v", err)
+		s.writeJSON(w, http.StatusInternalServerError, EvalResponse{
+			DurationMs: time.Since(start).Milliseconds(),
+			Error:      err.Error(),
+		})
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, EvalResponse{
+		Stdout:     result.Stdout,
+		Stderr:     result.Stderr,
+		ExitCode:   result.ExitCode,
+		DurationMs: result.Duration.Milliseconds(),
+	})
+}
+
+func (s *Server) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.Pool.Metrics())
+}
+
+func RunServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":3000", "address to listen on")
+	allowOrigin := fs.String("allow-origin", "", "value of the Access-Control-Allow-Origin header, disabled when empty")
+	workers := fs.Int("workers", 2, "number of scripts evaluated at the same time")
+	queueSize := fs.Int("queue", 16, "number of scripts that can wait for a worker before requests are rejected")
+	backend := fs.String("executor", "docker", "how scripts are run: docker, local or fake")
+	image := fs.String("image", executor.DefaultImage, "image the docker executor runs scripts in")
+	warm := fs.Int("warm", 0, "number of pre-created containers the docker executor keeps ready, 0 disables the pool, needs an image with the files/main.cs of this repository")
+	warmDir := fs.String("warm-dir", filepath.Join(os.TempDir(), "t3deval"), "directory the scripts of pre-created containers are written to, must be visible to the docker daemon")
+	binary := fs.String("binary", "", "Torque3D binary the local executor runs scripts with")
+	mainScript := fs.String("main", "files/main.cs", "main.cs the local executor runs scripts with")
+	limits := executor.DefaultLimits()
+	limits.RegisterFlags(fs)
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var e executor.Executor
+	switch *backend {
+	case "docker":
+		var d *executor.Docker
+		d, err = executor.NewDocker(*image, limits)
+		if err != nil || *warm == 0 {
+			e = d
+			break
+		}
+		var w *executor.Warm
+		w, err = executor.NewWarm(d, *warm, *warmDir)
+		if err != nil {
+			break
+		}
+		err = w.Check(ctx)
+		if err != nil {
+			break
+		}
+		w.Start(ctx)
+		defer func() {
+			_ = w.Close()
+		}()
+		e = w
+	case "local":
+		e, err = executor.NewLocal(*binary, *mainScript, limits)
+	case "fake":
+		e = executor.NewFake()
+	default:
+		err = errors.Errorf("unknown executor %s", *backend)
+	}
+	if err != nil {
+		return err
+	}
+
+	pool := NewWorkerPool(*workers, *queueSize, e)
+	pool.Start(ctx)
+
+	server := &http.Server{
+		Addr:    *addr,
+		Handler: NewServer(pool, *allowOrigin).Handler(),
+	}
+
+	// Shut down cleanly on interrupts, so pre-created containers are removed.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-signals
+		log.Printf("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), limits.Timeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Listening on %s with %d %s workers", *addr, *workers, *backend)
+	err = server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		<-stopped
+		return nil
+	}
+	return errors.WithStack(err)
+}
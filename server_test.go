@@ -0,0 +1,142 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/executor"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// failingReader fails like the body of a client that disconnected.
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+func TestHandleEval(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   io.Reader
+		// started is false for a pool without workers and without a queue,
+		// which rejects every job.
+		started    bool
+		wantStatus int
+		wantStdout string
+	}{
+		{
+			name:       "evaluates",
+			method:     http.MethodPost,
+			body:       strings.NewReader(`echo("hi");`),
+			started:    true,
+			wantStatus: http.StatusOK,
+			wantStdout: `echo("hi");`,
+		},
+		{
+			name:       "empty script",
+			method:     http.MethodPost,
+			body:       strings.NewReader("  \n"),
+			started:    true,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unreadable body",
+			method:     http.MethodPost,
+			body:       failingReader{},
+			started:    true,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodGet,
+			started:    true,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "too large",
+			method:     http.MethodPost,
+			body:       strings.NewReader(strings.Repeat("a", maxScriptSize+1)),
+			started:    true,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "largest allowed",
+			method:     http.MethodPost,
+			body:       strings.NewReader(strings.Repeat("a", maxScriptSize)),
+			started:    true,
+			wantStatus: http.StatusOK,
+			wantStdout: strings.Repeat("a", maxScriptSize),
+		},
+		{
+			name:       "busy",
+			method:     http.MethodPost,
+			body:       strings.NewReader(`echo("hi");`),
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queueSize := 0
+			if tt.started {
+				queueSize = 1
+			}
+			pool := NewWorkerPool(1, queueSize, executor.NewFake())
+			if tt.started {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				pool.Start(ctx)
+			}
+
+			r := httptest.NewRequest(tt.method, "/eval", tt.body)
+			w := httptest.NewRecorder()
+			NewServer(pool, "").Handler().ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var response EvalResponse
+			err := json.NewDecoder(w.Body).Decode(&response)
+			if err != nil {
+				t.Fatalf("unable to decode the response: %v", err)
+			}
+			if response.Stdout != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", response.Stdout, tt.wantStdout)
+			}
+			if (response.Error != "") != (tt.wantStatus != http.StatusOK) {
+				t.Errorf("error = %q for status %d", response.Error, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandleEvalScriptError(t *testing.T) {
+	fake := executor.NewFake()
+	fake.Func = func(script string) (*executor.Result, error) {
+		result := &executor.Result{Stderr: "syntax error", ExitCode: 1}
+		return result, &executor.Error{Kind: executor.ScriptError, Result: result}
+	}
+	pool := NewWorkerPool(1, 1, fake)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+
+	r := httptest.NewRequest(http.MethodPost, "/eval", strings.NewReader("echo("))
+	w := httptest.NewRecorder()
+	NewServer(pool, "").Handler().ServeHTTP(w, r)
+
+	var response EvalResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || response.ErrorKind != executor.ScriptError || response.Stderr != "syntax error" || response.ExitCode != 1 {
+		t.Errorf("got status %d and %+v, want 200 with the script error", w.Code, response)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+)
+
+// RunSite writes the doc sets as a standalone HTML site to the site folder,
+// with the folder of every doc set named after its section.
+func RunSite(cfg *Config, sets []*DocSet) error {
+	siteCfg := *cfg
+	siteCfg.Format = "html"
+	siteCfg.ContentDir = cfg.SiteDir
+	siteCfg.Incremental = false
+
+	siteSets := make([]*DocSet, len(sets))
+	for i, set := range sets {
+		siteSet := *set
+		siteSet.Output = filepath.Join(cfg.SiteDir, set.Section)
+		siteSets[i] = &siteSet
+	}
+
+	err := RunAll(&siteCfg, siteSets)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Wrote the site to %s", cfg.SiteDir)
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/formatter"
+	"ScriptExecServer/pkg/tooling"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"log"
+	"path/filepath"
+)
+
+// RunTooling writes the completion database, its schema and the VS Code
+// snippets built from the configured scripting doc set.
+func RunTooling(cfg *Config, sets []*DocSet) error {
+	var set *DocSet
+	for _, s := range sets {
+		if s.Name == cfg.Tooling.DocSet {
+			set = s
+			break
+		}
+	}
+	if set == nil {
+		return errors.New(fmt.Sprintf("tooling doc set %s is not loaded", cfg.Tooling.DocSet))
+	}
+
+	db := tooling.BuildDatabase(set.Compounds)
+	db.Schema = "./" + tooling.SchemaFile
+
+	data, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = formatter.WriteFileIfChanged(cfg.Tooling.Completion, data)
+	if err != nil {
+		return err
+	}
+
+	schemaPath := filepath.Join(filepath.Dir(cfg.Tooling.Completion), tooling.SchemaFile)
+	_, err = formatter.WriteFileIfChanged(schemaPath, []byte(tooling.Schema))
+	if err != nil {
+		return err
+	}
+
+	if cfg.Tooling.Snippets != "" {
+		data, err = json.MarshalIndent(tooling.BuildSnippets(db), "", "  ")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = formatter.WriteFileIfChanged(cfg.Tooling.Snippets, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Wrote %d functions, %d classes and %d enums to %s", len(db.Functions), len(db.Classes), len(db.Enums), cfg.Tooling.Completion)
+	return nil
+}
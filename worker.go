@@ -1,26 +1,41 @@
 package main
 
-import "context"
+import (
+	"ScriptExecServer/pkg/executor"
+	"context"
+	"github.com/pkg/errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBusy is returned by WorkerPool.Push when the queue is full.
+var ErrBusy = errors.New("too many scripts are waiting to be evaluated, try again later")
+
+// ErrStopped is returned for jobs pushed to or still queued in a WorkerPool
+// whose workers stopped.
+var ErrStopped = errors.New("the server is shutting down")
 
 type JobResult struct {
-	output string
+	output *executor.Result
 	err    error
 }
 
 type Job struct {
 	script        string
-	cb            func(output string, err error)
+	cb            func(output *executor.Result, err error)
 	outputChannel chan JobResult
 	ctx           context.Context
+	queued        time.Time
 }
 
 func NewJob(script string, ctx context.Context) *Job {
 	j := &Job{
 		script:        script,
-		outputChannel: make(chan JobResult),
+		outputChannel: make(chan JobResult, 1),
 		ctx:           ctx,
 	}
-	j.cb = func(output string, err error) {
+	j.cb = func(output *executor.Result, err error) {
 		j.outputChannel <- JobResult{
 			output: output,
 			err:    err,
@@ -29,31 +44,203 @@ func NewJob(script string, ctx context.Context) *Job {
 	return j
 }
 
-func (j *Job) GetOutput() (string, error) {
+func (j *Job) GetOutput() (*executor.Result, error) {
 	res := <-j.outputChannel
 	return res.output, res.err
 }
 
-type Worker struct {
-	queue chan *Job
+// LatencyStats summarizes a set of durations in milliseconds.
+type LatencyStats struct {
+	Count  int64 `json:"count"`
+	MeanMs int64 `json:"meanMs"`
+	MaxMs  int64 `json:"maxMs"`
+}
+
+type latency struct {
+	count int64
+	total time.Duration
+	max   time.Duration
+}
+
+func (l *latency) add(d time.Duration) {
+	l.count++
+	l.total += d
+	if d > l.max {
+		l.max = d
+	}
+}
+
+func (l *latency) stats() LatencyStats {
+	s := LatencyStats{
+		Count: l.count,
+		MaxMs: l.max.Milliseconds(),
+	}
+	if l.count > 0 {
+		s.MeanMs = (l.total / time.Duration(l.count)).Milliseconds()
+	}
+	return s
+}
+
+// PoolMetrics is a snapshot of the state of a WorkerPool. Completed counts the
+// scripts that ran, including those that exited with an error or hit a limit.
+type PoolMetrics struct {
+	Workers    int   `json:"workers"`
+	QueueSize  int   `json:"queueSize"`
+	QueueDepth int   `json:"queueDepth"`
+	Running    int64 `json:"running"`
+	Completed  int64 `json:"completed"`
+	Failed     int64 `json:"failed"`
+	Cancelled  int64 `json:"cancelled"`
+	Rejected   int64 `json:"rejected"`
+	// QueueLatency is the time jobs spent waiting for a worker.
+	QueueLatency LatencyStats `json:"queueLatency"`
+	// RunLatency is the time workers spent evaluating jobs.
+	RunLatency LatencyStats `json:"runLatency"`
+}
+
+// WorkerPool evaluates jobs with an Executor on a fixed number of workers.
+type WorkerPool struct {
+	workers  int
+	executor executor.Executor
+	queue    chan *Job
+
+	running   int64
+	completed int64
+	failed    int64
+	cancelled int64
+	rejected  int64
+
+	mu           sync.Mutex
+	queueLatency latency
+	runLatency   latency
+
+	// stopMu guards stopped, which is set once the workers stop. Push holds it
+	// while queueing, so no job is queued after the queue has been drained.
+	stopMu  sync.Mutex
+	stopped bool
+}
+
+func NewWorkerPool(workers int, queueSize int, e executor.Executor) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	return &WorkerPool{
+		workers:  workers,
+		executor: e,
+		queue:    make(chan *Job, queueSize),
+	}
+}
+
+// Push queues the job, or returns ErrBusy if the queue is full and ErrStopped
+// if the workers stopped.
+func (p *WorkerPool) Push(j *Job) error {
+	p.stopMu.Lock()
+	defer p.stopMu.Unlock()
+	if p.stopped {
+		atomic.AddInt64(&p.rejected, 1)
+		return ErrStopped
+	}
+
+	j.queued = time.Now()
+	select {
+	case p.queue <- j:
+		return nil
+	default:
+		atomic.AddInt64(&p.rejected, 1)
+		return ErrBusy
+	}
 }
 
-func NewWorker() *Worker {
-	return &Worker{
-		queue: make(chan *Job),
+// Start starts the workers, they stop once ctx is done. The jobs that are
+// still queued then fail with ErrStopped.
+func (p *WorkerPool) Start(ctx context.Context) {
+	for i := 0; i < p.workers; i++ {
+		go p.work(ctx)
 	}
+	go p.stop(ctx)
 }
 
-func (w *Worker) Push(j *Job) {
-	w.queue <- j
+func (p *WorkerPool) stop(ctx context.Context) {
+	<-ctx.Done()
+
+	p.stopMu.Lock()
+	p.stopped = true
+	p.stopMu.Unlock()
+
+	for {
+		select {
+		case j := <-p.queue:
+			atomic.AddInt64(&p.cancelled, 1)
+			j.cb(nil, ErrStopped)
+		default:
+			return
+		}
+	}
 }
 
-func (w *Worker) Work() {
+func (p *WorkerPool) work(ctx context.Context) {
 	for {
 		select {
-		case j := <-w.queue:
-			output, err := EvaluateScript(j.script, j.ctx)
-			j.cb(output, err)
+		case <-ctx.Done():
+			return
+		case j := <-p.queue:
+			p.run(j)
 		}
 	}
 }
+
+func (p *WorkerPool) run(j *Job) {
+	start := time.Now()
+	p.mu.Lock()
+	p.queueLatency.add(start.Sub(j.queued))
+	p.mu.Unlock()
+
+	// The client may have given up while the job was queued.
+	if err := j.ctx.Err(); err != nil {
+		atomic.AddInt64(&p.cancelled, 1)
+		j.cb(nil, errors.WithStack(err))
+		return
+	}
+
+	atomic.AddInt64(&p.running, 1)
+	output, err := p.executor.Evaluate(j.script, j.ctx)
+	atomic.AddInt64(&p.running, -1)
+
+	p.mu.Lock()
+	p.runLatency.add(time.Since(start))
+	p.mu.Unlock()
+
+	var evalErr *executor.Error
+	switch {
+	case err == nil || errors.As(err, &evalErr):
+		atomic.AddInt64(&p.completed, 1)
+	case j.ctx.Err() != nil:
+		atomic.AddInt64(&p.cancelled, 1)
+	default:
+		atomic.AddInt64(&p.failed, 1)
+	}
+	j.cb(output, err)
+}
+
+func (p *WorkerPool) Metrics() PoolMetrics {
+	p.mu.Lock()
+	queueLatency := p.queueLatency.stats()
+	runLatency := p.runLatency.stats()
+	p.mu.Unlock()
+
+	return PoolMetrics{
+		Workers:      p.workers,
+		QueueSize:    cap(p.queue),
+		QueueDepth:   len(p.queue),
+		Running:      atomic.LoadInt64(&p.running),
+		Completed:    atomic.LoadInt64(&p.completed),
+		Failed:       atomic.LoadInt64(&p.failed),
+		Cancelled:    atomic.LoadInt64(&p.cancelled),
+		Rejected:     atomic.LoadInt64(&p.rejected),
+		QueueLatency: queueLatency,
+		RunLatency:   runLatency,
+	}
+}
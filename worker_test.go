@@ -0,0 +1,256 @@
+package main
+
+import (
+	"ScriptExecServer/pkg/executor"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds, failing the test after a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWorkerPoolPushBusy(t *testing.T) {
+	// The pool isn't started, so nothing takes jobs off the queue.
+	pool := NewWorkerPool(1, 2, executor.NewFake())
+
+	for i := 0; i < 2; i++ {
+		err := pool.Push(NewJob("echo();", context.Background()))
+		if err != nil {
+			t.Fatalf("Push() of job %d = %v, want nil", i, err)
+		}
+	}
+	err := pool.Push(NewJob("echo();", context.Background()))
+	if err != ErrBusy {
+		t.Fatalf("Push() to a full queue = %v, want ErrBusy", err)
+	}
+
+	m := pool.Metrics()
+	if m.QueueDepth != 2 || m.Rejected != 1 {
+		t.Errorf("QueueDepth, Rejected = %d, %d, want 2, 1", m.QueueDepth, m.Rejected)
+	}
+}
+
+func TestWorkerPoolOrder(t *testing.T) {
+	fake := executor.NewFake()
+	pool := NewWorkerPool(1, 5, fake)
+
+	jobs := make([]*Job, 0)
+	want := make([]string, 0)
+	for i := 0; i < 5; i++ {
+		script := fmt.Sprintf("echo(%d);", i)
+		j := NewJob(script, context.Background())
+		err := pool.Push(j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, j)
+		want = append(want, script)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+
+	for i, j := range jobs {
+		result, err := j.GetOutput()
+		if err != nil {
+			t.Fatalf("job %d failed: %v", i, err)
+		}
+		if result.Stdout != want[i] {
+			t.Errorf("job %d printed %q, want %q", i, result.Stdout, want[i])
+		}
+	}
+	if got := fake.Scripts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("evaluated %v, want %v", got, want)
+	}
+}
+
+func TestWorkerPoolBackpressure(t *testing.T) {
+	fake := executor.NewFake()
+	fake.Delay = time.Hour
+	pool := NewWorkerPool(1, 1, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	running := NewJob("running", jobCtx)
+	err := pool.Push(running)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the first job to run", func() bool {
+		return pool.Metrics().Running == 1
+	})
+
+	err = pool.Push(NewJob("queued", jobCtx))
+	if err != nil {
+		t.Fatalf("Push() while the worker is busy = %v, want nil", err)
+	}
+	err = pool.Push(NewJob("rejected", jobCtx))
+	if err != ErrBusy {
+		t.Fatalf("Push() while the worker is busy and the queue full = %v, want ErrBusy", err)
+	}
+
+	// Once the running job is done, the queue has room again.
+	cancelJobs()
+	_, _ = running.GetOutput()
+	waitFor(t, "the queue to drain", func() bool {
+		return pool.Metrics().QueueDepth == 0
+	})
+	err = pool.Push(NewJob("accepted", context.Background()))
+	if err != nil {
+		t.Errorf("Push() after the queue drained = %v, want nil", err)
+	}
+}
+
+func TestWorkerPoolCancel(t *testing.T) {
+	fake := executor.NewFake()
+	fake.Delay = time.Hour
+	pool := NewWorkerPool(1, 2, fake)
+
+	// A job whose client gave up while it was queued isn't evaluated.
+	queuedCtx, cancelQueued := context.WithCancel(context.Background())
+	queued := NewJob("queued", queuedCtx)
+	cancelQueued()
+	err := pool.Push(queued)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+
+	_, err = queued.GetOutput()
+	if errors.Cause(err) != context.Canceled {
+		t.Errorf("cancelled queued job returned %v, want context.Canceled", err)
+	}
+	if len(fake.Scripts()) != 0 {
+		t.Errorf("cancelled queued job was evaluated")
+	}
+
+	// A job whose client gives up while it runs is stopped.
+	runningCtx, cancelRunning := context.WithCancel(context.Background())
+	running := NewJob("running", runningCtx)
+	err = pool.Push(running)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the job to run", func() bool {
+		return pool.Metrics().Running == 1
+	})
+	cancelRunning()
+	_, err = running.GetOutput()
+	if errors.Cause(err) != context.Canceled {
+		t.Errorf("cancelled running job returned %v, want context.Canceled", err)
+	}
+
+	if m := pool.Metrics(); m.Cancelled != 2 {
+		t.Errorf("Cancelled = %d, want 2", m.Cancelled)
+	}
+}
+
+func TestWorkerPoolMetrics(t *testing.T) {
+	fake := executor.NewFake()
+	fake.Func = func(script string) (*executor.Result, error) {
+		switch script {
+		case "fail":
+			return nil, errors.New("docker is gone")
+		case "error":
+			result := &executor.Result{ExitCode: 1}
+			return result, &executor.Error{Kind: executor.ScriptError, Result: result}
+		default:
+			return &executor.Result{Stdout: script}, nil
+		}
+	}
+	pool := NewWorkerPool(2, 4, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Start(ctx)
+
+	for _, script := range []string{"ok", "error", "fail", "ok"} {
+		j := NewJob(script, context.Background())
+		err := pool.Push(j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = j.GetOutput()
+	}
+
+	m := pool.Metrics()
+	want := PoolMetrics{
+		Workers:   2,
+		QueueSize: 4,
+		Completed: 3,
+		Failed:    1,
+	}
+	got := PoolMetrics{
+		Workers:    m.Workers,
+		QueueSize:  m.QueueSize,
+		QueueDepth: m.QueueDepth,
+		Running:    m.Running,
+		Completed:  m.Completed,
+		Failed:     m.Failed,
+		Cancelled:  m.Cancelled,
+		Rejected:   m.Rejected,
+	}
+	if got != want {
+		t.Errorf("Metrics() = %+v, want %+v", got, want)
+	}
+	if m.QueueLatency.Count != 4 || m.RunLatency.Count != 4 {
+		t.Errorf("latency counts = %d, %d, want 4, 4", m.QueueLatency.Count, m.RunLatency.Count)
+	}
+}
+
+func TestWorkerPoolStop(t *testing.T) {
+	fake := executor.NewFake()
+	fake.Delay = time.Hour
+	pool := NewWorkerPool(1, 2, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+	running := NewJob("running", jobCtx)
+	err := pool.Push(running)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the job to run", func() bool {
+		return pool.Metrics().Running == 1
+	})
+	queued := NewJob("queued", jobCtx)
+	err = pool.Push(queued)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	_, err = queued.GetOutput()
+	if err != ErrStopped {
+		t.Errorf("job queued when the pool stopped returned %v, want ErrStopped", err)
+	}
+	err = pool.Push(NewJob("late", context.Background()))
+	if err != ErrStopped {
+		t.Errorf("Push() after the pool stopped = %v, want ErrStopped", err)
+	}
+}